@@ -0,0 +1,152 @@
+package fsm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ExecutionMode selects how a machine serializes transitions of one instance.
+type ExecutionMode int
+
+const (
+	// ExecMutex locks a per-instance mutex around each transition, the default.
+	ExecMutex ExecutionMode = iota
+	// ExecActor runs the transitions of each instance on a dedicated
+	// goroutine that processes its mailbox in order. The goroutine exits once
+	// it has been idle for the ActorIdleTimeout.
+	ExecActor
+)
+
+// Fairness selects how ExecMutex machines hand an instance to goroutines
+// waiting for it.
+type Fairness int
+
+const (
+	// FairnessUnfair uses a sync.Mutex, the default. It favors throughput; a
+	// waiter may be overtaken by newly arriving callers for a while.
+	FairnessUnfair Fairness = iota
+	// FairnessFIFO serves waiters strictly in arrival order, so hot instances
+	// don't starve any caller.
+	FairnessFIFO
+)
+
+// WithFairness selects the fairness of the instance locks of the machine.
+// ExecActor machines always process their mailbox in arrival order.
+func WithFairness(fairness Fairness) RegisterOption {
+	return func(f *fsm) {
+		f.instanceLocks.fifo = fairness == FairnessFIFO
+	}
+}
+
+// DefaultActorIdleTimeout is how long an idle instance goroutine lives.
+const DefaultActorIdleTimeout = time.Minute
+
+// WithExecutionMode selects the execution mode of the machine.
+func WithExecutionMode(mode ExecutionMode) RegisterOption {
+	return func(f *fsm) {
+		f.mode = mode
+	}
+}
+
+// WithActorIdleTimeout overrides DefaultActorIdleTimeout for ExecActor.
+func WithActorIdleTimeout(d time.Duration) RegisterOption {
+	return func(f *fsm) {
+		f.actors.idle = d
+	}
+}
+
+// exec runs fn with exclusive access to instance s.
+func (f *fsm) exec(ctx context.Context, s interface{}, fn func() error) error {
+	if f.mode == ExecActor {
+		return f.actors.do(ctx, f.lockKey(s), fn)
+	}
+
+	unlock := f.instanceLocks.Lock(f.lockKey(s))
+	defer unlock()
+
+	return fn()
+}
+
+// actorSystem owns the goroutines of the instances of an ExecActor machine.
+type actorSystem struct {
+	mu     sync.Mutex
+	actors map[interface{}]*actor
+	idle   time.Duration
+}
+
+type actor struct {
+	mailbox chan func()
+	// pending counts messages sent or about to be sent, guarded by
+	// actorSystem.mu, so an idle actor never exits with mail on its way.
+	pending int
+}
+
+// do sends fn to the mailbox of s and waits for its result.
+func (as *actorSystem) do(ctx context.Context, s interface{}, fn func() error) error {
+	as.mu.Lock()
+	if as.actors == nil {
+		as.actors = make(map[interface{}]*actor)
+	}
+	a, ok := as.actors[s]
+	if !ok {
+		a = &actor{mailbox: make(chan func())}
+		as.actors[s] = a
+		go as.run(s, a)
+	}
+	a.pending++
+	as.mu.Unlock()
+
+	done := make(chan error, 1)
+	msg := func() { done <- fn() }
+
+	select {
+	case a.mailbox <- msg:
+	case <-ctx.Done():
+		as.mu.Lock()
+		a.pending--
+		as.mu.Unlock()
+		return ctx.Err()
+	}
+
+	return <-done
+}
+
+func (as *actorSystem) run(s interface{}, a *actor) {
+	idle := as.idle
+	if idle <= 0 {
+		idle = DefaultActorIdleTimeout
+	}
+
+	timer := time.NewTimer(idle)
+	defer timer.Stop()
+
+	for {
+		select {
+		case msg := <-a.mailbox:
+			msg()
+
+			as.mu.Lock()
+			a.pending--
+			as.mu.Unlock()
+
+			timer.Reset(idle)
+		case <-timer.C:
+			as.mu.Lock()
+			if a.pending == 0 {
+				delete(as.actors, s)
+				as.mu.Unlock()
+				return
+			}
+			as.mu.Unlock()
+			timer.Reset(idle)
+		}
+	}
+}
+
+// len returns the number of running instance goroutines.
+func (as *actorSystem) len() int {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	return len(as.actors)
+}
@@ -0,0 +1,53 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestActorExecutionMode(t *testing.T) {
+	calls := 0
+
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "ping",
+		From: []State{"started"},
+		To:   State("started"),
+		After: func(ctx context.Context, e *Event) error {
+			calls++ // serialized by the instance goroutine
+			return nil
+		},
+	}}, WithExecutionMode(ExecActor), WithActorIdleTimeout(10*time.Millisecond)); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("started")}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fsm.Fire(context.Background(), testStruct, "ping"); err != nil {
+				t.Errorf("Fire() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 50 {
+		t.Errorf("expected 50 transitions, got %d", calls)
+	}
+
+	machine, _ := fsm.machine(testStruct)
+	deadline := time.Now().Add(time.Second)
+	for machine.actors.len() != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := machine.actors.len(); n != 0 {
+		t.Errorf("expected idle instance goroutine to exit, %d running", n)
+	}
+}
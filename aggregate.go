@@ -0,0 +1,210 @@
+package fsm
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StatePair selects the time instances take to get from From to To.
+type StatePair struct {
+	From State
+	To   State
+}
+
+// EdgeCount is the number of transitions along one edge on one day.
+type EdgeCount struct {
+	// Day is midnight UTC of the day the transitions happened.
+	Day   time.Time
+	Type  string
+	Event string
+	From  State
+	To    State
+	Count int
+}
+
+// PairDuration sums the time instances that reached Pair.To on Day took
+// since they entered Pair.From.
+type PairDuration struct {
+	Day   time.Time
+	Type  string
+	Pair  StatePair
+	Count int
+	Total time.Duration
+}
+
+// AggregateSink receives the aggregates of a flush, e.g. to write them to a
+// warehouse table.
+type AggregateSink interface {
+	WriteAggregates(ctx context.Context, edges []EdgeCount, durations []PairDuration) error
+}
+
+// Aggregator is an AuditSink pre-aggregating successful transitions into
+// per-day per-edge counts and durations between state pairs, giving funnel
+// data without exporting the raw history. Use it WithAuditSink and Run it
+// to flush to Sink every Interval. Instances waiting to reach the To state
+// of a pair are forgotten once they reach a final state.
+type Aggregator struct {
+	Sink AggregateSink
+	// Interval between flushes of Run, DefaultFlushInterval if unset.
+	Interval time.Duration
+	Pairs    []StatePair
+	// Clock times Run, the system clock if nil. It must be a TimerClock to
+	// control the flushes, e.g. the fake clock of a test.
+	Clock Clock
+
+	mu        sync.Mutex
+	edges     map[EdgeCount]int
+	durations map[durationKey]*PairDuration
+	// entered holds when each instance entered the From state of a pair.
+	entered map[enteredKey]time.Time
+}
+
+type durationKey struct {
+	day  time.Time
+	typ  string
+	pair StatePair
+}
+
+type enteredKey struct {
+	instance string
+	pair     StatePair
+}
+
+// init allocates the maps emptied by Flush, the caller must hold a.mu.
+func (a *Aggregator) init() {
+	if a.edges == nil {
+		a.edges = make(map[EdgeCount]int)
+	}
+	if a.durations == nil {
+		a.durations = make(map[durationKey]*PairDuration)
+	}
+	if a.entered == nil {
+		a.entered = make(map[enteredKey]time.Time)
+	}
+}
+
+func (a *Aggregator) Record(ctx context.Context, entry HistoryEntry) error {
+	if entry.Error != "" {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.init()
+
+	day := entry.Time.UTC().Truncate(24 * time.Hour)
+	a.edges[EdgeCount{Day: day, Type: entry.Type, Event: entry.Event, From: entry.From, To: entry.To}]++
+
+	for _, pair := range a.Pairs {
+		key := enteredKey{instance: entry.Instance, pair: pair}
+
+		if entry.To == pair.To {
+			if since, ok := a.entered[key]; ok {
+				dk := durationKey{day: day, typ: entry.Type, pair: pair}
+				d, ok := a.durations[dk]
+				if !ok {
+					d = &PairDuration{Day: day, Type: entry.Type, Pair: pair}
+					a.durations[dk] = d
+				}
+				d.Count++
+				d.Total += entry.Time.Sub(since)
+				delete(a.entered, key)
+			}
+		}
+
+		switch {
+		case entry.To == pair.From:
+			a.entered[key] = entry.Time
+		case entry.Final:
+			delete(a.entered, key)
+		}
+	}
+
+	return nil
+}
+
+// Flush writes the aggregates collected since the last flush to Sink. They
+// are kept for the next flush if Sink fails.
+func (a *Aggregator) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	edges := make([]EdgeCount, 0, len(a.edges))
+	for edge, n := range a.edges {
+		edge.Count = n
+		edges = append(edges, edge)
+	}
+	durations := make([]PairDuration, 0, len(a.durations))
+	for _, d := range a.durations {
+		durations = append(durations, *d)
+	}
+	a.edges, a.durations = nil, nil
+	a.mu.Unlock()
+
+	if len(edges) == 0 && len(durations) == 0 {
+		return nil
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if !a.Day.Equal(b.Day) {
+			return a.Day.Before(b.Day)
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Event != b.Event {
+			return a.Event < b.Event
+		}
+		return a.From < b.From
+	})
+	sort.Slice(durations, func(i, j int) bool {
+		a, b := durations[i], durations[j]
+		if !a.Day.Equal(b.Day) {
+			return a.Day.Before(b.Day)
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Pair.From != b.Pair.From {
+			return a.Pair.From < b.Pair.From
+		}
+		return a.Pair.To < b.Pair.To
+	})
+
+	if err := a.Sink.WriteAggregates(ctx, edges, durations); err != nil {
+		a.restore(edges, durations)
+		return err
+	}
+	return nil
+}
+
+// restore merges the aggregates of a failed flush back.
+func (a *Aggregator) restore(edges []EdgeCount, durations []PairDuration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.init()
+
+	for _, edge := range edges {
+		n := edge.Count
+		edge.Count = 0
+		a.edges[edge] += n
+	}
+	for _, d := range durations {
+		dk := durationKey{day: d.Day, typ: d.Type, pair: d.Pair}
+		if cur, ok := a.durations[dk]; ok {
+			cur.Count += d.Count
+			cur.Total += d.Total
+			continue
+		}
+		d := d
+		a.durations[dk] = &d
+	}
+}
+
+// Run flushes every Interval until ctx is done, then flushes once more.
+func (a *Aggregator) Run(ctx context.Context) error {
+	return runFlushes(ctx, timersOf(a.Clock), a.Interval, a.Flush)
+}
@@ -0,0 +1,155 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type aggregateRecorder struct {
+	edges     []EdgeCount
+	durations []PairDuration
+	err       error
+}
+
+func (r *aggregateRecorder) WriteAggregates(ctx context.Context, edges []EdgeCount, durations []PairDuration) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.edges = append(r.edges, edges...)
+	r.durations = append(r.durations, durations...)
+	return nil
+}
+
+func TestAggregator(t *testing.T) {
+	sink := &aggregateRecorder{err: errors.New("warehouse down")}
+	agg := &Aggregator{Sink: sink, Pairs: []StatePair{{From: "created", To: "paid"}}}
+
+	fsm := NewFSM(WithAuditSink(agg))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "create",
+		From: []State{""},
+		To:   State("created"),
+	}, {
+		Name: "pay",
+		From: []State{"created"},
+		To:   State("paid"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		testStruct := &TestStruct{}
+		if err := fsm.Fire(ctx, testStruct, "create"); err != nil {
+			t.Errorf("Fire() error = %v", err)
+		}
+		if i < 2 {
+			if err := fsm.Fire(ctx, testStruct, "pay"); err != nil {
+				t.Errorf("Fire() error = %v", err)
+			}
+		}
+		// Failed attempts are not counted.
+		_ = fsm.Fire(ctx, testStruct, "create")
+	}
+
+	if err := agg.Flush(ctx); err == nil {
+		t.Errorf("Flush() to a failing sink succeeded")
+	}
+
+	sink.err = nil
+	if err := agg.Flush(ctx); err != nil {
+		t.Errorf("Flush() error = %v", err)
+	}
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	want := []EdgeCount{
+		{Day: day, Type: "*fsm.TestStruct", Event: "create", From: "", To: "created", Count: 3},
+		{Day: day, Type: "*fsm.TestStruct", Event: "pay", From: "created", To: "paid", Count: 2},
+	}
+	if !reflect.DeepEqual(sink.edges, want) {
+		t.Errorf("edges = %v, want %v", sink.edges, want)
+	}
+
+	if len(sink.durations) != 1 || sink.durations[0].Count != 2 {
+		t.Errorf("durations = %v, want 2 created->paid", sink.durations)
+	}
+
+	if err := agg.Flush(ctx); err != nil || len(sink.edges) != 2 {
+		t.Errorf("empty Flush() error = %v, edges = %v", err, sink.edges)
+	}
+}
+
+// instantTimers is a TimerClock whose timers fire at once, recording the
+// durations they were set for.
+type instantTimers struct {
+	mu    sync.Mutex
+	waits []time.Duration
+}
+
+func (c *instantTimers) Now() time.Time { return time.Now() }
+
+func (c *instantTimers) AfterFunc(d time.Duration, fn func()) func() bool {
+	c.mu.Lock()
+	c.waits = append(c.waits, d)
+	c.mu.Unlock()
+	go fn()
+	return func() bool { return false }
+}
+
+func TestAggregatorRun(t *testing.T) {
+	clock := &instantTimers{}
+	sink := &aggregateRecorder{err: errors.New("warehouse down")}
+	agg := &Aggregator{Sink: sink, Clock: clock}
+
+	ctx := context.Background()
+	if err := agg.Record(ctx, HistoryEntry{Type: "order", Event: "pay", From: "created", To: "paid", Time: time.Now()}); err != nil {
+		t.Errorf("Record() error = %v", err)
+	}
+	if err := agg.Run(ctx); !errors.Is(err, sink.err) {
+		t.Errorf("Run() error = %v, want %v", err, sink.err)
+	}
+	if len(clock.waits) != 1 || clock.waits[0] != DefaultFlushInterval {
+		t.Errorf("Run() waited %v, want DefaultFlushInterval", clock.waits)
+	}
+}
+
+func TestAggregatorRunFlushesOnExit(t *testing.T) {
+	sink := &aggregateRecorder{}
+	agg := &Aggregator{Sink: sink}
+	if err := agg.Record(context.Background(), HistoryEntry{Type: "order", Event: "pay", From: "created", To: "paid", Time: time.Now()}); err != nil {
+		t.Errorf("Record() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := agg.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+	if len(sink.edges) != 1 {
+		t.Errorf("Run() flushed %v on exit, want the pending edge", sink.edges)
+	}
+}
+
+func TestAggregatorForgetsFinalInstances(t *testing.T) {
+	agg := &Aggregator{Sink: &aggregateRecorder{}, Pairs: []StatePair{{From: "created", To: "paid"}}}
+
+	ctx := context.Background()
+	now := time.Now()
+	for _, entry := range []HistoryEntry{
+		{Instance: "a", Event: "create", To: "created", Time: now},
+		{Instance: "b", Event: "create", To: "created", Time: now},
+		{Instance: "a", Event: "cancel", From: "created", To: "canceled", Time: now, Final: true},
+	} {
+		if err := agg.Record(ctx, entry); err != nil {
+			t.Errorf("Record() error = %v", err)
+		}
+	}
+
+	if len(agg.entered) != 1 {
+		t.Errorf("Aggregator tracks %d instances, want 1", len(agg.entered))
+	}
+}
@@ -0,0 +1,46 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestOnAnyTransition(t *testing.T) {
+	fsm := NewFSM()
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	var order []string
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+		After: func(ctx context.Context, e *Event) error {
+			order = append(order, "after")
+			return nil
+		},
+	}, {
+		Name: "reset",
+		From: []State{"finished"},
+		To:   State("started"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	if err := fsm.OnAnyTransition(tag, func(ctx context.Context, e *Event) error {
+		order = append(order, "any:"+e.Event)
+		return nil
+	}); err != nil {
+		t.Errorf("OnAnyTransition() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("started")}
+	for _, event := range []string{"make", "reset", "reset"} {
+		_ = fsm.Fire(ctx, testStruct, event)
+	}
+
+	want := []string{"after", "any:make", "any:reset"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("callbacks ran %v, want %v", order, want)
+	}
+}
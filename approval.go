@@ -0,0 +1,94 @@
+package fsm
+
+import (
+	"context"
+	"slices"
+)
+
+// ApprovalsVar is the extended-state variable holding the actors that
+// approved an instance with FSM.Approve.
+const ApprovalsVar = "approvals"
+
+// DeclareApprovals declares ApprovalsVar so instances can collect approvals
+// for transitions guarded by RequireApprovals.
+func DeclareApprovals() RegisterOption {
+	return DeclareVar(ApprovalsVar, []string(nil))
+}
+
+// RequireApprovals returns a guard passing once n distinct actors approved
+// the instance. The approvals are cleared when the guarded transition
+// succeeds, so the next step starts counting from zero. FireE reports a
+// rejection by the guard as PendingApproval.
+func RequireApprovals(n int) Guard {
+	return func(ctx context.Context, e *Event) (bool, error) {
+		if e.Vars == nil {
+			return false, UnknownVarError{Name: ApprovalsVar}
+		}
+		return len(Var[[]string](e.Vars, ApprovalsVar)) >= n, nil
+	}
+}
+
+// approvalGuard is the name of the guards made by RequireApprovals.
+var approvalGuard = GuardName(RequireApprovals(0))
+
+func isApprovalGuard(g Guard) bool {
+	return GuardName(g) == approvalGuard
+}
+
+// awaitsApproval reports whether the guard named guard of event was made by
+// RequireApprovals.
+func (f *fsm) awaitsApproval(event, guard string) bool {
+	for _, g := range f.table().guards[event] {
+		if g.name == guard && g.approval {
+			return true
+		}
+	}
+	return false
+}
+
+// clearApprovals resets the approvals in vars if br was guarded by
+// RequireApprovals.
+func clearApprovals(br *branch, vars *Vars) error {
+	if vars == nil {
+		return nil
+	}
+	for _, g := range br.guards {
+		if g.approval {
+			return vars.Set(ApprovalsVar, []string(nil))
+		}
+	}
+	return nil
+}
+
+// Approve func to record that actor approved s and return the number of
+// distinct approvals. Approving twice as the same actor counts once
+func (f *FSM) Approve(ctx context.Context, s interface{}, actor string) (int, error) {
+	machine, ok := f.machine(s)
+	if !ok {
+		return 0, InternalError{}
+	}
+
+	var count int
+	err := machine.exec(ctx, s, func() error {
+		v, err := machine.loadVars(ctx, s)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return UnknownVarError{Name: ApprovalsVar}
+		}
+
+		approvals := Var[[]string](v, ApprovalsVar)
+		count = len(approvals)
+		if slices.Contains(approvals, actor) {
+			return nil
+		}
+
+		if err := v.Set(ApprovalsVar, append(slices.Clip(approvals), actor)); err != nil {
+			return err
+		}
+		count++
+		return machine.saveVars(ctx, s, v)
+	})
+	return count, err
+}
@@ -0,0 +1,51 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestApprovals(t *testing.T) {
+	fsm := NewFSM(WithIDFunc(byAddress))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:   "approve",
+		From:   []State{"review"},
+		To:     State("approved"),
+		Guards: []Guard{RequireApprovals(2)},
+	}, {
+		Name:   "publish",
+		From:   []State{"approved"},
+		To:     State("published"),
+		Guards: []Guard{RequireApprovals(1)},
+	}}, DeclareApprovals()); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	s := &TestStruct{State: "review"}
+
+	for _, actor := range []string{"alice", "alice"} {
+		if n, err := fsm.Approve(ctx, s, actor); err != nil || n != 1 {
+			t.Errorf("Approve(%s) = %d, %v, want 1", actor, n, err)
+		}
+	}
+	if result, err := fsm.FireE(ctx, s, "approve"); err == nil || result != PendingApproval {
+		t.Errorf("FireE() = %v, %v with a single approval, want PendingApproval", result, err)
+	}
+
+	if n, err := fsm.Approve(ctx, s, "bob"); err != nil || n != 2 {
+		t.Errorf("Approve(bob) = %d, %v, want 2", n, err)
+	}
+	// Evaluating the guard must not consume the approvals.
+	if ok, err := fsm.MayFire(ctx, s, "approve"); err != nil || !ok {
+		t.Errorf("MayFire() = %v, %v, want true", ok, err)
+	}
+	if err := fsm.Fire(ctx, s, "approve"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	if ok, _ := fsm.MayFire(ctx, s, "publish"); ok {
+		t.Errorf("MayFire() = true, want the approvals cleared")
+	}
+}
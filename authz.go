@@ -0,0 +1,67 @@
+package fsm
+
+import "context"
+
+type rolesKey struct{}
+
+// WithRoles returns a context carrying the roles of the caller, checked
+// against EventTransition.AllowedRoles.
+func WithRoles(ctx context.Context, roles ...string) context.Context {
+	return context.WithValue(ctx, rolesKey{}, roles)
+}
+
+// RolesFromContext returns the roles stored by WithRoles.
+func RolesFromContext(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey{}).([]string)
+	return roles
+}
+
+// Authorizer decides whether the caller in ctx may fire the event of e on
+// e.Source. allowed holds the AllowedRoles of the transition.
+type Authorizer interface {
+	Authorize(ctx context.Context, e *Event, allowed []string) (bool, error)
+}
+
+// WithAuthorizer replaces the default authorization, which requires one of
+// the roles in the context (see WithRoles) to be allowed.
+func WithAuthorizer(a Authorizer) FSMOption {
+	return func(f *FSM) {
+		f.authorizer = a
+	}
+}
+
+type roleAuthorizer struct{}
+
+func (roleAuthorizer) Authorize(ctx context.Context, e *Event, allowed []string) (bool, error) {
+	for _, role := range RolesFromContext(ctx) {
+		for _, a := range allowed {
+			if role == a {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// authorize returns PermissionDeniedError unless the caller may take br.
+// Transitions without AllowedRoles are open to everyone.
+func (f *fsm) authorize(ctx context.Context, e *Event, br *branch) error {
+	allowed := br.roles
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	authorizer := f.parent.authorizer
+	if authorizer == nil {
+		authorizer = roleAuthorizer{}
+	}
+
+	ok, err := authorizer.Authorize(ctx, e, allowed)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return PermissionDeniedError{Event: e.Event, Roles: RolesFromContext(ctx)}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAllowedRoles(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:         "approve",
+		From:         []State{"review"},
+		To:           State("approved"),
+		AllowedRoles: []string{"manager", "admin"},
+	}, {
+		Name: "comment",
+		From: []State{"review"},
+		To:   State("review"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	clerk := WithRoles(context.Background(), "clerk")
+	manager := WithRoles(context.Background(), "clerk", "manager")
+	testStruct := &TestStruct{State: State("review")}
+
+	events, err := fsm.GetPermittedEvents(clerk, testStruct)
+	if err != nil {
+		t.Errorf("GetPermittedEvents() error = %v", err)
+	}
+	if !reflect.DeepEqual(events, []string{"comment"}) {
+		t.Errorf("GetPermittedEvents() for clerk = %v, want [comment]", events)
+	}
+
+	if err := fsm.Fire(clerk, testStruct, "approve"); !errors.As(err, new(PermissionDeniedError)) {
+		t.Errorf("Fire() as clerk error = %v, want PermissionDeniedError", err)
+	}
+	if err := fsm.Fire(manager, testStruct, "approve"); err != nil {
+		t.Errorf("Fire() as manager error = %v", err)
+	}
+}
+
+func TestAllowedRolesPerTransition(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:         "cancel",
+		From:         []State{"created"},
+		To:           State("cancelled"),
+		AllowedRoles: []string{"user"},
+	}, {
+		Name:         "cancel",
+		From:         []State{"paid"},
+		To:           State("cancelled"),
+		AllowedRoles: []string{"admin"},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	user := WithRoles(context.Background(), "user")
+	if err := fsm.Fire(user, &TestStruct{State: "paid"}, "cancel"); !errors.As(err, new(PermissionDeniedError)) {
+		t.Errorf("Fire() from paid as user error = %v, want PermissionDeniedError", err)
+	}
+	if ok, err := fsm.MayFire(user, &TestStruct{State: "paid"}, "cancel", SkipGuard(true)); ok || err != nil {
+		t.Errorf("MayFire() from paid as user = %v, %v", ok, err)
+	}
+	if err := fsm.Fire(user, &TestStruct{State: "created"}, "cancel"); err != nil {
+		t.Errorf("Fire() from created as user error = %v", err)
+	}
+}
+
+type ownerAuthorizer struct{}
+
+func (ownerAuthorizer) Authorize(ctx context.Context, e *Event, allowed []string) (bool, error) {
+	actor, _ := ActorFromContext(ctx)
+	return actor == "owner", nil
+}
+
+func TestAuthorizer(t *testing.T) {
+	fsm := NewFSM(WithAuthorizer(ownerAuthorizer{}))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:         "delete",
+		From:         []State{"active"},
+		To:           State("deleted"),
+		AllowedRoles: []string{"owner"},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("active")}
+	if err := fsm.Fire(WithActor(context.Background(), "guest"), testStruct, "delete"); !errors.As(err, new(PermissionDeniedError)) {
+		t.Errorf("Fire() as guest error = %v, want PermissionDeniedError", err)
+	}
+	if err := fsm.Fire(WithActor(context.Background(), "owner"), testStruct, "delete"); err != nil {
+		t.Errorf("Fire() as owner error = %v", err)
+	}
+}
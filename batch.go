@@ -0,0 +1,67 @@
+package fsm
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBatchConcurrency is the number of instances FireAll fires at once
+// unless WithBatchConcurrency says otherwise.
+const DefaultBatchConcurrency = 16
+
+// WithBatchConcurrency bounds the number of instances FireAll fires at once.
+func WithBatchConcurrency(n int) FSMOption {
+	return func(f *FSM) {
+		f.batchConcurrency = n
+	}
+}
+
+// FireAll func to fire event on every instance concurrently. The returned
+// map holds the error of each failed instance by its index in instances;
+// instances not started before ctx is done fail with ctx.Err().
+func (f *FSM) FireAll(ctx context.Context, instances []interface{}, event string, options ...Option) map[int]error {
+	return fireAll(ctx, f, instances, event, options)
+}
+
+// FireEach is FireAll for a typed slice, e.g. []*Order.
+func FireEach[T any](ctx context.Context, f *FSM, instances []T, event string, options ...Option) map[int]error {
+	return fireAll(ctx, f, instances, event, options)
+}
+
+func fireAll[T any](ctx context.Context, f *FSM, instances []T, event string, options []Option) map[int]error {
+	workers := f.batchConcurrency
+	if workers < 1 {
+		workers = DefaultBatchConcurrency
+	}
+	sem := make(chan struct{}, workers)
+
+	errs := make(map[int]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, s := range instances {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			for j := i; j < len(instances); j++ {
+				errs[j] = ctx.Err()
+			}
+			return errs
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int, s interface{}) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := f.Fire(ctx, s, event, options...); err != nil {
+				mu.Lock()
+				errs[i] = err
+				mu.Unlock()
+			}
+		}(i, s)
+	}
+	wg.Wait()
+
+	return errs
+}
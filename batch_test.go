@@ -0,0 +1,60 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFireAll(t *testing.T) {
+	fsm := NewFSM(WithBatchConcurrency(4))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	instances := make([]*TestStruct, 100)
+	for i := range instances {
+		instances[i] = &TestStruct{State: State("started")}
+	}
+	instances[7].State = State("finished")
+	instances[42].State = State("finished")
+
+	errs := FireEach(context.Background(), fsm, instances, "make")
+	if len(errs) != 2 {
+		t.Errorf("FireEach() errors = %v, want 2", errs)
+	}
+	for _, i := range []int{7, 42} {
+		if !errors.As(errs[i], new(UnknownEventError)) {
+			t.Errorf("errs[%d] = %v, want UnknownEventError", i, errs[i])
+		}
+	}
+	for i, instance := range instances {
+		if instance.State != State("finished") {
+			t.Errorf("instances[%d].State = %s, want finished", i, instance.State)
+		}
+	}
+}
+
+func TestFireAllCanceled(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	errs := fsm.FireAll(ctx, []interface{}{&TestStruct{State: State("started")}}, "make")
+	if !errors.Is(errs[0], context.Canceled) {
+		t.Errorf("errs[0] = %v, want context.Canceled", errs[0])
+	}
+}
@@ -0,0 +1,140 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// maxFireAllocs is the allocation budget of a Fire or MayFire without
+// options, hooks or vars: the Options and the Event passed to guards and
+// callbacks.
+const maxFireAllocs = 2
+
+func newBenchFSM(b testing.TB) *FSM {
+	return newBenchFSMWith(b, 0)
+}
+
+// newBenchFSMWith registers the make and reset events and n other
+// transitions between unrelated states, to show dispatch doesn't depend on
+// the size of the machine.
+func newBenchFSMWith(b testing.TB, n int) *FSM {
+	events := Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+	}, {
+		Name: "reset",
+		From: []State{"finished"},
+		To:   State("started"),
+	}}
+	for i := 0; i < n; i++ {
+		events = append(events, EventTransition{
+			Name: fmt.Sprintf("step%d", i),
+			From: []State{State(fmt.Sprintf("s%d", i))},
+			To:   State(fmt.Sprintf("s%d", i+1)),
+		})
+	}
+
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", events); err != nil {
+		b.Fatalf("fsm.Register() error = %v", err)
+	}
+	return fsm
+}
+
+var benchSizes = []int{0, 10, 100, 1000}
+
+func BenchmarkFire(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("transitions=%d", n+2), func(b *testing.B) {
+			fsm := newBenchFSMWith(b, n)
+			ctx := context.Background()
+			testStruct := &TestStruct{State: State("started")}
+			events := [2]string{"make", "reset"}
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := fsm.Fire(ctx, testStruct, events[i%2]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMayFire(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("transitions=%d", n+2), func(b *testing.B) {
+			fsm := newBenchFSMWith(b, n)
+			ctx := context.Background()
+			testStruct := &TestStruct{State: State("started")}
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if ok, err := fsm.MayFire(ctx, testStruct, "make"); !ok || err != nil {
+					b.Fatal(ok, err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetPermittedEvents(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("transitions=%d", n+2), func(b *testing.B) {
+			fsm := newBenchFSMWith(b, n)
+			ctx := context.Background()
+			testStruct := &TestStruct{State: State("started")}
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := fsm.GetPermittedEvents(ctx, testStruct); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestFireAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+
+	fsm := newBenchFSM(t)
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("started")}
+	events := [2]string{"make", "reset"}
+
+	i := 0
+	allocs := testing.AllocsPerRun(1000, func() {
+		if err := fsm.Fire(ctx, testStruct, events[i%2]); err != nil {
+			t.Fatal(err)
+		}
+		i++
+	})
+	if allocs > maxFireAllocs {
+		t.Errorf("Fire() allocates %v times, budget is %d", allocs, maxFireAllocs)
+	}
+}
+
+func TestMayFireAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+
+	fsm := newBenchFSM(t)
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("started")}
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		if ok, err := fsm.MayFire(ctx, testStruct, "make"); !ok || err != nil {
+			t.Fatal(ok, err)
+		}
+	})
+	if allocs > maxFireAllocs {
+		t.Errorf("MayFire() allocates %v times, budget is %d", allocs, maxFireAllocs)
+	}
+}
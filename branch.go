@@ -0,0 +1,172 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// branch is one EventTransition leaving a state. Transitions sharing a name
+// and a source state are tried in order, see EventTransition.Priority.
+type branch struct {
+	// id identifies the transition among those of its machine, see
+	// transitionID.
+	id       string
+	to       State
+	priority int
+	fallback bool
+	guards   []namedGuard
+	before   Callback
+	after    Callback
+	draft    bool
+	labels   map[string]string
+	toFunc   func(context.Context, *Event) (State, error)
+	targets  []State
+	roles    []string
+	onError  string
+	retry    RetryPolicy
+	timeout  time.Duration
+	// unavailable is the GuardFallback of the transition.
+	unavailable GuardFallback
+	quota       int
+	throttle    Throttle
+}
+
+// transitionID identifies the transition e among those of its machine,
+// which keeps its ID as long as its name, states and priority don't change.
+func transitionID(e EventTransition) string {
+	from := make([]string, len(e.From))
+	for j, src := range e.From {
+		from[j] = string(src)
+	}
+	return e.Name + ":" + strings.Join(from, ",") + ":" + string(e.To) + ":" + strconv.Itoa(e.Priority)
+}
+
+// RejectAmbiguous makes Register fail with AmbiguousTransitionError if two
+// transitions other than the Default share a name, a From state and a
+// Priority, instead of resolving them by declaration order.
+func RejectAmbiguous() RegisterOption {
+	return func(f *fsm) {
+		f.unambiguous = true
+	}
+}
+
+// checkBranches reports sorted branches of key as ambiguous if more than one
+// is the default or, with RejectAmbiguous, two others share a priority.
+func (f *fsm) checkBranches(key eventKey, branches []*branch) error {
+	defaults := 0
+	for i, br := range branches {
+		if br.fallback {
+			defaults++
+			continue
+		}
+		if f.unambiguous && i > 0 && !branches[i-1].fallback && branches[i-1].priority == br.priority {
+			return AmbiguousTransitionError{Event: key.event, State: string(key.src)}
+		}
+	}
+	if defaults > 1 {
+		return AmbiguousTransitionError{Event: key.event, State: string(key.src)}
+	}
+	return nil
+}
+
+// selectBranch returns the first branch of e leaving state whose guards
+// pass, setting e.Destination to it. If none passes it returns nil and the
+// name of the guard rejecting the last branch. Draft branches are skipped
+// unless args include them. It stops with ctx.Err() once ctx is done.
+//
+// If authorize is set, branches the caller may not take are skipped before
+// their guards run; if no guard rejected another branch, the
+// PermissionDeniedError of the first of them is returned.
+func (f *fsm) selectBranch(ctx context.Context, e *Event, state State, args *Options, authorize bool) (*branch, string, error) {
+	t := f.table()
+	var guard string
+	var denied error
+	for _, br := range t.branches[eventKey{e.Event, state}] {
+		if br.draft && !args.includeDraft() {
+			continue
+		}
+		e.Destination = br.to
+
+		if authorize {
+			if err := f.authorize(ctx, e, br); err != nil {
+				if !errors.As(err, new(PermissionDeniedError)) {
+					return nil, "", err
+				}
+				if denied == nil {
+					denied = err
+				}
+				continue
+			}
+		}
+
+		ok, name, err := f.guardBranch(ctx, br, e, args)
+		if err != nil {
+			return nil, name, err
+		}
+		if ok {
+			return br, "", nil
+		}
+		guard = name
+	}
+	if guard == "" && denied != nil {
+		return nil, "", denied
+	}
+	return nil, guard, nil
+}
+
+// guardBranch evaluates the guards of br in order and returns the name of
+// the first one rejecting the transition or failing. It passes without
+// evaluating them if args skip guards.
+func (f *fsm) guardBranch(ctx context.Context, br *branch, e *Event, args *Options) (bool, string, error) {
+	if args != nil && args.SkipGuards {
+		return true, "", nil
+	}
+	for _, g := range br.guards {
+		if err := ctx.Err(); err != nil {
+			return false, "", err
+		}
+		if ok, err := f.evalGuard(ctx, br, g, e, args); err != nil || !ok {
+			return false, g.name, err
+		}
+	}
+	return true, "", nil
+}
+
+func (br *branch) beforeCallback(ctx context.Context, e *Event) error {
+	if br.before == nil {
+		return nil
+	}
+	return br.before(ctx, e)
+}
+
+func (br *branch) afterCallback(ctx context.Context, e *Event) error {
+	if br.after == nil {
+		return nil
+	}
+	return br.after(ctx, e)
+}
+
+// choose calls the ToFunc of br and sets e.Destination to the state it
+// returns, which must be one of the targets of br.
+func (f *fsm) choose(ctx context.Context, e *Event, br *branch) (State, error) {
+	var to State
+	err := f.retry(ctx, br, e, func() error {
+		return f.bounded(ctx, br, e.Event, "to", func(ctx context.Context) (err error) {
+			to, err = br.toFunc(ctx, e)
+			return err
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !slices.Contains(br.targets, to) {
+		return "", UndeclaredTargetError{Event: e.Event, State: string(to)}
+	}
+	e.Destination = to
+	return to, nil
+}
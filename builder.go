@@ -0,0 +1,118 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// MachineBuilder defines a machine step by step, see Builder.
+type MachineBuilder struct {
+	def Definition
+	err error
+}
+
+// Builder starts the definition of a machine for tag:
+//
+//	def, err := fsm.Builder(reflect.TypeOf((*Order)(nil))).
+//		Column("State").
+//		Event("make").From("started").To("finished").Guard(isValid).
+//		Build()
+func Builder(tag reflect.Type) *MachineBuilder {
+	return &MachineBuilder{def: Definition{Type: tag}}
+}
+
+// Column sets the state field of the machine.
+func (b *MachineBuilder) Column(column string) *MachineBuilder {
+	b.def.Column = column
+	return b
+}
+
+// Event starts a new transition; the following calls configure it.
+func (b *MachineBuilder) Event(name string) *MachineBuilder {
+	b.def.Events = append(b.def.Events, EventTransition{Name: name})
+	return b
+}
+
+// transition returns the transition being configured, recording an error
+// if Event has not been called yet.
+func (b *MachineBuilder) transition(method string) *EventTransition {
+	if len(b.def.Events) == 0 {
+		if b.err == nil {
+			b.err = errors.New("fsm: builder: " + method + " called before Event")
+		}
+		return &EventTransition{}
+	}
+	return &b.def.Events[len(b.def.Events)-1]
+}
+
+// From adds source states to the current transition.
+func (b *MachineBuilder) From(states ...State) *MachineBuilder {
+	t := b.transition("From")
+	t.From = append(t.From, states...)
+	return b
+}
+
+// To sets the destination state of the current transition.
+func (b *MachineBuilder) To(state State) *MachineBuilder {
+	b.transition("To").To = state
+	return b
+}
+
+// Guard adds guards to the current transition.
+func (b *MachineBuilder) Guard(guards ...Guard) *MachineBuilder {
+	t := b.transition("Guard")
+	t.Guards = append(t.Guards, guards...)
+	return b
+}
+
+// Before sets the Before callback of the current transition.
+func (b *MachineBuilder) Before(fn func(context.Context, *Event) error) *MachineBuilder {
+	b.transition("Before").Before = fn
+	return b
+}
+
+// After sets the After callback of the current transition.
+func (b *MachineBuilder) After(fn func(context.Context, *Event) error) *MachineBuilder {
+	b.transition("After").After = fn
+	return b
+}
+
+// OnError sets the error event of the current transition.
+func (b *MachineBuilder) OnError(event string) *MachineBuilder {
+	b.transition("OnError").OnError = event
+	return b
+}
+
+// Quota sets the per-actor quota of the current transition.
+func (b *MachineBuilder) Quota(limit int) *MachineBuilder {
+	b.transition("Quota").Quota = limit
+	return b
+}
+
+// Build validates and returns the definition.
+func (b *MachineBuilder) Build() (Definition, error) {
+	if b.err != nil {
+		return Definition{}, b.err
+	}
+
+	if b.def.Column == "" {
+		return Definition{}, errors.New("fsm: builder: missing Column")
+	}
+
+	for _, e := range b.def.Events {
+		if len(e.From) == 0 {
+			return Definition{}, errors.New("fsm: builder: event " + e.Name + " has no From states")
+		}
+		if e.To == "" {
+			return Definition{}, errors.New("fsm: builder: event " + e.Name + " has no To state")
+		}
+	}
+
+	return b.def, nil
+}
+
+// RegisterDefinition func to register a machine described by def
+func (f *FSM) RegisterDefinition(def Definition, options ...RegisterOption) error {
+	return f.Register(def.Type, def.Column, def.Events, def.options(options...)...)
+}
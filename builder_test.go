@@ -0,0 +1,47 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	var calls []string
+
+	def, err := Builder(reflect.TypeOf((*TestStruct)(nil))).
+		Column("State").
+		Event("make").From("started").To("finished").
+		Guard(IsTestStructValid).
+		Before(func(ctx context.Context, e *Event) error {
+			calls = append(calls, "before")
+			return nil
+		}).
+		After(func(ctx context.Context, e *Event) error {
+			calls = append(calls, "after")
+			return nil
+		}).
+		Event("reset").From("finished").To("started").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	fsm := NewFSM()
+	if err := fsm.RegisterDefinition(def); err != nil {
+		t.Errorf("fsm.RegisterDefinition() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("started")}
+	if err := fsm.Fire(context.Background(), testStruct, "make"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	if testStruct.State != State("finished") || len(calls) != 2 {
+		t.Errorf("unexpected state %s and calls %v", testStruct.State, calls)
+	}
+
+	if _, err := Builder(reflect.TypeOf((*TestStruct)(nil))).Column("State").From("started").Build(); err == nil {
+		t.Error("expected From before Event to fail")
+	}
+}
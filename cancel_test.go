@@ -0,0 +1,75 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFireCanceledBetweenGuards(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	second := false
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+		Guards: []Guard{
+			func(ctx context.Context, e *Event) (bool, error) {
+				cancel()
+				return true, nil
+			},
+			func(ctx context.Context, e *Event) (bool, error) {
+				second = true
+				return true, nil
+			},
+		},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("started")}
+	if err := fsm.Fire(ctx, testStruct, "make"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Fire() error = %v, want context.Canceled", err)
+	}
+	if second {
+		t.Errorf("guard ran after cancellation")
+	}
+	if testStruct.State != State("started") {
+		t.Errorf("state = %s, want started", testStruct.State)
+	}
+}
+
+func TestFireCanceledBeforeStateWrite(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	after := false
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+		Before: func(ctx context.Context, e *Event) error {
+			cancel()
+			return nil
+		},
+		After: func(ctx context.Context, e *Event) error {
+			after = true
+			return nil
+		},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("started")}
+	if err := fsm.Fire(ctx, testStruct, "make"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Fire() error = %v, want context.Canceled", err)
+	}
+	if after || testStruct.State != State("started") {
+		t.Errorf("transition applied after cancellation: state = %s, after = %v", testStruct.State, after)
+	}
+}
@@ -0,0 +1,93 @@
+package fsm
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the FSM the time, e.g. for history entries, timestamp fields
+// and the age checks of Reconciler and StuckDetector.
+type Clock interface {
+	Now() time.Time
+}
+
+// TimerClock is a Clock that also runs timers, used by retry backoffs,
+// callback timeouts, expiry in the default MemoryStore and the loops of
+// Reconciler.Run and RunScheduler. Timers of a plain Clock use the system
+// clock.
+type TimerClock interface {
+	Clock
+	// AfterFunc calls fn in its own goroutine once d passed, unless stop is
+	// called first. stop reports whether it prevented the call.
+	AfterFunc(d time.Duration, fn func()) (stop func() bool)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) AfterFunc(d time.Duration, fn func()) func() bool {
+	return time.AfterFunc(d, fn).Stop
+}
+
+// WithClock reads the time from c instead of the system clock, e.g. to
+// control time in tests. Implement TimerClock to control timers as well.
+func WithClock(c Clock) FSMOption {
+	return func(f *FSM) {
+		f.clock = c
+	}
+}
+
+func (f *FSM) now() time.Time {
+	return f.clock.Now()
+}
+
+func (f *FSM) timers() TimerClock {
+	return timersOf(f.clock)
+}
+
+// timersOf returns c if it runs timers, the system clock otherwise.
+func timersOf(c Clock) TimerClock {
+	if c, ok := c.(TimerClock); ok {
+		return c
+	}
+	return systemClock{}
+}
+
+// nowOf returns the time on c, the system clock if c is nil.
+func nowOf(c Clock) time.Time {
+	if c == nil {
+		return time.Now()
+	}
+	return c.Now()
+}
+
+// sleep waits for d on c, returning the error of ctx if it is done first.
+func sleep(ctx context.Context, c TimerClock, d time.Duration) error {
+	done := make(chan struct{})
+	stop := c.AfterFunc(d, func() { close(done) })
+	select {
+	case <-ctx.Done():
+		stop()
+		return ctx.Err()
+	case <-done:
+		return nil
+	}
+}
+
+// withTimeout is context.WithTimeout on the clock of f. Timed by a clock
+// other than the system clock, the context is canceled with
+// context.DeadlineExceeded as its cause but carries no deadline.
+func (f *FSM) withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	c := f.timers()
+	if _, system := c.(systemClock); system {
+		return context.WithTimeout(ctx, d)
+	}
+
+	tctx, cancel := context.WithCancelCause(ctx)
+	stop := c.AfterFunc(d, func() { cancel(context.DeadlineExceeded) })
+	return tctx, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
@@ -0,0 +1,271 @@
+// Command fsmctl inspects machine definitions without writing Go:
+//
+//	fsmctl validate order.yaml
+//	fsmctl lint -config lint.yaml order.yaml
+//	fsmctl diff -format markdown order-v1.yaml order-v2.yaml
+//	fsmctl render -format png -out order.png order.yaml
+//
+// Definitions are JSON or YAML files, or Go plugins (.so) exporting a
+// Schema variable of type fsm.Schema. Rendering PNG requires Graphviz dot.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plugin"
+	"strings"
+
+	"github.com/ceearrashee/fsm"
+	"github.com/ceearrashee/fsm/fsmlint"
+)
+
+const usage = `usage: fsmctl <command> [flags] definitions...
+
+commands:
+  validate  check definitions are well formed
+  lint      check definitions against fsmlint rules
+  diff      print the transitions added and removed between two definitions
+  render    draw a definition as dot, mermaid, markdown or png
+`
+
+// errIssues makes fsmctl exit with status 1 instead of 2.
+var errIssues = errors.New("issues found")
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "validate":
+		err = validate(args)
+	case "lint":
+		err = lint(args)
+	case "diff":
+		err = diff(args)
+	case "render":
+		err = render(args)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if errors.Is(err, errIssues) {
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fsmctl:", err)
+		os.Exit(2)
+	}
+}
+
+func formatOf(path string) fsm.Format {
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		return fsm.FormatYAML
+	}
+	return fsm.FormatJSON
+}
+
+// load reads the definition at path, validating it.
+func load(path string) (fsm.Schema, error) {
+	if strings.ToLower(filepath.Ext(path)) == ".so" {
+		return loadPlugin(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fsm.Schema{}, err
+	}
+	defer f.Close()
+
+	schema, err := fsm.ParseDefinition(f, formatOf(path))
+	if err != nil {
+		return fsm.Schema{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return schema, nil
+}
+
+func loadPlugin(path string) (fsm.Schema, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return fsm.Schema{}, err
+	}
+
+	sym, err := p.Lookup("Schema")
+	if err != nil {
+		return fsm.Schema{}, err
+	}
+
+	schema, ok := sym.(*fsm.Schema)
+	if !ok {
+		return fsm.Schema{}, fmt.Errorf("%s: Schema is a %T, not an fsm.Schema", path, sym)
+	}
+	if err := schema.Validate(); err != nil {
+		return fsm.Schema{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return *schema, nil
+}
+
+func validate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	fs.Parse(args)
+
+	for _, path := range fs.Args() {
+		if _, err := load(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func lint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	config := fs.String("config", "", "rule configuration (.json, .yaml or .yml)")
+	fs.Parse(args)
+
+	var cfg fsmlint.Config
+	if *config != "" {
+		f, err := os.Open(*config)
+		if err != nil {
+			return err
+		}
+		cfg, err = fsmlint.ParseConfig(f, formatOf(*config))
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	l, err := fsmlint.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	issues := false
+	for _, path := range fs.Args() {
+		schema, err := load(path)
+		if err != nil {
+			return err
+		}
+		for _, issue := range l.Lint(schema) {
+			fmt.Printf("%s: %s\n", path, issue)
+			issues = true
+		}
+	}
+
+	if issues {
+		return errIssues
+	}
+	return nil
+}
+
+func diff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", "text", "text, dot, mermaid, markdown or png")
+	out := fs.String("out", "", "output file, stdout if empty")
+	title := fs.String("title", "", "title of rendered diffs")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		return errors.New("diff needs the old and the new definition")
+	}
+
+	old, err := load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	new, err := load(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	d := fsm.Diff(old, new)
+	if *format != "text" {
+		return write(*out, *format, new, fsm.ExportOptions{Title: *title, Diff: &d})
+	}
+
+	var b bytes.Buffer
+	for _, t := range d.Added {
+		fmt.Fprintf(&b, "+ %s: %s -> %s\n", t.Event, t.From, t.To)
+	}
+	for _, t := range d.Removed {
+		fmt.Fprintf(&b, "- %s: %s -> %s\n", t.Event, t.From, t.To)
+	}
+	for _, m := range d.Modified {
+		fmt.Fprintf(&b, "~ %s: %s -> %s (was %s)\n", m.Event, m.From, m.NewTo, m.OldTo)
+	}
+	for _, state := range d.AddedStates {
+		fmt.Fprintf(&b, "+ state %s\n", state)
+	}
+	for _, state := range d.RemovedStates {
+		fmt.Fprintf(&b, "- state %s\n", state)
+	}
+	return output(*out, b.Bytes())
+}
+
+func render(args []string) error {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	format := fs.String("format", "dot", "dot, mermaid, markdown or png")
+	out := fs.String("out", "", "output file, stdout if empty")
+	title := fs.String("title", "", "title of the diagram")
+	version := fs.String("version", "", "version stamped on the diagram")
+	draft := fs.Bool("draft", false, "include draft transitions")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("render needs a definition")
+	}
+
+	schema, err := load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return write(*out, *format, schema, fsm.ExportOptions{Title: *title, Version: *version, IncludeDraft: *draft})
+}
+
+// write exports schema in format to out.
+func write(out, format string, schema fsm.Schema, opts fsm.ExportOptions) error {
+	var b bytes.Buffer
+	var err error
+	switch format {
+	case "dot", "png":
+		err = fsm.WriteDOT(&b, schema, opts)
+	case "mermaid":
+		err = fsm.WriteMermaid(&b, schema, opts)
+	case "markdown":
+		err = fsm.WriteMarkdown(&b, schema, opts)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if format != "png" {
+		return output(out, b.Bytes())
+	}
+
+	cmd := exec.Command("dot", "-Tpng")
+	cmd.Stdin = &b
+	cmd.Stderr = os.Stderr
+	png, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("running dot: %w", err)
+	}
+	return output(out, png)
+}
+
+func output(out string, data []byte) error {
+	if out == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(out, data, 0o644)
+}
@@ -0,0 +1,74 @@
+// Command fsmgen generates a reflection-free state machine from a JSON or
+// YAML definition, typically from a go:generate directive:
+//
+//	//go:generate go run github.com/ceearrashee/fsm/cmd/fsmgen -in order.yaml -type Order -out order_fsm.go
+//
+// With -tests it writes a table-driven test skeleton covering every state
+// and event pair instead of the machine.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ceearrashee/fsm"
+	"github.com/ceearrashee/fsm/fsmgen"
+)
+
+func main() {
+	in := flag.String("in", "", "definition file (.json, .yaml or .yml)")
+	out := flag.String("out", "", "output file, stdout if empty")
+	pkg := flag.String("package", os.Getenv("GOPACKAGE"), "package of the generated file")
+	typ := flag.String("type", "", "model type the machine operates on")
+	machine := flag.String("machine", "", "name of the generated type, <type>Machine if empty")
+	stateType := flag.String("state-type", "", "type of the state field, fsm.State if empty")
+	tests := flag.Bool("tests", false, "generate a test skeleton of the machine instead")
+	flag.Parse()
+
+	if err := run(*in, *out, *tests, fsmgen.Config{
+		Package:   *pkg,
+		Type:      *typ,
+		Machine:   *machine,
+		StateType: *stateType,
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, "fsmgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out string, tests bool, cfg fsmgen.Config) error {
+	f, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	format := fsm.FormatJSON
+	if ext := strings.ToLower(filepath.Ext(in)); ext == ".yaml" || ext == ".yml" {
+		format = fsm.FormatYAML
+	}
+
+	cfg.Schema, err = fsm.ParseDefinition(f, format)
+	if err != nil {
+		return err
+	}
+
+	generate := fsmgen.Generate
+	if tests {
+		generate = fsmgen.GenerateTests
+	}
+
+	src, err := generate(cfg)
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
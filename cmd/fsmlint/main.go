@@ -0,0 +1,77 @@
+// Command fsmlint checks JSON or YAML machine definitions against the rules
+// of a fsmlint configuration and exits with status 1 if any are violated:
+//
+//	go run github.com/ceearrashee/fsm/cmd/fsmlint -config lint.yaml order.yaml payment.yaml
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ceearrashee/fsm"
+	"github.com/ceearrashee/fsm/fsmlint"
+)
+
+func main() {
+	config := flag.String("config", "", "rule configuration (.json, .yaml or .yml)")
+	flag.Parse()
+
+	ok, err := run(*config, flag.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fsmlint:", err)
+		os.Exit(2)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+func formatOf(path string) fsm.Format {
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		return fsm.FormatYAML
+	}
+	return fsm.FormatJSON
+}
+
+func run(config string, paths []string) (bool, error) {
+	var cfg fsmlint.Config
+	if config != "" {
+		f, err := os.Open(config)
+		if err != nil {
+			return false, err
+		}
+		cfg, err = fsmlint.ParseConfig(f, formatOf(config))
+		f.Close()
+		if err != nil {
+			return false, err
+		}
+	}
+
+	l, err := fsmlint.New(cfg)
+	if err != nil {
+		return false, err
+	}
+
+	ok := true
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return false, err
+		}
+		schema, err := fsm.ParseDefinition(f, formatOf(path))
+		f.Close()
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", path, err)
+		}
+
+		for _, issue := range l.Lint(schema) {
+			fmt.Printf("%s: %s\n", path, issue)
+			ok = false
+		}
+	}
+
+	return ok, nil
+}
@@ -0,0 +1,102 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type Order struct {
+	PaymentState  State
+	ShippingState State
+}
+
+func TestMultipleStateColumns(t *testing.T) {
+	tag := reflect.TypeOf((*Order)(nil))
+
+	fsm := NewFSM()
+	if err := fsm.Register(tag, "PaymentState", Events{{
+		Name: "pay",
+		From: []State{"pending"},
+		To:   State("paid"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := fsm.Register(tag, "ShippingState", Events{{
+		Name: "ship",
+		From: []State{"pending"},
+		To:   State("shipped"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	order := &Order{PaymentState: "pending", ShippingState: "pending"}
+
+	if err := fsm.Fire(context.Background(), order, "pay"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if err := fsm.FireOn(context.Background(), order, "ShippingState", "ship"); err != nil {
+		t.Errorf("FireOn() error = %v", err)
+	}
+
+	if order.PaymentState != "paid" || order.ShippingState != "shipped" {
+		t.Errorf("unexpected states %+v", order)
+	}
+}
+
+type RegionOrder struct {
+	Payment     State
+	Fulfillment State
+	Status      State
+}
+
+func TestRegionJoin(t *testing.T) {
+	tag := reflect.TypeOf((*RegionOrder)(nil))
+
+	fsm := NewFSM()
+	if err := fsm.Register(tag, "Status", Events{{
+		Name:   "complete",
+		From:   []State{"open"},
+		To:     State("complete"),
+		Guards: []Guard{fsm.InState("Payment", "paid"), fsm.InState("Fulfillment", "delivered")},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := fsm.Register(tag, "Payment", Events{{
+		Name: "pay",
+		From: []State{"pending"},
+		To:   State("paid"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := fsm.Register(tag, "Fulfillment", Events{{
+		Name: "deliver",
+		From: []State{"pending"},
+		To:   State("delivered"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	order := &RegionOrder{Payment: "pending", Fulfillment: "pending", Status: "open"}
+
+	if err := fsm.FireOn(ctx, order, "Payment", "pay"); err != nil {
+		t.Errorf("FireOn() error = %v", err)
+	}
+	if ok, _ := fsm.MayFire(ctx, order, "complete"); ok {
+		t.Errorf("MayFire() = true before fulfillment is done")
+	}
+
+	if err := fsm.FireOn(ctx, order, "Fulfillment", "deliver"); err != nil {
+		t.Errorf("FireOn() error = %v", err)
+	}
+	if err := fsm.Fire(ctx, order, "complete"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	regions, err := fsm.Regions(order)
+	want := map[string]State{"Status": "complete", "Payment": "paid", "Fulfillment": "delivered"}
+	if err != nil || !reflect.DeepEqual(regions, want) {
+		t.Errorf("Regions() = %v, %v, want %v", regions, err, want)
+	}
+}
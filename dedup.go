@@ -0,0 +1,159 @@
+package fsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// dedupRecord is the outcome of a deduplicated Fire call. A rejection is
+// kept as the name of its type in settledErrors and its fields, so a repeat
+// returns an error of the same type.
+type dedupRecord struct {
+	Result    FireResult      `json:"result"`
+	Error     string          `json:"error,omitempty"`
+	ErrorType string          `json:"error_type,omitempty"`
+	ErrorData json.RawMessage `json:"error_data,omitempty"`
+}
+
+// settledError encodes and decodes one type of error settled remembers.
+type settledError struct {
+	name   string
+	as     func(err error) (interface{}, bool)
+	decode func(data []byte) (error, error)
+}
+
+func settledAs[E error](name string) settledError {
+	return settledError{
+		name: name,
+		as: func(err error) (interface{}, bool) {
+			var e E
+			ok := errors.As(err, &e)
+			return e, ok
+		},
+		decode: func(data []byte) (error, error) {
+			var e E
+			err := json.Unmarshal(data, &e)
+			return e, err
+		},
+	}
+}
+
+var settledErrors = []settledError{
+	settledAs[InvalidTransitionError]("invalid_transition"),
+	settledAs[UnknownEventError]("unknown_event"),
+	settledAs[MachineCompletedError]("completed"),
+	settledAs[DraftTransitionError]("draft"),
+	settledAs[PermissionDeniedError]("permission_denied"),
+	settledAs[UninitializedStateError]("uninitialized_state"),
+	settledAs[UnknownStateError]("unknown_state"),
+}
+
+// setError stores err in rec.
+func (rec *dedupRecord) setError(err error) error {
+	rec.Error = err.Error()
+	for _, t := range settledErrors {
+		if e, ok := t.as(err); ok {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			rec.ErrorType, rec.ErrorData = t.name, data
+			return nil
+		}
+	}
+	return nil
+}
+
+// err rebuilds the error stored in rec.
+func (rec *dedupRecord) err() error {
+	for _, t := range settledErrors {
+		if t.name == rec.ErrorType {
+			err, derr := t.decode(rec.ErrorData)
+			if derr != nil {
+				return derr
+			}
+			return err
+		}
+	}
+	return errors.New(rec.Error)
+}
+
+// fireOnce fires event unless a call with the same dedup key already did,
+// in which case NoOp is returned, or the original error for a repeated
+// rejection. Only successes and rejections a retry can't change are
+// remembered, other failures may be retried with the same key.
+func (f *fsm) fireOnce(ctx context.Context, s interface{}, event string, args *Options) (FireResult, error) {
+	id, ok := f.identityOf(s)
+	if !ok {
+		return Failed, IdentityRequiredError{Type: f.tag, Option: "WithDedupKey"}
+	}
+	key := "dedup:" + f.name + ":" + f.column + ":" + id + ":" + event + ":" + args.DedupKey
+
+	unlock := f.dedupLocks.Lock(key)
+	defer unlock()
+
+	store := f.parent.store
+	if f.parent.dedupStore != nil {
+		store = f.parent.dedupStore
+	}
+
+	data, err := store.Load(ctx, key)
+	if err != nil {
+		return Failed, err
+	}
+
+	if data != nil {
+		var rec dedupRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return Failed, err
+		}
+
+		if rec.Error != "" {
+			return rec.Result, rec.err()
+		}
+		return NoOp, nil
+	}
+
+	result, ferr := f.fireE(ctx, s, event, args)
+	if !settled(ferr) {
+		return result, ferr
+	}
+
+	rec := dedupRecord{Result: result}
+	if ferr != nil {
+		if err := rec.setError(ferr); err != nil {
+			return result, errors.Join(ferr, err)
+		}
+	}
+
+	data, err = json.Marshal(rec)
+	if err != nil {
+		return result, errors.Join(ferr, err)
+	}
+
+	if err := store.Save(ctx, key, data, f.parent.dedupWindow); err != nil {
+		return result, errors.Join(ferr, err)
+	}
+
+	return result, ferr
+}
+
+// settled reports whether err is nil or a rejection firing again with the
+// same key would repeat, such as an unknown event. Context, store and
+// callback errors are not.
+func settled(err error) bool {
+	var invalid InvalidTransitionError
+	switch {
+	case err == nil:
+		return true
+	case errors.As(err, &invalid):
+		return invalid.Err == nil
+	case errors.As(err, new(UnknownEventError)), errors.As(err, new(MachineCompletedError)),
+		errors.As(err, new(DraftTransitionError)), errors.As(err, new(PermissionDeniedError)),
+		errors.As(err, new(UninitializedStateError)), errors.As(err, new(UnknownStateError)):
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,162 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDedupWindow(t *testing.T) {
+	calls := 0
+
+	fsm := NewFSM(WithDedupWindow(time.Minute), WithIDFunc(func(s interface{}) string { return "ping-1" }))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "ping",
+		From: []State{"started"},
+		To:   State("started"),
+		After: func(ctx context.Context, e *Event) error {
+			calls++
+			return nil
+		},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("started")}
+
+	for i, want := range []FireResult{Completed, NoOp, NoOp} {
+		// A reloaded entity is recognised by its ID.
+		reloaded := &TestStruct{State: testStruct.State}
+		result, err := fsm.FireE(ctx, reloaded, "ping", WithDedupKey("req-1"))
+		if err != nil || result != want {
+			t.Errorf("FireE() #%d = (%s, %v), want %s", i, result, err, want)
+		}
+	}
+
+	if err := fsm.Fire(ctx, testStruct, "ping", WithDedupKey("req-2")); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected 2 transitions, got %d", calls)
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	calls := 0
+	events := Events{{
+		Name: "ping",
+		From: []State{"started"},
+		To:   State("started"),
+		After: func(ctx context.Context, e *Event) error {
+			calls++
+			return nil
+		},
+	}}
+
+	// Two processes sharing the dedup store.
+	store := NewMemoryStore()
+	id := WithIDFunc(func(s interface{}) string { return "ping-1" })
+	first, second := NewFSM(WithDedupStore(store), id), NewFSM(WithDedupStore(store), id)
+	for _, fsm := range []*FSM{first, second} {
+		if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", events); err != nil {
+			t.Errorf("fsm.Register() error = %v", err)
+		}
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("started")}
+	if err := first.Fire(ctx, testStruct, "ping", WithIdempotencyKey("delivery-1")); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if result, err := second.FireE(ctx, testStruct, "ping", WithIdempotencyKey("delivery-1")); err != nil || result != NoOp {
+		t.Errorf("FireE() redelivered = (%s, %v)", result, err)
+	}
+
+	if calls != 1 {
+		t.Errorf("expected 1 transition, got %d", calls)
+	}
+}
+
+func TestDedupRetriesFailures(t *testing.T) {
+	calls, fail := 0, true
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "ping",
+		From: []State{"started"},
+		To:   State("started"),
+		After: func(ctx context.Context, e *Event) error {
+			calls++
+			if fail {
+				return errors.New("unavailable")
+			}
+			return nil
+		},
+	}}, WithIdentity(func(s interface{}) string { return "ping-1" })); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("started")}
+	if err := fsm.Fire(ctx, testStruct, "ping", WithIdempotencyKey("delivery-1")); err == nil {
+		t.Error("Fire() error = nil")
+	}
+	fail = false
+	for i, want := range []FireResult{Completed, NoOp} {
+		if result, err := fsm.FireE(ctx, testStruct, "ping", WithIdempotencyKey("delivery-1")); err != nil || result != want {
+			t.Errorf("FireE() #%d = (%s, %v), want %s", i, result, err, want)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls)
+	}
+
+	if err := fsm.Fire(ctx, testStruct, "pong", WithIdempotencyKey("delivery-2")); !errors.As(err, new(UnknownEventError)) {
+		t.Errorf("Fire() error = %v, want UnknownEventError", err)
+	}
+	if err := fsm.Fire(ctx, testStruct, "pong", WithIdempotencyKey("delivery-2")); err == nil {
+		t.Error("repeated Fire() error = nil, want the original rejection")
+	}
+}
+
+func TestDedupRequiresIdentity(t *testing.T) {
+	events := Events{{Name: "ping", From: []State{"started"}, To: State("started")}}
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := NewFSM(WithDedupWindow(time.Minute)).Register(tag, "State", events); !errors.As(err, new(IdentityRequiredError)) {
+		t.Errorf("Register() error = %v, want IdentityRequiredError", err)
+	}
+
+	fsm := NewFSM()
+	if err := fsm.Register(tag, "State", events); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := fsm.Fire(context.Background(), &TestStruct{State: "started"}, "ping", WithDedupKey("req-1")); !errors.As(err, new(IdentityRequiredError)) {
+		t.Errorf("Fire() error = %v, want IdentityRequiredError", err)
+	}
+}
+
+func TestDedupRepeatsRejection(t *testing.T) {
+	fsm := NewFSM(WithIDFunc(func(s interface{}) string { return "ping-1" }))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "ping",
+		From: []State{"started"},
+		To:   State("started"),
+		Guards: []Guard{func(ctx context.Context, e *Event) (bool, error) {
+			return false, nil
+		}},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		var invalid InvalidTransitionError
+		err := fsm.Fire(ctx, &TestStruct{State: "started"}, "ping", WithDedupKey("req-1"))
+		if !errors.As(err, &invalid) || invalid.Event != "ping" || invalid.State != "started" {
+			t.Errorf("Fire() #%d error = %v, want InvalidTransitionError", i, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package fsm
+
+import "context"
+
+// WithDefaultGuards adds guards to every transition of the machine, e.g. a
+// tenant isolation check, evaluated ahead of its own guards of the same
+// cost. Transitions setting SkipDefaults opt out.
+func WithDefaultGuards(guards ...Guard) RegisterOption {
+	return func(f *fsm) {
+		f.defaults.guards = append(f.defaults.guards, guards...)
+	}
+}
+
+// WithDefaultBefore runs fn ahead of the Before callback of every transition
+// of the machine. Transitions setting SkipDefaults opt out.
+func WithDefaultBefore(fn Callback) RegisterOption {
+	return func(f *fsm) {
+		f.defaults.before = append(f.defaults.before, fn)
+	}
+}
+
+// WithDefaultAfter runs fn, e.g. touching an updated_at column, following
+// the After callback of every transition of the machine. Transitions setting
+// SkipDefaults opt out.
+func WithDefaultAfter(fn Callback) RegisterOption {
+	return func(f *fsm) {
+		f.defaults.after = append(f.defaults.after, fn)
+	}
+}
+
+// defaults are the guards and callbacks added to every transition.
+type defaults struct {
+	guards []Guard
+	before []Callback
+	after  []Callback
+}
+
+// apply returns e with the defaults added.
+func (d defaults) apply(e EventTransition) EventTransition {
+	if e.SkipDefaults {
+		return e
+	}
+	if len(d.guards) > 0 {
+		e.Guards = append(append([]Guard(nil), d.guards...), e.Guards...)
+	}
+	e.Before = chainCallbacks(append(append([]Callback(nil), d.before...), e.Before)...)
+	e.After = chainCallbacks(append([]Callback{e.After}, d.after...)...)
+	return e
+}
+
+// chainCallbacks returns a callback calling fns in order until one fails,
+// skipping nil ones, or nil if all of them are.
+func chainCallbacks(fns ...Callback) func(context.Context, *Event) error {
+	var chain []Callback
+	for _, fn := range fns {
+		if fn != nil {
+			chain = append(chain, fn)
+		}
+	}
+	switch len(chain) {
+	case 0:
+		return nil
+	case 1:
+		return chain[0]
+	}
+	return func(ctx context.Context, e *Event) error {
+		for _, fn := range chain {
+			if err := fn(ctx, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,94 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestDefaults(t *testing.T) {
+	var calls []string
+	record := func(name string) Callback {
+		return func(ctx context.Context, e *Event) error {
+			calls = append(calls, name+" "+e.Event)
+			return nil
+		}
+	}
+	sameTenant := func(ctx context.Context, e *Event) (bool, error) {
+		return e.Reason != "other tenant", nil
+	}
+
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:   "pay",
+		From:   []State{"created"},
+		To:     State("paid"),
+		Before: record("before"),
+		After:  record("after"),
+	}, {
+		Name:         "reset",
+		From:         []State{"paid"},
+		To:           State("created"),
+		SkipDefaults: true,
+	}}, WithDefaultGuards(sameTenant), WithDefaultBefore(record("default before")), WithDefaultAfter(record("touch"))); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("created")}
+	if err := fsm.Fire(ctx, testStruct, "pay", WithReason("other tenant")); !errors.As(err, new(InvalidTransitionError)) {
+		t.Errorf("Fire() error = %v, want InvalidTransitionError from the default guard", err)
+	}
+	if err := fsm.Fire(ctx, testStruct, "pay"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if err := fsm.Fire(ctx, testStruct, "reset", WithReason("other tenant")); err != nil {
+		t.Errorf("Fire() opted out error = %v", err)
+	}
+
+	if want := []string{"default before pay", "before pay", "after pay", "touch pay"}; !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestDefaultsSchema(t *testing.T) {
+	// Each callback needs its own function, the registry names them by code.
+	audit := func(ctx context.Context, e *Event) error { return nil }
+	notify := func(ctx context.Context, e *Event) error { return nil }
+	touch := func(ctx context.Context, e *Event) error { return nil }
+	paid := func(ctx context.Context, e *Event) (bool, error) { return true, nil }
+
+	registry := NewRegistry()
+	if err := registry.RegisterCallback("audit", audit); err != nil {
+		t.Fatalf("RegisterCallback() error = %v", err)
+	}
+	if err := registry.RegisterCallback("notify", notify); err != nil {
+		t.Fatalf("RegisterCallback() error = %v", err)
+	}
+	if err := registry.RegisterGuard("paid", paid); err != nil {
+		t.Fatalf("RegisterGuard() error = %v", err)
+	}
+
+	fsm := NewFSM(WithRegistry(registry))
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name:       "ship",
+		From:       []State{"paid"},
+		To:         State("shipped"),
+		GuardNames: []string{"paid"},
+		Before:     audit,
+		After:      notify,
+	}}, WithDefaultGuards(paid), WithDefaultBefore(touch), WithDefaultAfter(touch)); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	schema, err := fsm.Schema(tag)
+	if err != nil || len(schema.Events) != 1 {
+		t.Fatalf("fsm.Schema() = %+v, %v", schema, err)
+	}
+	if e := schema.Events[0]; !slices.Equal(e.Guards, []string{"paid"}) || e.Before != "audit" || e.After != "notify" {
+		t.Errorf("schema event = %+v, want the declared guards and callbacks", e)
+	}
+}
@@ -0,0 +1,190 @@
+package fsm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the encoding of a declarative machine schema.
+type Format int
+
+const (
+	FormatJSON Format = iota
+	FormatYAML
+)
+
+// Schema is the declarative form of a machine. Guards and callbacks are
+// referenced by the names they are registered under in a Registry.
+type Schema struct {
+	Column string `json:"column" yaml:"column"`
+	// States optionally lists every state; when set, transitions may only
+	// use these states.
+	States []State       `json:"states,omitempty" yaml:"states,omitempty"`
+	Events []SchemaEvent `json:"events" yaml:"events"`
+}
+
+// SchemaEvent is the declarative form of an EventTransition.
+type SchemaEvent struct {
+	Name    string   `json:"name" yaml:"name"`
+	From    []State  `json:"from" yaml:"from"`
+	To      State    `json:"to" yaml:"to"`
+	Guards  []string `json:"guards,omitempty" yaml:"guards,omitempty"`
+	Before  string   `json:"before,omitempty" yaml:"before,omitempty"`
+	After   string   `json:"after,omitempty" yaml:"after,omitempty"`
+	OnError string   `json:"on_error,omitempty" yaml:"on_error,omitempty"`
+	Quota   int      `json:"quota,omitempty" yaml:"quota,omitempty"`
+	Draft   bool     `json:"draft,omitempty" yaml:"draft,omitempty"`
+
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+// ParseDefinition decodes and validates a schema from r.
+func ParseDefinition(r io.Reader, format Format) (Schema, error) {
+	var schema Schema
+
+	switch format {
+	case FormatJSON:
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&schema); err != nil {
+			return Schema{}, err
+		}
+	case FormatYAML:
+		dec := yaml.NewDecoder(r)
+		dec.KnownFields(true)
+		if err := dec.Decode(&schema); err != nil {
+			return Schema{}, err
+		}
+	default:
+		return Schema{}, fmt.Errorf("fsm: unknown definition format %d", format)
+	}
+
+	return schema, schema.Validate()
+}
+
+// Validate checks the schema is complete and only uses declared states.
+func (s Schema) Validate() error {
+	if s.Column == "" {
+		return SchemaError{Reason: "missing column"}
+	}
+
+	states := make(map[State]bool, len(s.States))
+	for _, state := range s.States {
+		states[state] = true
+	}
+	known := func(state State) bool {
+		return len(states) == 0 || states[state]
+	}
+
+	for _, e := range s.Events {
+		if e.Name == "" {
+			return SchemaError{Reason: "event without name"}
+		}
+		if len(e.From) == 0 || e.To == "" {
+			return SchemaError{Event: e.Name, Reason: "missing from or to state"}
+		}
+		for _, src := range e.From {
+			if !known(src) {
+				return SchemaError{Event: e.Name, Reason: "undeclared state " + string(src)}
+			}
+		}
+		if !known(e.To) {
+			return SchemaError{Event: e.Name, Reason: "undeclared state " + string(e.To)}
+		}
+	}
+
+	return nil
+}
+
+// Definition resolves the callbacks of the schema in registry and returns
+// the machine definition for tag. Guard names are resolved at Register, so
+// the FSM must be created WithRegistry(registry).
+func (s Schema) Definition(tag reflect.Type, registry *Registry) (Definition, error) {
+	def := Definition{Type: tag, Column: s.Column, States: append([]State(nil), s.States...)}
+
+	callback := func(event, name string) (Callback, error) {
+		if name == "" {
+			return nil, nil
+		}
+
+		var fn Callback
+		ok := false
+		if registry != nil {
+			fn, ok = registry.Callback(name)
+		}
+		if !ok {
+			return nil, UnknownCallbackError{Event: event, Callback: name}
+		}
+		return fn, nil
+	}
+
+	for _, e := range s.Events {
+		before, err := callback(e.Name, e.Before)
+		if err != nil {
+			return Definition{}, err
+		}
+
+		after, err := callback(e.Name, e.After)
+		if err != nil {
+			return Definition{}, err
+		}
+
+		def.Events = append(def.Events, EventTransition{
+			Name:       e.Name,
+			From:       append([]State(nil), e.From...),
+			To:         e.To,
+			GuardNames: append([]string(nil), e.Guards...),
+			Before:     before,
+			After:      after,
+			OnError:    e.OnError,
+			Quota:      e.Quota,
+			Draft:      e.Draft,
+			Labels:     e.Labels,
+		})
+	}
+
+	return def, nil
+}
+
+// LoadDefinition func to parse a schema from r and register it for tag
+func (f *FSM) LoadDefinition(tag reflect.Type, r io.Reader, format Format, options ...RegisterOption) error {
+	schema, err := ParseDefinition(r, format)
+	if err != nil {
+		return err
+	}
+
+	def, err := schema.Definition(tag, f.registry)
+	if err != nil {
+		return err
+	}
+
+	return f.RegisterDefinition(def, options...)
+}
+
+// Schema func to return the declarative form of the default machine of tag,
+// e.g. to export it. States are those declared by WithStates, sorted.
+// Guards are listed by the names they are reported under and callbacks by
+// the names they are registered under in the Registry; other callbacks are
+// left out.
+func (f *FSM) Schema(tag reflect.Type) (Schema, error) {
+	machine, err := f.defaultMachine(tag)
+	if err != nil {
+		return Schema{}, err
+	}
+
+	return machine.table().schema, nil
+}
+
+// callbackName returns the name fn is registered under in the Registry of
+// the FSM, empty if it has none.
+func (f *fsm) callbackName(fn Callback) string {
+	if fn == nil || f.parent.registry == nil {
+		return ""
+	}
+	name, _ := f.parent.registry.callbackName(fn)
+	return name
+}
@@ -0,0 +1,69 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testDefinitionYAML = `
+column: State
+states: [finished, started]
+events:
+  - name: make
+    from: [started]
+    to: finished
+    guards: [valid]
+    after: count
+`
+
+func TestLoadDefinition(t *testing.T) {
+	calls := 0
+	registry := NewRegistry()
+	if err := registry.RegisterGuard("valid", IsTestStructValid); err != nil {
+		t.Errorf("registry.RegisterGuard() error = %v", err)
+	}
+	if err := registry.RegisterCallback("count", func(ctx context.Context, e *Event) error {
+		calls++
+		return nil
+	}); err != nil {
+		t.Errorf("registry.RegisterCallback() error = %v", err)
+	}
+
+	fsm := NewFSM(WithRegistry(registry))
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.LoadDefinition(tag, strings.NewReader(testDefinitionYAML), FormatYAML, StrictStates()); err != nil {
+		t.Fatalf("fsm.LoadDefinition() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("started")}
+	if err := fsm.Fire(context.Background(), testStruct, "make"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	if testStruct.State != State("finished") || calls != 1 {
+		t.Errorf("unexpected state %s and %d calls", testStruct.State, calls)
+	}
+
+	want, err := ParseDefinition(strings.NewReader(testDefinitionYAML), FormatYAML)
+	if err != nil {
+		t.Errorf("ParseDefinition() error = %v", err)
+	}
+	if schema, err := fsm.Schema(tag); err != nil || !reflect.DeepEqual(schema, want) {
+		t.Errorf("fsm.Schema() = %+v, %v, want %+v", schema, err, want)
+	}
+}
+
+func TestParseDefinitionJSON(t *testing.T) {
+	_, err := ParseDefinition(strings.NewReader(`{
+		"column": "State",
+		"states": ["started"],
+		"events": [{"name": "make", "from": ["started"], "to": "finished"}]
+	}`), FormatJSON)
+
+	if !errors.As(err, new(SchemaError)) {
+		t.Errorf("expected 'SchemaError' for undeclared state, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type fieldRecordingStore struct {
+	*MemoryStore
+	saved [][]string
+}
+
+func (r *fieldRecordingStore) SaveFields(ctx context.Context, key string, fields map[string][]byte, version uint64) (uint64, error) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	r.saved = append(r.saved, names)
+	return r.MemoryStore.SaveFields(ctx, key, fields, version)
+}
+
+func TestDeltaVarsWrites(t *testing.T) {
+	store := &fieldRecordingStore{MemoryStore: NewMemoryStore()}
+	fsm := NewFSM(WithStateStore(store), WithIDFunc(byAddress))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "retry",
+		From: []State{"failed"},
+		To:   State("failed"),
+		After: func(ctx context.Context, e *Event) error {
+			return e.Vars.Set("retries", Var[int](e.Vars, "retries")+1)
+		},
+	}}, DeclareVar("retries", 0), DeclareVar("assignee", "")); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("failed")}
+
+	if err := fsm.SetVar(ctx, testStruct, "assignee", "alice"); err != nil {
+		t.Errorf("fsm.SetVar() error = %v", err)
+	}
+	if err := fsm.Fire(ctx, testStruct, "retry"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	want := [][]string{{"assignee"}, {"retries"}}
+	if !reflect.DeepEqual(store.saved, want) {
+		t.Errorf("saved fields = %v, want %v", store.saved, want)
+	}
+
+	vars, err := fsm.Vars(ctx, testStruct)
+	if err != nil {
+		t.Errorf("fsm.Vars() error = %v", err)
+	}
+	if Var[string](vars, "assignee") != "alice" || Var[int](vars, "retries") != 1 {
+		t.Errorf("vars = %v, want alice and 1", vars.values)
+	}
+}
+
+func TestDeltaVarsVersionConflict(t *testing.T) {
+	fsm := NewFSM(WithIDFunc(byAddress))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "retry",
+		From: []State{"failed"},
+		To:   State("failed"),
+	}}, DeclareVar("retries", 0)); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("failed")}
+	machine, _ := fsm.machine(testStruct)
+
+	first, _ := machine.loadVars(ctx, testStruct)
+	second, _ := machine.loadVars(ctx, testStruct)
+
+	_ = first.Set("retries", 1)
+	if err := machine.saveVars(ctx, testStruct, first); err != nil {
+		t.Errorf("saveVars() error = %v", err)
+	}
+
+	_ = second.Set("retries", 2)
+	if err := machine.saveVars(ctx, testStruct, second); !errors.As(err, new(VersionConflictError)) {
+		t.Errorf("saveVars() of stale vars error = %v, want VersionConflictError", err)
+	}
+}
@@ -0,0 +1,203 @@
+package fsm
+
+import (
+	"slices"
+	"sort"
+)
+
+// Transition is a single edge of a machine.
+type Transition struct {
+	Event string
+	From  State
+	To    State
+}
+
+// TransitionChange is a transition whose destination or guards changed
+// between two versions of a schema.
+type TransitionChange struct {
+	Event     string
+	From      State
+	OldTo     State
+	NewTo     State
+	OldGuards []string
+	NewGuards []string
+}
+
+// SchemaDiff lists what changed between two versions of a schema. Each
+// list is sorted, transitions by event, source and destination.
+type SchemaDiff struct {
+	Added   []Transition
+	Removed []Transition
+	// Modified holds transitions declared for the same event and source in
+	// both versions whose destination or guards differ.
+	Modified      []TransitionChange
+	AddedStates   []State
+	RemovedStates []State
+}
+
+// Empty reports whether the schemas have the same states and transitions.
+func (d SchemaDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0 &&
+		len(d.AddedStates) == 0 && len(d.RemovedStates) == 0
+}
+
+// edges returns the transitions of s in declaration order, leaving out
+// drafts unless draft is set, in which case only drafts are returned.
+func (s Schema) edges(draft bool) []Transition {
+	var edges []Transition
+	for _, e := range s.Events {
+		if e.Draft != draft {
+			continue
+		}
+		for _, src := range e.From {
+			edges = append(edges, Transition{Event: e.Name, From: src, To: e.To})
+		}
+	}
+	return edges
+}
+
+// guardsOf returns the guards of the transitions of s by event and source.
+func (s Schema) guardsOf() map[eventKey][]string {
+	guards := make(map[eventKey][]string)
+	for _, e := range s.Events {
+		for _, src := range e.From {
+			guards[eventKey{e.Name, src}] = e.Guards
+		}
+	}
+	return guards
+}
+
+// states returns the states of s, those listed in States or else every
+// source and destination.
+func (s Schema) states() map[State]bool {
+	states := make(map[State]bool)
+	if len(s.States) > 0 {
+		for _, state := range s.States {
+			states[state] = true
+		}
+		return states
+	}
+
+	for _, t := range s.edges(false) {
+		states[t.From] = true
+		states[t.To] = true
+	}
+	return states
+}
+
+// Diff compares two versions of a schema. Drafts are ignored, so enabling
+// one shows it as added.
+func Diff(old, new Schema) SchemaDiff {
+	var d SchemaDiff
+
+	before, after := byKey(old.edges(false)), byKey(new.edges(false))
+	oldGuards, newGuards := old.guardsOf(), new.guardsOf()
+	for key, ts := range after {
+		prev, ok := before[key]
+		if ok && len(prev) == 1 && len(ts) == 1 {
+			if prev[0].To != ts[0].To || !slices.Equal(oldGuards[key], newGuards[key]) {
+				d.Modified = append(d.Modified, TransitionChange{
+					Event:     key.event,
+					From:      key.src,
+					OldTo:     prev[0].To,
+					NewTo:     ts[0].To,
+					OldGuards: oldGuards[key],
+					NewGuards: newGuards[key],
+				})
+			}
+			continue
+		}
+
+		for _, t := range ts {
+			if !slices.Contains(prev, t) {
+				d.Added = append(d.Added, t)
+			}
+		}
+	}
+	for key, ts := range before {
+		next, ok := after[key]
+		if ok && len(next) == 1 && len(ts) == 1 {
+			continue
+		}
+
+		for _, t := range ts {
+			if !slices.Contains(next, t) {
+				d.Removed = append(d.Removed, t)
+			}
+		}
+	}
+
+	oldStates, newStates := old.states(), new.states()
+	for state := range newStates {
+		if !oldStates[state] {
+			d.AddedStates = append(d.AddedStates, state)
+		}
+	}
+	for state := range oldStates {
+		if !newStates[state] {
+			d.RemovedStates = append(d.RemovedStates, state)
+		}
+	}
+
+	sortTransitions(d.Added)
+	sortTransitions(d.Removed)
+	sort.Slice(d.Modified, func(i, j int) bool {
+		a, b := d.Modified[i], d.Modified[j]
+		if a.Event != b.Event {
+			return a.Event < b.Event
+		}
+		return a.From < b.From
+	})
+	slices.Sort(d.AddedStates)
+	slices.Sort(d.RemovedStates)
+	return d
+}
+
+// DiffEvents is Diff for transitions declared in Go. Guards are compared by
+// the names Register reports them under, without a Registry.
+func DiffEvents(old, new Events) SchemaDiff {
+	return Diff(eventsSchema(old), eventsSchema(new))
+}
+
+func eventsSchema(events Events) Schema {
+	var s Schema
+	for _, e := range events {
+		se := SchemaEvent{Name: e.Name, From: e.From, To: e.To, Draft: e.Draft, Labels: e.Labels}
+		for _, g := range e.Guards {
+			se.Guards = append(se.Guards, GuardName(g))
+		}
+		se.Guards = append(se.Guards, e.GuardNames...)
+		// As in the compiled schema, a ToFunc transition has an edge per
+		// target.
+		if e.ToFunc == nil {
+			s.Events = append(s.Events, se)
+		}
+		for _, target := range e.Targets {
+			se.To = target
+			s.Events = append(s.Events, se)
+		}
+	}
+	return s
+}
+
+func byKey(ts []Transition) map[eventKey][]Transition {
+	m := make(map[eventKey][]Transition)
+	for _, t := range ts {
+		key := eventKey{t.Event, t.From}
+		m[key] = append(m[key], t)
+	}
+	return m
+}
+
+func sortTransitions(ts []Transition) {
+	sort.Slice(ts, func(i, j int) bool {
+		a, b := ts[i], ts[j]
+		if a.Event != b.Event {
+			return a.Event < b.Event
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+}
@@ -0,0 +1,41 @@
+package fsm
+
+// IncludeDraft makes MayFire, MayFireDetailed, GetPermittedEvents and
+// GetPermittedStates consider transitions marked EventTransition.Draft. Fire
+// always rejects them.
+func IncludeDraft() Option {
+	return func(args *Options) {
+		args.IncludeDraft = true
+	}
+}
+
+func (args *Options) includeDraft() bool {
+	return args != nil && args.IncludeDraft
+}
+
+// destination returns the state event leads to from key.src, looking at
+// draft transitions only if args include them.
+func (f *fsm) destination(key eventKey, args *Options) (State, bool) {
+	t := f.table()
+	if to, ok := t.transitions[key]; ok {
+		if args.includeDraft() {
+			return t.branches[key][0].to, true
+		}
+		return to, true
+	}
+	if args.includeDraft() {
+		to, ok := t.drafts[key]
+		return to, ok
+	}
+	return "", false
+}
+
+// eventsFrom returns the events declared from state, followed by those only
+// declared as drafts if args include them.
+func (f *fsm) eventsFrom(state State, args *Options) []string {
+	t := f.table()
+	if !args.includeDraft() {
+		return t.initialStates[state]
+	}
+	return append(append([]string(nil), t.initialStates[state]...), t.draftStates[state]...)
+}
@@ -0,0 +1,99 @@
+package fsm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDraftTransition(t *testing.T) {
+	fsm := NewFSM()
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	err := fsm.Register(tag, "State", Events{{
+		Name: "pay",
+		From: []State{"created"},
+		To:   State("paid"),
+	}, {
+		Name:  "hold",
+		From:  []State{"created"},
+		To:    State("on_hold"),
+		Draft: true,
+	}})
+	if err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	s := &TestStruct{State: "created"}
+
+	events, _ := fsm.GetPermittedEvents(ctx, s)
+	if !reflect.DeepEqual(events, []string{"pay"}) {
+		t.Errorf("GetPermittedEvents() = %v, want [pay]", events)
+	}
+
+	events, _ = fsm.GetPermittedEvents(ctx, s, IncludeDraft())
+	sort.Strings(events)
+	if !reflect.DeepEqual(events, []string{"hold", "pay"}) {
+		t.Errorf("GetPermittedEvents(IncludeDraft()) = %v, want [hold pay]", events)
+	}
+
+	states, _ := fsm.GetPermittedStates(ctx, s, IncludeDraft())
+	if len(states) != 2 {
+		t.Errorf("GetPermittedStates(IncludeDraft()) = %v, want 2 states", states)
+	}
+
+	if ok, _ := fsm.MayFire(ctx, s, "hold"); ok {
+		t.Errorf("MayFire() = true for a draft")
+	}
+	if ok, _ := fsm.MayFire(ctx, s, "hold", IncludeDraft()); !ok {
+		t.Errorf("MayFire(IncludeDraft()) = false")
+	}
+
+	err = fsm.Fire(ctx, s, "hold", IncludeDraft())
+	if !errors.As(err, new(DraftTransitionError)) {
+		t.Errorf("Fire() error = %v, want DraftTransitionError", err)
+	}
+	if s.State != "created" {
+		t.Errorf("State = %v, want created", s.State)
+	}
+
+	schema, _ := fsm.Schema(tag)
+	var b bytes.Buffer
+	if err := WriteMermaid(&b, schema, ExportOptions{}); err != nil || strings.Contains(b.String(), "hold") {
+		t.Errorf("WriteMermaid() = %q, %v, want no draft", b.String(), err)
+	}
+	b.Reset()
+	if err := WriteMermaid(&b, schema, ExportOptions{IncludeDraft: true}); err != nil || !strings.Contains(b.String(), "created --> on_hold: hold (draft)") {
+		t.Errorf("WriteMermaid(IncludeDraft) = %q, %v, want the draft", b.String(), err)
+	}
+}
+
+func TestDraftBranchSkippedByFire(t *testing.T) {
+	fsm := NewFSM()
+	err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:     "review",
+		From:     []State{"submitted"},
+		To:       State("auto_approved"),
+		Priority: 1,
+		Draft:    true,
+	}, {
+		Name: "review",
+		From: []State{"submitted"},
+		To:   State("in_review"),
+	}})
+	if err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	s := &TestStruct{State: "submitted"}
+	if err := fsm.Fire(context.Background(), s, "review"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if s.State != "in_review" {
+		t.Errorf("State = %v, want in_review", s.State)
+	}
+}
@@ -1,12 +1,35 @@
 package fsm
 
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type InvalidTransitionError struct {
 	Event string
 	State string
+	// Guard is the name of the guard that rejected the transition.
+	Guard string
+	// Err is the error returned by Guard, if any.
+	Err error
 }
 
 func (e InvalidTransitionError) Error() string {
-	return "Event " + e.Event + "cannot transition from " + e.State
+	msg := "Event " + e.Event + " cannot transition from " + e.State
+	if e.Guard != "" {
+		msg += ": rejected by guard " + e.Guard
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e InvalidTransitionError) Unwrap() error {
+	return e.Err
 }
 
 type UnknownEventError struct {
@@ -22,3 +45,432 @@ type InternalError struct{}
 func (InternalError) Error() string {
 	return "internal error"
 }
+
+// IdentityRequiredError is returned by Register if Option needs instances of
+// Type to be identified by WithIdentity or WithIDFunc rather than by their
+// address, e.g. to recognise an entity loaded again, and by Fire for such an
+// Option passed without either.
+type IdentityRequiredError struct {
+	Type   reflect.Type
+	Option string
+}
+
+func (e IdentityRequiredError) Error() string {
+	return e.Option + " requires WithIdentity or WithIDFunc for " + e.Type.String()
+}
+
+// SharedStateError is returned by FireValue if the state field Column of
+// Type is reached through a pointer, so the copy would share it with the
+// original.
+type SharedStateError struct {
+	Type   reflect.Type
+	Column string
+}
+
+func (e SharedStateError) Error() string {
+	return "state field " + e.Column + " of " + e.Type.String() + " is reached through a pointer"
+}
+
+// UnregisteredError is returned by Unregister for unknown types and by
+// transitions racing with the removal of their machine.
+type UnregisteredError struct {
+	Type reflect.Type
+}
+
+func (e UnregisteredError) Error() string {
+	return "machine for " + e.Type.String() + " is not registered"
+}
+
+// MachineCompletedError is returned by Fire for instances in a final state,
+// see FSM.MarkFinal.
+type MachineCompletedError struct {
+	Event string
+	State string
+}
+
+func (e MachineCompletedError) Error() string {
+	return "event " + e.Event + " cannot be fired: machine completed in final state " + e.State
+}
+
+// VersionConflictError is returned by DeltaStore.SaveFields if the key was
+// changed concurrently, e.g. by another process firing the same instance.
+type VersionConflictError struct {
+	Key string
+}
+
+func (e VersionConflictError) Error() string {
+	return "version conflict on " + e.Key
+}
+
+// UninitializedStateError is returned by Fire for instances with an empty
+// state field if the machine was registered with RequireInitialized.
+type UninitializedStateError struct {
+	Event string
+}
+
+func (e UninitializedStateError) Error() string {
+	return "event " + e.Event + " cannot be fired: state is not initialized"
+}
+
+// UnknownStateError is returned by Fire with StrictStates for instances in
+// State, which is not declared by WithStates, and by ForceState for such a
+// target.
+type UnknownStateError struct {
+	Event string
+	State string
+}
+
+func (e UnknownStateError) Error() string {
+	return "event " + e.Event + " cannot be fired: unknown state " + e.State
+}
+
+// AmbiguousTransitionError is returned by Register if two transitions of
+// Event leaving State are declared Default, or with RejectAmbiguous if two
+// others leave it with the same priority.
+type AmbiguousTransitionError struct {
+	Event string
+	State string
+}
+
+func (e AmbiguousTransitionError) Error() string {
+	return "event " + e.Event + " has ambiguous transitions from " + e.State
+}
+
+// MachineFrozenError is returned by Fire while the type is frozen, see
+// FSM.Freeze.
+type MachineFrozenError struct {
+	Type  string
+	Event string
+}
+
+func (e MachineFrozenError) Error() string {
+	return "event " + e.Event + " cannot be fired: " + e.Type + " is frozen"
+}
+
+// PermissionDeniedError is returned by Fire if the caller is not allowed to
+// fire Event, see EventTransition.AllowedRoles. Roles are those found in the
+// context.
+type PermissionDeniedError struct {
+	Event string
+	Roles []string
+}
+
+func (e PermissionDeniedError) Error() string {
+	return "permission denied for event " + e.Event
+}
+
+// DraftTransitionError is returned by Fire for an event only declared as a
+// draft from State, see EventTransition.Draft.
+type DraftTransitionError struct {
+	Event string
+	State string
+}
+
+func (e DraftTransitionError) Error() string {
+	return "event " + e.Event + " is a draft from " + e.State
+}
+
+// PropagationError is returned by Fire if the transition succeeded but the
+// event propagated to related instances could not be scheduled for some of
+// them, see FSM.Propagate. Errors holds the error of each by its index in
+// the slice returned by Propagation.Related.
+type PropagationError struct {
+	Event  string
+	Errors map[int]error
+}
+
+func (e PropagationError) Error() string {
+	return "propagated event " + e.Event + " could not be scheduled on " + strconv.Itoa(len(e.Errors)) + " related instances"
+}
+
+// SagaError is returned by FSM.RunSaga if step Step failed with Err.
+// Compensations holds the errors of compensation events that failed too, by
+// the index of their step.
+type SagaError struct {
+	Step          int
+	Event         string
+	Err           error
+	Compensations map[int]error
+}
+
+func (e SagaError) Error() string {
+	msg := "saga step " + strconv.Itoa(e.Step) + " (" + e.Event + ") failed: " + e.Err.Error()
+	if len(e.Compensations) > 0 {
+		msg += "; " + strconv.Itoa(len(e.Compensations)) + " compensations failed"
+	}
+	return msg
+}
+
+func (e SagaError) Unwrap() error {
+	return e.Err
+}
+
+// ReplayError is returned by FSM.Replay if the event at Index of the log
+// could not be applied.
+type ReplayError struct {
+	Index int
+	Event string
+	Err   error
+}
+
+func (e ReplayError) Error() string {
+	return "replay of event " + strconv.Itoa(e.Index) + " (" + e.Event + ") failed: " + e.Err.Error()
+}
+
+func (e ReplayError) Unwrap() error {
+	return e.Err
+}
+
+// StateConflictError is returned by Fire if another instance of the same
+// group already is in State, see Unique.
+type StateConflictError struct {
+	State string
+	Group string
+}
+
+func (e StateConflictError) Error() string {
+	return "another instance of " + e.Group + " is already " + e.State
+}
+
+// CallbackTimeoutError is returned if Hook of Event, "before", "after",
+// "enter", "any", "to" or the name of a guard, did not return within
+// Timeout. It wraps context.DeadlineExceeded.
+type CallbackTimeoutError struct {
+	Event   string
+	Hook    string
+	Timeout time.Duration
+}
+
+func (e CallbackTimeoutError) Error() string {
+	return "event " + e.Event + ": " + e.Hook + " timed out after " + e.Timeout.String()
+}
+
+func (e CallbackTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// UnreachableStateError is returned if no transitions lead from From to To.
+type UnreachableStateError struct {
+	From string
+	To   string
+}
+
+func (e UnreachableStateError) Error() string {
+	return "state " + e.To + " can't be reached from " + e.From
+}
+
+// FireToError is returned by FSM.FireTo if the instance got stuck in State
+// on its way to Target after firing the events Fired. Event and Guard name
+// the transition that could not be taken and the guard rejecting it, if
+// known.
+type FireToError struct {
+	Target string
+	State  string
+	Fired  []string
+	Event  string
+	Guard  string
+	Err    error
+}
+
+func (e FireToError) Error() string {
+	msg := "can't reach " + e.Target + " from " + e.State
+	if len(e.Fired) > 0 {
+		msg += " after firing " + strings.Join(e.Fired, ", ")
+	}
+	if e.Guard != "" {
+		msg += ": " + e.Event + " blocked by guard " + e.Guard
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e FireToError) Unwrap() error {
+	return e.Err
+}
+
+// UndeclaredTargetError is returned by Fire if the ToFunc of Event chose
+// State, which is not among its Targets.
+type UndeclaredTargetError struct {
+	Event string
+	State string
+}
+
+func (e UndeclaredTargetError) Error() string {
+	return "event " + e.Event + " chose undeclared target " + e.State
+}
+
+// UnresolvedInstanceError is returned for a scheduled fire of Instance of
+// Type that is not held by this process without a loader, see
+// WithScheduleLoader.
+type UnresolvedInstanceError struct {
+	Type     string
+	Instance string
+}
+
+func (e UnresolvedInstanceError) Error() string {
+	return "instance " + e.Instance + " of " + e.Type + " can't be resolved"
+}
+
+// ReasonRequiredError is returned by calls that must be given WithReason,
+// such as FSM.ForceState.
+type ReasonRequiredError struct {
+	Event string
+}
+
+func (e ReasonRequiredError) Error() string {
+	return "event " + e.Event + " requires a reason"
+}
+
+// StaleStateError is returned by Fire with IfState if the instance was in
+// State instead of Expected.
+type StaleStateError struct {
+	Event    string
+	Expected string
+	State    string
+}
+
+func (e StaleStateError) Error() string {
+	return "event " + e.Event + " expected state " + e.Expected + ", instance is " + e.State
+}
+
+// InvariantViolationError is returned by Fire if an invariant registered
+// with FSM.Invariant rejected the instance after Event moved it from State.
+// The instance was rolled back.
+type InvariantViolationError struct {
+	Event string
+	State string
+	Err   error
+}
+
+func (e InvariantViolationError) Error() string {
+	return "event " + e.Event + " from " + e.State + " violates an invariant: " + e.Err.Error()
+}
+
+func (e InvariantViolationError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownVersionError is returned for instances of a version that is not
+// registered, see FSM.RegisterVersion.
+type UnknownVersionError struct {
+	Type    reflect.Type
+	Version int
+}
+
+func (e UnknownVersionError) Error() string {
+	return "version " + strconv.Itoa(e.Version) + " of " + e.Type.String() + " is not registered"
+}
+
+// MergeConflictError is returned by Merge if the overrides declare Event
+// from State more than once.
+type MergeConflictError struct {
+	Event string
+	State string
+}
+
+func (e MergeConflictError) Error() string {
+	return "event " + e.Event + " from " + e.State + " is overridden twice"
+}
+
+type QuotaExceededError struct {
+	Event string
+	Actor string
+	Limit int
+}
+
+func (e QuotaExceededError) Error() string {
+	return "actor " + e.Actor + " exceeded quota of " + strconv.Itoa(e.Limit) + " for event " + e.Event
+}
+
+// ThrottledError is returned by Fire if Event was fired too recently or too
+// often on the instance, see EventTransition.Throttle. It may be fired again
+// after RetryAfter.
+type ThrottledError struct {
+	Event      string
+	RetryAfter time.Duration
+}
+
+func (e ThrottledError) Error() string {
+	return "event " + e.Event + " is throttled, retry after " + e.RetryAfter.String()
+}
+
+// ProviderConflictError is returned by FSM.Install when contributions
+// overlap. Event and From are empty if the whole machine is defined twice;
+// the first provider is empty if a mixin has no machine to extend, or if
+// Registered is set because the machine was added by FSM.Register.
+type ProviderConflictError struct {
+	Type       reflect.Type
+	Event      string
+	From       State
+	Providers  [2]string
+	Registered bool
+}
+
+func (e ProviderConflictError) Error() string {
+	switch {
+	case e.Registered:
+		return "provider " + e.Providers[1] + " defines registered machine " + e.Type.String()
+	case e.Providers[0] == "":
+		return "provider " + e.Providers[1] + " extends unknown machine " + e.Type.String()
+	case e.Event == "":
+		return "providers " + e.Providers[0] + " and " + e.Providers[1] + " both define machine " + e.Type.String()
+	default:
+		return "providers " + e.Providers[0] + " and " + e.Providers[1] + " both define event " + e.Event +
+			" from " + string(e.From) + " on " + e.Type.String()
+	}
+}
+
+type UnknownGuardError struct {
+	Event string
+	Guard string
+}
+
+func (e UnknownGuardError) Error() string {
+	return "guard " + e.Guard + " of event " + e.Event + " is not registered"
+}
+
+type DuplicateNameError struct {
+	Name string
+}
+
+func (e DuplicateNameError) Error() string {
+	return "name " + e.Name + " is already registered"
+}
+
+type UnknownVarError struct {
+	Name string
+}
+
+func (e UnknownVarError) Error() string {
+	return "variable " + e.Name + " is not declared"
+}
+
+type VarTypeError struct {
+	Name string
+	Type string
+}
+
+func (e VarTypeError) Error() string {
+	return "variable " + e.Name + " must be of type " + e.Type
+}
+
+type UnknownCallbackError struct {
+	Event    string
+	Callback string
+}
+
+func (e UnknownCallbackError) Error() string {
+	return "callback " + e.Callback + " of event " + e.Event + " is not registered"
+}
+
+// SchemaError is returned for an invalid declarative definition.
+type SchemaError struct {
+	Event  string
+	Reason string
+}
+
+func (e SchemaError) Error() string {
+	if e.Event != "" {
+		return "invalid definition of event " + e.Event + ": " + e.Reason
+	}
+	return "invalid definition: " + e.Reason
+}
@@ -0,0 +1,64 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestEventFields(t *testing.T) {
+	var attempts []int
+	var dryRuns []bool
+	var seen Event
+
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:  "pay",
+		From:  []State{"created"},
+		To:    State("paid"),
+		Retry: RetryPolicy{MaxAttempts: 3},
+		Guards: []Guard{func(ctx context.Context, e *Event) (bool, error) {
+			dryRuns = append(dryRuns, e.DryRun)
+			return true, nil
+		}},
+		Before: func(ctx context.Context, e *Event) error {
+			if attempts = append(attempts, e.Attempt); e.Attempt < 2 {
+				return errors.New("gateway unavailable")
+			}
+			return nil
+		},
+		After: func(ctx context.Context, e *Event) error {
+			seen = *e
+			return nil
+		},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("created")}
+	if ok, err := fsm.MayFire(ctx, testStruct, "pay"); !ok || err != nil {
+		t.Errorf("MayFire() = %v, %v", ok, err)
+	}
+	if err := fsm.Fire(ctx, testStruct, "pay", WithArgs(42, "EUR")); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	if !slices.Equal(attempts, []int{1, 2}) {
+		t.Errorf("Before attempts = %v, want [1 2]", attempts)
+	}
+	if !slices.Equal(dryRuns, []bool{true, false}) {
+		t.Errorf("guard DryRun = %v, want [true false]", dryRuns)
+	}
+	if seen.From != "created" || seen.Destination != "paid" || seen.Attempt != 1 || !slices.Equal(seen.Args, []interface{}{42, "EUR"}) {
+		t.Errorf("After got %+v", seen)
+	}
+	if s, ok := SourceOf[*TestStruct](&seen); !ok || s != testStruct {
+		t.Errorf("SourceOf() = %v, %v", s, ok)
+	}
+	if _, ok := SourceOf[*Order](&seen); ok {
+		t.Errorf("SourceOf[*Order]() ok, want false")
+	}
+}
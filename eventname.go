@@ -0,0 +1,45 @@
+package fsm
+
+import "context"
+
+// EventName is the name of an event of the machine of model type T.
+// Declaring the events of a machine as constants,
+//
+//	const (
+//		Pay  fsm.EventName[*Order] = "pay"
+//		Ship fsm.EventName[*Order] = "ship"
+//	)
+//
+// and firing them with Fire catches misspelled events, and events fired on
+// instances of the wrong type, at compile time. Use Name, e.g. in
+// EventTransition.Name, where a plain string is expected.
+type EventName[T any] string
+
+// Name returns the event name as a string.
+func (e EventName[T]) Name() string {
+	return string(e)
+}
+
+// Fire fires the event on s through m.
+func (e EventName[T]) Fire(ctx context.Context, m Machine, s T, options ...Option) error {
+	return m.Fire(ctx, s, string(e), options...)
+}
+
+// MayFire reports whether m may fire the event on s.
+func (e EventName[T]) MayFire(ctx context.Context, m Machine, s T, options ...Option) (bool, error) {
+	return m.MayFire(ctx, s, string(e), options...)
+}
+
+// PermittedEvents is GetPermittedEvents returning EventNames of T.
+func PermittedEvents[T any](ctx context.Context, m Machine, s T, options ...Option) ([]EventName[T], error) {
+	names, err := m.GetPermittedEvents(ctx, s, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]EventName[T], len(names))
+	for i, name := range names {
+		events[i] = EventName[T](name)
+	}
+	return events, nil
+}
@@ -0,0 +1,42 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+const (
+	testStart  EventName[*TestStruct] = "start"
+	testFinish EventName[*TestStruct] = "finish"
+)
+
+func TestEventName(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: testStart.Name(),
+		From: []State{"created"},
+		To:   State("started"),
+	}, {
+		Name: testFinish.Name(),
+		From: []State{"started"},
+		To:   State("finished"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("created")}
+	if err := testStart.Fire(ctx, fsm, testStruct); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	events, err := PermittedEvents(ctx, fsm, testStruct)
+	if err != nil || !reflect.DeepEqual(events, []EventName[*TestStruct]{testFinish}) {
+		t.Errorf("PermittedEvents() = %v, %v, want [%v]", events, err, testFinish)
+	}
+
+	if ok, err := testFinish.MayFire(ctx, fsm, testStruct); !ok || err != nil {
+		t.Errorf("MayFire() = %v, %v, want true", ok, err)
+	}
+}
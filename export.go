@@ -0,0 +1,224 @@
+package fsm
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ExportOptions configures the DOT, Mermaid and Markdown exporters.
+type ExportOptions struct {
+	// Title names the machine, e.g. "Order".
+	Title string
+	// Version stamps the export, e.g. a release or commit.
+	Version string
+	// Diff, if set, annotates transitions added since the previous version
+	// and draws removed ones, producing a visual changelog.
+	Diff *SchemaDiff
+	// IncludeDraft adds draft transitions, drawn dotted.
+	IncludeDraft bool
+}
+
+type change int
+
+const (
+	unchanged change = iota
+	added
+	removed
+	draft
+	modified
+)
+
+type exportEdge struct {
+	Transition
+	change change
+	labels map[string]string
+}
+
+// exportEdges returns the transitions of s followed by those removed by
+// opts.Diff and the drafts if requested, each with its change.
+func exportEdges(s Schema, opts ExportOptions) []exportEdge {
+	isAdded := make(map[Transition]bool)
+	isModified := make(map[Transition]bool)
+	var gone []Transition
+	if opts.Diff != nil {
+		for _, t := range opts.Diff.Added {
+			isAdded[t] = true
+		}
+		for _, m := range opts.Diff.Modified {
+			isModified[Transition{Event: m.Event, From: m.From, To: m.NewTo}] = true
+		}
+		gone = opts.Diff.Removed
+	}
+
+	labels := s.labelsOf()
+	var edges []exportEdge
+	for _, t := range s.edges(false) {
+		c := unchanged
+		switch {
+		case isAdded[t]:
+			c = added
+		case isModified[t]:
+			c = modified
+		}
+		edges = append(edges, exportEdge{Transition: t, change: c, labels: labels[t]})
+	}
+	for _, t := range gone {
+		edges = append(edges, exportEdge{Transition: t, change: removed})
+	}
+	if opts.IncludeDraft {
+		for _, t := range s.edges(true) {
+			edges = append(edges, exportEdge{Transition: t, change: draft, labels: labels[t]})
+		}
+	}
+	return edges
+}
+
+// labelsOf returns the labels of the transitions of s.
+func (s Schema) labelsOf() map[Transition]map[string]string {
+	labels := make(map[Transition]map[string]string)
+	for _, e := range s.Events {
+		if len(e.Labels) == 0 {
+			continue
+		}
+		for _, src := range e.From {
+			labels[Transition{Event: e.Name, From: src, To: e.To}] = e.Labels
+		}
+	}
+	return labels
+}
+
+// formatLabels returns labels as "key=value" pairs sorted by key.
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+func (o ExportOptions) heading() string {
+	title := o.Title
+	if title == "" {
+		title = "state machine"
+	}
+	if o.Version != "" {
+		title += " " + o.Version
+	}
+	return title
+}
+
+// WriteDOT writes s as a Graphviz digraph. Added transitions are green,
+// modified ones orange, removed ones red and dashed, drafts gray and
+// dotted. Labels become the tooltip of their edge.
+func WriteDOT(w io.Writer, s Schema, opts ExportOptions) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", opts.heading())
+	fmt.Fprintf(&b, "\tlabel=%q;\n", opts.heading())
+
+	for _, e := range exportEdges(s, opts) {
+		attrs := fmt.Sprintf("label=%q", e.Event)
+		switch e.change {
+		case added:
+			attrs = fmt.Sprintf("label=%q, color=green, fontcolor=green", "+ "+e.Event)
+		case removed:
+			attrs = fmt.Sprintf("label=%q, color=red, fontcolor=red, style=dashed", "- "+e.Event)
+		case modified:
+			attrs = fmt.Sprintf("label=%q, color=orange, fontcolor=orange", "~ "+e.Event)
+		case draft:
+			attrs = fmt.Sprintf("label=%q, color=gray, fontcolor=gray, style=dotted", e.Event+" (draft)")
+		}
+		if len(e.labels) > 0 {
+			attrs += fmt.Sprintf(", tooltip=%q", formatLabels(e.labels))
+		}
+		fmt.Fprintf(&b, "\t%q -> %q [%s];\n", e.From, e.To, attrs)
+	}
+
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid writes s as a Mermaid state diagram. Added transitions are
+// labelled "+", modified ones "~", removed ones "-" and drafts "(draft)".
+func WriteMermaid(w io.Writer, s Schema, opts ExportOptions) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "---\ntitle: %s\n---\nstateDiagram-v2\n", opts.heading())
+
+	for _, e := range exportEdges(s, opts) {
+		label := e.Event
+		switch e.change {
+		case added:
+			label = "+ " + label
+		case removed:
+			label = "- " + label
+		case modified:
+			label = "~ " + label
+		case draft:
+			label += " (draft)"
+		}
+		fmt.Fprintf(&b, "\t%s --> %s: %s\n", mermaidState(e.From), mermaidState(e.To), label)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidState maps the empty initial state to Mermaid's start marker.
+func mermaidState(state State) string {
+	if state == "" {
+		return "[*]"
+	}
+	return string(state)
+}
+
+// WriteMarkdown writes s as a Markdown table of transitions with a change
+// column when opts.Diff or opts.IncludeDraft is set, and a labels column
+// when any transition has labels.
+func WriteMarkdown(w io.Writer, s Schema, opts ExportOptions) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", opts.heading())
+
+	edges := exportEdges(s, opts)
+	annotated := opts.Diff != nil || opts.IncludeDraft
+	labelled := false
+	for _, e := range edges {
+		labelled = labelled || len(e.labels) > 0
+	}
+
+	header, rule := "| Event | From | To |", "|---|---|---|"
+	if annotated {
+		header, rule = header+" Change |", rule+"---|"
+	}
+	if labelled {
+		header, rule = header+" Labels |", rule+"---|"
+	}
+	b.WriteString(header + "\n" + rule + "\n")
+
+	for _, e := range edges {
+		row := fmt.Sprintf("| %s | %s | %s |", e.Event, e.From, e.To)
+		if annotated {
+			switch e.change {
+			case added:
+				row += " added |"
+			case removed:
+				row = fmt.Sprintf("| ~~%s~~ | ~~%s~~ | ~~%s~~ | removed |", e.Event, e.From, e.To)
+			case modified:
+				row += " modified |"
+			case draft:
+				row += " draft |"
+			default:
+				row += "  |"
+			}
+		}
+		if labelled {
+			row += " " + formatLabels(e.labels) + " |"
+		}
+		b.WriteString(row + "\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,227 @@
+package fsm
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func exportSchemas(t *testing.T) (Schema, Schema) {
+	fsm := NewFSM()
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "pay",
+		From: []State{"created"},
+		To:   State("paid"),
+	}, {
+		Name: "cancel",
+		From: []State{"created", "paid"},
+		To:   State("canceled"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	old, err := fsm.Schema(tag)
+	if err != nil {
+		t.Errorf("Schema() error = %v", err)
+	}
+
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "pay",
+		From: []State{"created"},
+		To:   State("paid"),
+	}, {
+		Name: "cancel",
+		From: []State{"created"},
+		To:   State("canceled"),
+	}, {
+		Name: "ship",
+		From: []State{"paid"},
+		To:   State("shipped"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	new, err := fsm.Schema(tag)
+	if err != nil {
+		t.Errorf("Schema() error = %v", err)
+	}
+	return old, new
+}
+
+func TestDiff(t *testing.T) {
+	old, new := exportSchemas(t)
+
+	d := Diff(old, new)
+	want := SchemaDiff{
+		Added:       []Transition{{Event: "ship", From: "paid", To: "shipped"}},
+		Removed:     []Transition{{Event: "cancel", From: "paid", To: "canceled"}},
+		AddedStates: []State{"shipped"},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("Diff() = %+v, want %+v", d, want)
+	}
+	if !Diff(new, new).Empty() {
+		t.Errorf("Diff() of equal schemas is not empty")
+	}
+}
+
+func TestDiffEventsModified(t *testing.T) {
+	old := Events{{Name: "pay", From: []State{"created"}, To: State("paid")}}
+	new := Events{{Name: "pay", From: []State{"created"}, To: State("settled"), GuardNames: []string{"funded"}}}
+
+	d := DiffEvents(old, new)
+	want := SchemaDiff{
+		Modified: []TransitionChange{{
+			Event:     "pay",
+			From:      "created",
+			OldTo:     "paid",
+			NewTo:     "settled",
+			NewGuards: []string{"funded"},
+		}},
+		AddedStates:   []State{"settled"},
+		RemovedStates: []State{"paid"},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("DiffEvents() = %+v, want %+v", d, want)
+	}
+
+	var b bytes.Buffer
+	if err := WriteMermaid(&b, eventsSchema(new), ExportOptions{Diff: &d}); err != nil {
+		t.Errorf("WriteMermaid() error = %v", err)
+	}
+	if want := "\tcreated --> settled: ~ pay\n"; !strings.Contains(b.String(), want) {
+		t.Errorf("WriteMermaid() = %q, want %q", b.String(), want)
+	}
+}
+
+func TestExporters(t *testing.T) {
+	old, new := exportSchemas(t)
+	d := Diff(old, new)
+	opts := ExportOptions{Title: "Order", Version: "v2", Diff: &d}
+
+	tests := []struct {
+		name  string
+		write func(*bytes.Buffer) error
+		want  string
+	}{
+		{"dot", func(b *bytes.Buffer) error { return WriteDOT(b, new, opts) }, `digraph "Order v2" {
+	label="Order v2";
+	"created" -> "paid" [label="pay"];
+	"created" -> "canceled" [label="cancel"];
+	"paid" -> "shipped" [label="+ ship", color=green, fontcolor=green];
+	"paid" -> "canceled" [label="- cancel", color=red, fontcolor=red, style=dashed];
+}
+`},
+		{"mermaid", func(b *bytes.Buffer) error { return WriteMermaid(b, new, opts) }, `---
+title: Order v2
+---
+stateDiagram-v2
+	created --> paid: pay
+	created --> canceled: cancel
+	paid --> shipped: + ship
+	paid --> canceled: - cancel
+`},
+		{"markdown", func(b *bytes.Buffer) error { return WriteMarkdown(b, new, opts) }, `## Order v2
+
+| Event | From | To | Change |
+|---|---|---|---|
+| pay | created | paid |  |
+| cancel | created | canceled |  |
+| ship | paid | shipped | added |
+| ~~cancel~~ | ~~paid~~ | ~~canceled~~ | removed |
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := tt.write(&b); err != nil {
+				t.Errorf("write error = %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestExportLabels(t *testing.T) {
+	s := Schema{Column: "State", Events: []SchemaEvent{{
+		Name:   "cancel",
+		From:   []State{"created"},
+		To:     "canceled",
+		Labels: map[string]string{"title": "Cancel order", "color": "red"},
+	}, {
+		Name: "pay",
+		From: []State{"created"},
+		To:   "paid",
+	}}}
+
+	var md, dot bytes.Buffer
+	if err := WriteMarkdown(&md, s, ExportOptions{}); err != nil {
+		t.Errorf("WriteMarkdown() error = %v", err)
+	}
+	if err := WriteDOT(&dot, s, ExportOptions{}); err != nil {
+		t.Errorf("WriteDOT() error = %v", err)
+	}
+
+	if !strings.Contains(md.String(), "| cancel | created | canceled | color=red, title=Cancel order |") ||
+		!strings.Contains(md.String(), "| pay | created | paid |  |") {
+		t.Errorf("WriteMarkdown() = %s", md.String())
+	}
+	if !strings.Contains(dot.String(), `tooltip="color=red, title=Cancel order"`) {
+		t.Errorf("WriteDOT() = %s", dot.String())
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	s := Schema{Column: "State", Events: []SchemaEvent{
+		{Name: "pay", From: []State{"created"}, To: "paid"},
+		{Name: "cancel", From: []State{"created", "paid"}, To: "canceled"},
+	}}
+
+	var b bytes.Buffer
+	if err := WriteJSONSchema(&b, s, ExportOptions{Title: "Order"}); err != nil {
+		t.Errorf("WriteJSONSchema() error = %v", err)
+	}
+
+	var got struct {
+		Title      string `json:"title"`
+		Properties map[string]struct {
+			Enum []string `json:"enum"`
+		} `json:"properties"`
+		AllOf []struct {
+			If struct {
+				Properties map[string]struct {
+					Const string `json:"const"`
+				} `json:"properties"`
+			} `json:"if"`
+			Then struct {
+				Properties map[string]struct {
+					Enum []string `json:"enum"`
+				} `json:"properties"`
+			} `json:"then"`
+		} `json:"allOf"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v\n%s", err, b.String())
+	}
+
+	if got.Title != "Order" || !slices.Equal(got.Properties["State"].Enum, []string{"canceled", "created", "paid"}) ||
+		!slices.Equal(got.Properties["event"].Enum, []string{"cancel", "pay"}) {
+		t.Errorf("WriteJSONSchema() =\n%s", b.String())
+	}
+
+	permitted := make(map[string][]string)
+	for _, rule := range got.AllOf {
+		permitted[rule.If.Properties["State"].Const] = rule.Then.Properties["event"].Enum
+	}
+	want := map[string][]string{"canceled": {}, "created": {"cancel", "pay"}, "paid": {"cancel"}}
+	if !reflect.DeepEqual(permitted, want) {
+		t.Errorf("events by state = %v, want %v", permitted, want)
+	}
+}
@@ -0,0 +1,86 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFairnessFIFO(t *testing.T) {
+	var order []int
+	release := make(chan struct{})
+
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "ping",
+		From: []State{"started"},
+		To:   State("started"),
+		After: func(ctx context.Context, e *Event) error {
+			if n, ok := ctx.Value(orderKey{}).(int); ok {
+				order = append(order, n)
+			} else {
+				<-release
+			}
+			return nil
+		},
+	}}, WithFairness(FairnessFIFO)); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("started")}
+	machine, _ := fsm.machine(testStruct)
+
+	holder := make(chan error)
+	go func() { holder <- fsm.Fire(context.Background(), testStruct, "ping") }()
+	waitForWaiters(t, machine, testStruct, 0)
+
+	done := make(chan error)
+	for i := 1; i <= 5; i++ {
+		go func(i int) {
+			done <- fsm.Fire(context.WithValue(context.Background(), orderKey{}, i), testStruct, "ping")
+		}(i)
+		waitForWaiters(t, machine, testStruct, i)
+	}
+
+	close(release)
+	if err := <-holder; err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := <-done; err != nil {
+			t.Errorf("Fire() error = %v", err)
+		}
+	}
+
+	if want := []int{1, 2, 3, 4, 5}; !reflect.DeepEqual(order, want) {
+		t.Errorf("waiters ran in order %v, want %v", order, want)
+	}
+}
+
+type orderKey struct{}
+
+// waitForWaiters blocks until n goroutines wait for the lock of s.
+func waitForWaiters(t *testing.T, f *fsm, s interface{}, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		f.instanceLocks.mu.Lock()
+		m, ok := f.instanceLocks.locks[s]
+		f.instanceLocks.mu.Unlock()
+
+		if ok {
+			fm := m.Locker.(*fifoMutex)
+			fm.mu.Lock()
+			waiting := len(fm.waiters)
+			locked := fm.locked
+			fm.mu.Unlock()
+			if locked && waiting == n {
+				return
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d waiters", n)
+}
@@ -0,0 +1,145 @@
+package fsm
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// FallbackMode is the decision taken for a guard whose dependencies are
+// unavailable.
+type FallbackMode int
+
+const (
+	// FallbackNone returns the guard error from Fire, the default.
+	FallbackNone FallbackMode = iota
+	// FallbackDeny rejects the transition.
+	FallbackDeny
+	// FallbackAllow lets the transition pass the guard.
+	FallbackAllow
+	// FallbackCached reuses the last outcome of the guard for the same
+	// instance if it is not older than MaxAge, and rejects otherwise. See
+	// WithGuardCacheSize.
+	FallbackCached
+)
+
+// GuardFallback is the degradation policy of the guards of a transition.
+type GuardFallback struct {
+	Mode FallbackMode
+	// MaxAge bounds the age of results reused by FallbackCached, zero means
+	// any age.
+	MaxAge time.Duration
+}
+
+// UnavailableError marks a guard error caused by an infrastructure outage
+// rather than a decision, see Unavailable.
+type UnavailableError struct {
+	Err error
+}
+
+func (e UnavailableError) Error() string {
+	return "guard dependency unavailable: " + e.Err.Error()
+}
+
+func (e UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// Unavailable wraps err so the transition's GuardFallback applies to it.
+func Unavailable(err error) error {
+	return UnavailableError{Err: err}
+}
+
+// WithUnavailableClassifier overrides how guard errors are recognised as
+// infrastructure failures, by default errors wrapping UnavailableError.
+func WithUnavailableClassifier(fn func(error) bool) FSMOption {
+	return func(f *FSM) {
+		f.isUnavailable = fn
+	}
+}
+
+func (f *FSM) unavailable(err error) bool {
+	if f.isUnavailable != nil {
+		return f.isUnavailable(err)
+	}
+	return errors.As(err, new(UnavailableError))
+}
+
+// DefaultGuardCacheSize is how many guard outcomes each machine keeps for
+// FallbackCached unless WithGuardCacheSize is set.
+const DefaultGuardCacheSize = 10000
+
+// WithGuardCacheSize bounds how many guard outcomes each machine keeps for
+// FallbackCached. Once full, the oldest outcome is forgotten first.
+func WithGuardCacheSize(n int) FSMOption {
+	return func(f *FSM) {
+		f.guardCacheSize = n
+	}
+}
+
+type cachedGuard struct {
+	passed bool
+	at     time.Time
+}
+
+// guardCache holds the last outcome of guards for FallbackCached, forgetting
+// the oldest once it holds more than its size.
+type guardCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedGuard
+	order   []string // keys by first store, oldest first
+}
+
+func (c *guardCache) store(key string, guard cachedGuard, size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]cachedGuard)
+	}
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = guard
+
+	for len(c.order) > 0 && len(c.entries) > max(size, 1) {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// load returns the outcome stored under key unless it is older than maxAge,
+// which is forgotten. Zero maxAge accepts any age.
+func (c *guardCache) load(key string, maxAge time.Duration, now time.Time) (cachedGuard, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	guard, ok := c.entries[key]
+	if ok && maxAge > 0 && now.Sub(guard.at) > maxAge {
+		c.forget(key)
+		return cachedGuard{}, false
+	}
+	return guard, ok
+}
+
+// forget removes key, c.mu must be held.
+func (c *guardCache) forget(key string) {
+	delete(c.entries, key)
+	for i, k := range c.order {
+		if k == key {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// len returns the number of outcomes held.
+func (c *guardCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (f *fsm) guardCacheKey(g namedGuard, e *Event) string {
+	return f.instanceKey(e.Source) + ":" + e.Event + ":" + string(e.From) + ":" + g.name
+}
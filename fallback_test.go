@@ -0,0 +1,114 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGuardFallback(t *testing.T) {
+	down := false
+	permissions := func(ctx context.Context, e *Event) (bool, error) {
+		if down {
+			return false, Unavailable(errors.New("permissions service timeout"))
+		}
+		return true, nil
+	}
+
+	cases := []struct {
+		mode FallbackMode
+		want bool
+	}{
+		{FallbackDeny, false},
+		{FallbackAllow, true},
+		{FallbackCached, true},
+	}
+
+	for _, c := range cases {
+		down = false
+
+		fsm := NewFSM()
+		if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+			Name:     "make",
+			From:     []State{"started"},
+			To:       State("started"),
+			Guards:   []Guard{permissions},
+			Fallback: GuardFallback{Mode: c.mode},
+		}}); err != nil {
+			t.Errorf("fsm.Register() error = %v", err)
+		}
+
+		testStruct := &TestStruct{State: State("started")}
+		if err := fsm.Fire(context.Background(), testStruct, "make"); err != nil {
+			t.Errorf("mode %d: Fire() error = %v", c.mode, err)
+		}
+
+		down = true
+		ok, err := fsm.MayFire(context.Background(), testStruct, "make")
+		if err != nil || ok != c.want {
+			t.Errorf("mode %d: MayFire() = (%v, %v), want %v", c.mode, ok, err, c.want)
+		}
+	}
+}
+
+func TestGuardFallbackPerTransition(t *testing.T) {
+	down := func(ctx context.Context, e *Event) (bool, error) {
+		return false, Unavailable(errors.New("permissions service timeout"))
+	}
+
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:     "make",
+		From:     []State{"started"},
+		To:       State("made"),
+		Guards:   []Guard{down},
+		Fallback: GuardFallback{Mode: FallbackAllow},
+	}, {
+		Name:     "make",
+		From:     []State{"paused"},
+		To:       State("made"),
+		Guards:   []Guard{down},
+		Fallback: GuardFallback{Mode: FallbackDeny},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	for state, want := range map[State]bool{"started": true, "paused": false} {
+		if ok, err := fsm.MayFire(context.Background(), &TestStruct{State: state}, "make"); err != nil || ok != want {
+			t.Errorf("MayFire() from %s = (%v, %v), want %v", state, ok, err, want)
+		}
+	}
+}
+
+func TestGuardCacheSize(t *testing.T) {
+	fsm := NewFSM(WithGuardCacheSize(2))
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name:     "make",
+		From:     []State{"started"},
+		To:       State("started"),
+		Guards:   []Guard{func(ctx context.Context, e *Event) (bool, error) { return true, nil }},
+		Fallback: GuardFallback{Mode: FallbackCached},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	instances := []*TestStruct{{State: "started"}, {State: "started"}, {State: "started"}}
+	for _, s := range instances {
+		if err := fsm.Fire(context.Background(), s, "make"); err != nil {
+			t.Errorf("Fire() error = %v", err)
+		}
+	}
+
+	machine, _ := fsm.machine(instances[0])
+	if n := machine.guardCache.len(); n != 2 {
+		t.Errorf("guard cache holds %d outcomes, want 2", n)
+	}
+	guard := machine.table().branches[eventKey{"make", "started"}][0].guards[0]
+	oldest := machine.guardCacheKey(guard, &Event{Event: "make", Source: instances[0], From: "started"})
+	if _, ok := machine.guardCache.load(oldest, 0, time.Now()); ok {
+		t.Error("oldest outcome still cached")
+	}
+}
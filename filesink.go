@@ -0,0 +1,171 @@
+package fsm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Compression wraps w so a batch written to it is compressed. The returned
+// writer is closed at the end of every batch.
+type Compression func(w io.Writer) (io.WriteCloser, error)
+
+// Gzip compresses batches as gzip members at level. A file of appended
+// members is itself a valid gzip stream.
+func Gzip(level int) Compression {
+	return func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, level)
+	}
+}
+
+// DefaultBatchSize is the number of entries a FileSink or SQLSink buffers before it
+// flushes on its own.
+const DefaultBatchSize = 1000
+
+// DefaultFlushInterval is how often FileSink, SQLSink and Aggregator flush when run
+// without an interval.
+const DefaultFlushInterval = time.Minute
+
+// FileSink is an AuditSink appending history entries as JSON lines to the
+// file at Path. Entries are buffered and written in batches, compressed with
+// Compression if set, e.g. Gzip(gzip.BestSpeed) or a zstd encoder. Run it
+// to also flush every FlushInterval, and Flush before the process exits.
+// A batch is appended in a single write and undone if that fails, so the
+// file must not be appended to by other writers. Clock times Run, the
+// system clock if nil.
+type FileSink struct {
+	Path          string
+	Compression   Compression
+	BatchSize     int
+	FlushInterval time.Duration
+	Clock         Clock
+
+	mu      sync.Mutex
+	pending []HistoryEntry
+}
+
+func (s *FileSink) Record(ctx context.Context, entry HistoryEntry) error {
+	s.mu.Lock()
+	s.pending = append(s.pending, entry)
+	full := len(s.pending) >= s.batchSize()
+	s.mu.Unlock()
+
+	if full {
+		return s.Flush()
+	}
+	return nil
+}
+
+func (s *FileSink) batchSize() int {
+	if s.BatchSize > 0 {
+		return s.BatchSize
+	}
+	return DefaultBatchSize
+}
+
+// Flush writes the buffered entries as one batch. They are kept for the
+// next flush if writing fails.
+func (s *FileSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	if err := s.write(s.pending); err != nil {
+		return err
+	}
+	s.pending = nil
+	return nil
+}
+
+// write appends entries to the file in a single write. If it fails the file
+// is truncated to its previous size, so a retried batch is not duplicated.
+func (s *FileSink) write(entries []HistoryEntry) (err error) {
+	batch, err := encodeBatch(entries, s.Compression)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(batch); err != nil {
+		return errors.Join(err, f.Truncate(info.Size()))
+	}
+	return nil
+}
+
+// encodeBatch returns entries as JSON lines, compressed with compression if
+// it is set.
+func encodeBatch(entries []HistoryEntry, compression Compression) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var cw io.WriteCloser
+	if compression != nil {
+		var err error
+		if cw, err = compression(&buf); err != nil {
+			return nil, err
+		}
+		w = cw
+	}
+
+	enc := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return nil, err
+		}
+	}
+
+	if cw != nil {
+		if err := cw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Run flushes every FlushInterval, DefaultFlushInterval if unset, until ctx
+// is done, then flushes once more.
+func (s *FileSink) Run(ctx context.Context) error {
+	return runFlushes(ctx, timersOf(s.Clock), s.FlushInterval, func(context.Context) error { return s.Flush() })
+}
+
+// runFlushes calls flush every interval on c, DefaultFlushInterval if unset,
+// until ctx is done, then once more with a context that is not canceled.
+func runFlushes(ctx context.Context, c TimerClock, interval time.Duration, flush func(ctx context.Context) error) error {
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+
+	for {
+		if err := sleep(ctx, c, interval); err != nil {
+			if ferr := flush(context.WithoutCancel(ctx)); ferr != nil {
+				return ferr
+			}
+			return err
+		}
+		if err := flush(ctx); err != nil {
+			return err
+		}
+	}
+}
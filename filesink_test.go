@@ -0,0 +1,92 @@
+package fsm
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileSinkGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl.gz")
+	sink := &FileSink{Path: path, Compression: Gzip(gzip.BestSpeed), BatchSize: 2}
+
+	fsm := NewFSM(WithAuditSink(sink))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "toggle",
+		From: []State{"on"},
+		To:   State("off"),
+	}, {
+		Name: "toggle",
+		From: []State{"off"},
+		To:   State("on"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("on")}
+	for i := 0; i < 5; i++ {
+		if err := fsm.Fire(ctx, testStruct, "toggle"); err != nil {
+			t.Errorf("Fire() error = %v", err)
+		}
+	}
+	if err := sink.Flush(); err != nil {
+		t.Errorf("Flush() error = %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+
+	var tos []State
+	scanner := bufio.NewScanner(zr)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Errorf("Unmarshal() error = %v", err)
+		}
+		tos = append(tos, entry.To)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Errorf("reading history error = %v", err)
+	}
+
+	want := []State{"off", "on", "off", "on", "off"}
+	if !reflect.DeepEqual(tos, want) {
+		t.Errorf("recorded %v, want %v", tos, want)
+	}
+}
+
+func TestFileSinkRunDefaultInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	clock := &instantTimers{}
+	sink := &FileSink{Path: path, Clock: clock}
+	if err := sink.Record(context.Background(), HistoryEntry{Event: "pay", To: "paid"}); err != nil {
+		t.Errorf("Record() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sink.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || len(data) == 0 {
+		t.Errorf("Run() did not flush on exit: %q, %v", data, err)
+	}
+	if len(clock.waits) == 0 || clock.waits[0] != DefaultFlushInterval {
+		t.Errorf("Run() waited %v, want DefaultFlushInterval", clock.waits)
+	}
+}
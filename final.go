@@ -0,0 +1,40 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+)
+
+// isFinal reports whether state was marked final.
+func (f *fsm) isFinal(state State) bool {
+	return f.stateConfig().finals[state]
+}
+
+// MarkFinal func to mark states of the default machine of tag as final.
+// Instances in a final state are complete: Fire returns
+// MachineCompletedError and no events are permitted.
+func (f *FSM) MarkFinal(tag reflect.Type, states ...State) error {
+	return f.updateStates(tag, func(c *stateConfig) {
+		for _, state := range states {
+			c.finals[state] = true
+		}
+	})
+}
+
+// OnComplete func to set the callback run once an instance of the default
+// machine of tag enters a final state, after the After callback of the
+// transition
+func (f *FSM) OnComplete(tag reflect.Type, fn Callback) error {
+	return f.updateStates(tag, func(c *stateConfig) {
+		c.onComplete = fn
+	})
+}
+
+// completed runs the OnComplete callback if e entered a final state.
+func (f *fsm) completed(ctx context.Context, e *Event) error {
+	c := f.stateConfig()
+	if !c.finals[e.Destination] || c.onComplete == nil {
+		return nil
+	}
+	return c.onComplete(ctx, e)
+}
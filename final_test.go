@@ -0,0 +1,70 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFinalStates(t *testing.T) {
+	fsm := NewFSM()
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "finish",
+		From: []State{"started"},
+		To:   State("finished"),
+	}, {
+		Name: "restart",
+		From: []State{"finished"},
+		To:   State("started"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	completed := 0
+	if err := fsm.MarkFinal(tag, State("finished")); err != nil {
+		t.Errorf("MarkFinal() error = %v", err)
+	}
+	if err := fsm.OnComplete(tag, func(ctx context.Context, e *Event) error {
+		completed++
+		return nil
+	}); err != nil {
+		t.Errorf("OnComplete() error = %v", err)
+	}
+
+	instance := &TestStruct{State: State("started")}
+	if err := fsm.Fire(context.Background(), instance, "finish"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if completed != 1 {
+		t.Errorf("OnComplete ran %d times, want 1", completed)
+	}
+
+	err := fsm.Fire(context.Background(), instance, "restart")
+	if !errors.As(err, new(MachineCompletedError)) {
+		t.Errorf("Fire() in final state error = %v, want MachineCompletedError", err)
+	}
+	if completed != 1 {
+		t.Errorf("OnComplete ran %d times, want 1", completed)
+	}
+
+	events, err := fsm.GetPermittedEvents(context.Background(), instance)
+	if err != nil {
+		t.Errorf("GetPermittedEvents() error = %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("GetPermittedEvents() = %v, want none", events)
+	}
+
+	if ok, _ := fsm.MayFire(context.Background(), instance, "restart"); ok {
+		t.Errorf("MayFire() in final state = true")
+	}
+}
+
+func TestMarkFinalUnregistered(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.MarkFinal(reflect.TypeOf((*TestStruct)(nil)), State("finished")); err == nil {
+		t.Errorf("MarkFinal() on unregistered type succeeded")
+	}
+}
@@ -0,0 +1,73 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+)
+
+// FireTo func to fire events on s until it is in state target, e.g. to
+// move an entity deep into a workflow from admin tooling or tests. Before
+// every step the shortest path to target is computed, starting with a
+// transition permitted from the current state and not returning to a state
+// already visited. If no such path remains or Fire fails, FireTo stops with
+// a FireToError holding the events fired so far.
+func (f *FSM) FireTo(ctx context.Context, s interface{}, target State, options ...Option) error {
+	var fired []string
+	visited := map[State]bool{}
+
+	for {
+		machine, ok := f.machine(s, options...)
+		if !ok {
+			return InternalError{}
+		}
+
+		_, state, err := machine.getSourceState(s)
+		if err != nil {
+			return err
+		}
+		if state == target {
+			return nil
+		}
+		visited[state] = true
+
+		infos, err := machine.GetPermittedTransitions(ctx, s, options...)
+		if err != nil {
+			return FireToError{Target: string(target), State: string(state), Fired: fired, Err: err}
+		}
+
+		permitted := make(map[Transition]bool)
+		for _, info := range infos {
+			if info.Permitted {
+				permitted[Transition{Event: info.Event, From: info.From, To: info.To}] = true
+			}
+		}
+
+		edges := machine.table().schema.edges(false)
+		path, ok := shortestPath(edges, state, target, func(t Transition) bool {
+			return !visited[t.To] && (t.From != state || permitted[t])
+		})
+		if !ok {
+			ferr := FireToError{Target: string(target), State: string(state), Fired: fired, Err: UnreachableStateError{From: string(state), To: string(target)}}
+			// Name the guard blocking the shortest path ignoring guards, if any.
+			if blocked, ok := shortestPath(edges, state, target, func(t Transition) bool { return !visited[t.To] }); ok {
+				for _, info := range infos {
+					if info.Event == blocked[0].Event {
+						ferr.Event, ferr.Guard = info.Event, info.Guard
+					}
+				}
+			}
+			return ferr
+		}
+
+		event := path[0].Event
+		if err := f.Fire(ctx, s, event, options...); err != nil {
+			ferr := FireToError{Target: string(target), State: string(state), Fired: fired, Event: event, Err: err}
+			var terr InvalidTransitionError
+			if errors.As(err, &terr) {
+				ferr.Guard = terr.Guard
+			}
+			return ferr
+		}
+		fired = append(fired, event)
+	}
+}
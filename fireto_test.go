@@ -0,0 +1,46 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFireTo(t *testing.T) {
+	inStock := func(ctx context.Context, e *Event) (bool, error) {
+		return e.Reason != "out of stock", nil
+	}
+
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "pay",
+		From: []State{"created"},
+		To:   State("paid"),
+	}, {
+		Name:   "ship",
+		From:   []State{"paid"},
+		To:     State("shipped"),
+		Guards: []Guard{inStock},
+	}, {
+		Name: "deliver",
+		From: []State{"shipped"},
+		To:   State("delivered"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("created")}
+	if err := fsm.FireTo(ctx, testStruct, "delivered"); err != nil || testStruct.State != "delivered" {
+		t.Errorf("FireTo() = %v, %v, want delivered", testStruct.State, err)
+	}
+
+	testStruct = &TestStruct{State: State("created")}
+	var ferr FireToError
+	err := fsm.FireTo(ctx, testStruct, "delivered", WithReason("out of stock"))
+	if !errors.As(err, &ferr) || ferr.State != "paid" || !reflect.DeepEqual(ferr.Fired, []string{"pay"}) ||
+		ferr.Event != "ship" || ferr.Guard != GuardName(inStock) {
+		t.Errorf("FireTo() error = %v, want blocked at ship", err)
+	}
+}
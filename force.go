@@ -0,0 +1,132 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// ForceEvent is the event name ForceState records transitions under.
+const ForceEvent = "$force"
+
+// ForceState func to move s to state regardless of the declared transitions
+// and guards, e.g. to release an entity stuck after an outage. The OnEnter
+// and OnAnyTransition callbacks of state run, and the transition is recorded
+// in the history and audit sink and published as ForceEvent. Unique states
+// and invariants are enforced as by Fire. A reason must be given with
+// WithReason, ForceState fails with ReasonRequiredError otherwise.
+func (f *FSM) ForceState(ctx context.Context, s interface{}, state State, options ...Option) error {
+	args := &Options{}
+	for _, option := range options {
+		option(args)
+	}
+	if args.Reason == "" {
+		return ReasonRequiredError{Event: ForceEvent}
+	}
+
+	machine, ok := f.machine(s, options...)
+	if !ok {
+		return InternalError{}
+	}
+	if machine.validStates != nil && !machine.validStates[state] {
+		return UnknownStateError{Event: ForceEvent, State: string(state)}
+	}
+
+	return machine.forceState(ctx, s, state, args)
+}
+
+func (f *fsm) forceState(ctx context.Context, s interface{}, state State, args *Options) error {
+	started := time.Now()
+	a := &attempt{
+		labels: MetricLabels{Type: f.name, Event: ForceEvent, To: string(state), Tenant: f.parent.tenant(ctx, s)},
+		reason: args.Reason,
+		meta:   args.Meta,
+		args:   args.Args,
+	}
+
+	err := f.exec(ctx, s, func() error {
+		unlock, err := f.lockInstance(ctx, s)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		return f.forceExclusive(ctx, s, state, a)
+	})
+	if err != nil {
+		f.parent.metrics.IncFailure(a.labels, errorKind(err))
+	} else {
+		f.parent.metrics.IncTransition(a.labels)
+	}
+	f.parent.metrics.ObserveFire(a.labels, time.Since(started))
+
+	if herr := f.record(ctx, s, a, err); herr != nil && err == nil {
+		return herr
+	}
+	if err != nil {
+		return err
+	}
+	return f.publish(ctx, s, a)
+}
+
+// forceExclusive writes state, the caller must hold the instance.
+func (f *fsm) forceExclusive(ctx context.Context, s interface{}, state State, a *attempt) error {
+	if f.removed.Load() {
+		return UnregisteredError{Type: f.tag}
+	}
+
+	field, from, err := f.getSourceState(s)
+	if err != nil {
+		return err
+	}
+	a.labels.From = string(from)
+
+	vars, err := f.loadVars(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	stamps, err := f.stampsFor(reflect.TypeOf(s))
+	if err != nil {
+		return err
+	}
+	if err := stamps.check(reflect.ValueOf(s).Elem()); err != nil {
+		return err
+	}
+
+	claim, err := f.claimUnique(ctx, s, from, state)
+	if err != nil {
+		return err
+	}
+	defer claim.release()
+
+	rollback := f.rollback(s, vars)
+	if err := f.setState(field, f.accessFor(reflect.TypeOf(s)), state); err != nil {
+		return err
+	}
+	stamps.set(reflect.ValueOf(s).Elem(), f.parent.now(), state != from)
+
+	// As in Fire, unique groups are recorded once the state is kept.
+	e := &Event{Event: ForceEvent, Source: s, From: from, Destination: state, Vars: vars, Reason: a.reason, Meta: a.meta, Args: a.args}
+	err = f.bounded(ctx, nil, ForceEvent, "enter", func(ctx context.Context) error { return f.entered(ctx, e) })
+	if err != nil {
+		f.logCallbackError(ctx, e, a.labels.From, "enter", err)
+	} else if err = f.bounded(ctx, nil, ForceEvent, "any", func(ctx context.Context) error { return f.transitioned(ctx, e) }); err != nil {
+		f.logCallbackError(ctx, e, a.labels.From, "any", err)
+	} else if ierr := f.checkInvariants(ctx, e, from); ierr != nil {
+		return errors.Join(ierr, rollback(ctx))
+	}
+
+	if cerr := claim.commit(ctx); cerr != nil {
+		return errors.Join(err, cerr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := f.saveVars(ctx, s, vars); err != nil {
+		return err
+	}
+	return f.completed(ctx, e)
+}
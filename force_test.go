@@ -0,0 +1,95 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestForceState(t *testing.T) {
+	fsm := NewFSM(WithHistory(10), WithIDFunc(byAddress))
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "finish",
+		From: []State{"started"},
+		To:   State("finished"),
+		Guards: []Guard{func(ctx context.Context, e *Event) (bool, error) {
+			return false, nil
+		}},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	var entered *Event
+	if err := fsm.OnEnter(tag, "canceled", func(ctx context.Context, e *Event) error {
+		entered = e
+		return nil
+	}); err != nil {
+		t.Errorf("OnEnter() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("started")}
+	if err := fsm.ForceState(ctx, testStruct, "canceled"); !errors.As(err, new(ReasonRequiredError)) {
+		t.Errorf("ForceState() without reason error = %v, want ReasonRequiredError", err)
+	}
+
+	if err := fsm.ForceState(ctx, testStruct, "canceled", WithReason("stuck since outage")); err != nil {
+		t.Errorf("ForceState() error = %v", err)
+	}
+	if testStruct.State != "canceled" {
+		t.Errorf("State = %v, want canceled", testStruct.State)
+	}
+	if entered == nil || entered.Event != ForceEvent {
+		t.Errorf("OnEnter got %+v, want a %s event", entered, ForceEvent)
+	}
+
+	history, err := fsm.History(ctx, testStruct)
+	if err != nil || len(history) != 1 || history[0].Event != ForceEvent || history[0].From != "started" || history[0].Reason != "stuck since outage" {
+		t.Errorf("History() = %+v, %v", history, err)
+	}
+}
+
+func TestForceStateUnique(t *testing.T) {
+	fsm := NewFSM(WithIDFunc(byAddress))
+	tag := reflect.TypeOf((*Membership)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "activate",
+		From: []State{"pending"},
+		To:   State("active"),
+	}}, Unique("active", func(s interface{}) string {
+		return s.(*Membership).Customer
+	})); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := fsm.Invariant(tag, func(ctx context.Context, s interface{}) error {
+		if s.(*Membership).State == "banned" {
+			return errors.New("memberships can't be banned")
+		}
+		return nil
+	}); err != nil {
+		t.Errorf("Invariant() error = %v", err)
+	}
+
+	ctx := context.Background()
+	first := &Membership{Customer: "c1", State: "pending"}
+	second := &Membership{Customer: "c1", State: "pending"}
+	if err := fsm.ForceState(ctx, first, "active", WithReason("support")); err != nil {
+		t.Errorf("ForceState() error = %v", err)
+	}
+	if err := fsm.ForceState(ctx, second, "active", WithReason("support")); !errors.As(err, new(StateConflictError)) {
+		t.Errorf("ForceState() error = %v, want StateConflictError", err)
+	}
+
+	if err := fsm.ForceState(ctx, first, "expired", WithReason("support")); err != nil {
+		t.Errorf("ForceState() error = %v", err)
+	}
+	if err := fsm.Fire(ctx, second, "activate"); err != nil {
+		t.Errorf("Fire() error = %v after the holder was forced out", err)
+	}
+
+	if err := fsm.ForceState(ctx, first, "banned", WithReason("support")); !errors.As(err, new(InvariantViolationError)) || first.State != "expired" {
+		t.Errorf("ForceState() = %v, state %q, want InvariantViolationError", err, first.State)
+	}
+}
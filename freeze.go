@@ -0,0 +1,49 @@
+package fsm
+
+import (
+	"reflect"
+	"slices"
+)
+
+// Freeze func to make Fire fail fast with MachineFrozenError for every
+// machine of tag, e.g. during a migration. Events in allowed can still be
+// fired. Freezing a frozen type replaces its allowed events.
+func (f *FSM) Freeze(tag reflect.Type, allowed ...string) error {
+	machines := f.machinesOf(tag)
+	if len(machines) == 0 {
+		return InternalError{}
+	}
+	machines = append(slices.Clip(machines), f.olderVersions(tag, machines[0])...)
+
+	set := make(map[string]bool, len(allowed))
+	for _, event := range allowed {
+		set[event] = true
+	}
+	for _, m := range machines {
+		m.frozen.Store(&set)
+	}
+	return nil
+}
+
+// Unfreeze func to let the machines of tag fire events again
+func (f *FSM) Unfreeze(tag reflect.Type) error {
+	machines := f.machinesOf(tag)
+	if len(machines) == 0 {
+		return InternalError{}
+	}
+	machines = append(slices.Clip(machines), f.olderVersions(tag, machines[0])...)
+
+	for _, m := range machines {
+		m.frozen.Store(nil)
+	}
+	return nil
+}
+
+// checkFrozen returns MachineFrozenError if f is frozen for event.
+func (f *fsm) checkFrozen(event string) error {
+	allowed := f.frozen.Load()
+	if allowed == nil || (*allowed)[event] {
+		return nil
+	}
+	return MachineFrozenError{Type: f.name, Event: event}
+}
@@ -0,0 +1,47 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+	fsm := NewFSM()
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+	}, {
+		Name: "cancel",
+		From: []State{"started"},
+		To:   State("canceled"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	if err := fsm.Freeze(tag, "cancel"); err != nil {
+		t.Errorf("Freeze() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("started")}
+	if err := fsm.Fire(ctx, testStruct, "make"); !errors.As(err, new(MachineFrozenError)) {
+		t.Errorf("Fire() while frozen error = %v, want MachineFrozenError", err)
+	}
+	if testStruct.State != State("started") {
+		t.Errorf("state = %s, want started", testStruct.State)
+	}
+	if err := fsm.Fire(ctx, &TestStruct{State: State("started")}, "cancel"); err != nil {
+		t.Errorf("Fire() of allowed event error = %v", err)
+	}
+
+	if err := fsm.Unfreeze(tag); err != nil {
+		t.Errorf("Unfreeze() error = %v", err)
+	}
+	if err := fsm.Fire(ctx, testStruct, "make"); err != nil {
+		t.Errorf("Fire() after Unfreeze error = %v", err)
+	}
+}
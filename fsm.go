@@ -2,36 +2,152 @@ package fsm
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Guard func(context.Context, *Event) (bool, error)
 
 type Event struct {
-	Event       string
-	Source      interface{}
+	Event  string
+	Source interface{}
+	// From is the state Source is leaving, Destination the one it enters.
+	From        State
 	Destination State
+	// Vars holds the extended-state variables of Source, nil if the machine
+	// declares none.
+	Vars *Vars
+	// Reason, Meta and Args are set by the caller with WithReason, WithMeta
+	// and WithArgs.
+	Reason string
+	Meta   map[string]interface{}
+	Args   []interface{}
+	// Attempt numbers the calls of a guard or callback retried under
+	// EventTransition.Retry, starting at 1. It is zero in other hooks.
+	Attempt int
+	// DryRun is set while guards are evaluated without firing, e.g. by
+	// MayFire, GetPermittedTransitions or Replay.
+	DryRun bool
+}
+
+// SourceOf returns the source of e as a T, e.g. SourceOf[*Order](e), and
+// whether it is one.
+func SourceOf[T any](e *Event) (T, bool) {
+	s, ok := e.Source.(T)
+	return s, ok
 }
 
 type EventTransition struct {
-	Name   string
-	From   []State
-	To     State
-	Guards []Guard
-	After  func(context.Context, *Event) error
-	Before func(context.Context, *Event) error
+	Name string
+	From []State
+	To   State
+	// Priority orders transitions sharing Name and a From state: Fire takes
+	// the first one, by descending Priority and then declaration order,
+	// whose guards pass. See RejectAmbiguous.
+	Priority int
+	// Default makes the transition the branch Fire takes when no other
+	// transition sharing Name and a From state passes its guards, whatever
+	// its Priority. At most one such transition may be the default.
+	Default bool
+	Guards  []Guard
+	// GuardNames references guards registered in the Registry of the FSM,
+	// see WithRegistry. They run after Guards.
+	GuardNames []string
+	// GuardCosts assigns costs to guards by the name they are reported
+	// under, see GuardCost. Unlisted guards have CostDefault.
+	GuardCosts map[string]GuardCost
+	After      func(context.Context, *Event) error
+	Before     func(context.Context, *Event) error
+	// SkipDefaults opts the transition out of the guards and callbacks added
+	// by WithDefaultGuards, WithDefaultBefore and WithDefaultAfter.
+	SkipDefaults bool
+	// OnError names the event fired when a Before or After callback of this
+	// transition fails, e.g. to move the instance into a failure state. It is
+	// fired from the state the instance is in after the failure.
+	OnError string
+	// Fallback decides the outcome of guards failing with an error the FSM
+	// classifies as unavailable, see Unavailable.
+	Fallback GuardFallback
+	// Retry retries guards and callbacks failing with transient errors.
+	Retry RetryPolicy
+	// CallbackTimeout bounds every guard and callback of the transition,
+	// overriding WithCallbackTimeout, see CallbackTimeoutError.
+	CallbackTimeout time.Duration
+	// Labels describe the transition for user interfaces, e.g. a button
+	// title or a confirmation prompt. They are returned by
+	// GetPermittedTransitions and exported with the schema.
+	Labels map[string]string
+	// ToFunc chooses the destination when the transition is fired, once its
+	// guards passed, e.g. "auto_approved" or "manual_review" by risk score.
+	// It must return one of Targets, which lists the possible destinations
+	// for introspection and exports. To, or else the first of Targets, is
+	// reported as the destination wherever the choice is not made.
+	ToFunc  func(context.Context, *Event) (State, error)
+	Targets []State
+	// Weight is the relative probability of this transition among those
+	// leaving the same state in a Simulator. It is ignored by Fire; unset
+	// weights count as 1.
+	Weight float64
+	// Quota limits how many times a single actor (see WithActor) may take
+	// this transition on one instance. Zero means unlimited. Quotas and
+	// throttles need WithIdentity or WithIDFunc.
+	Quota int
+	// Throttle limits how often the transition may be taken on one
+	// instance, see ThrottledError.
+	Throttle Throttle
+	// AllowedRoles restricts the transition to callers with one of these
+	// roles, see WithRoles and WithAuthorizer. Empty means everyone may take
+	// it. Transitions sharing a name are authorized independently.
+	AllowedRoles []string
+	// Locks returns named resources locked through the LockProvider of the
+	// FSM for the duration of the transition, e.g. the SKU being reserved.
+	Locks func(ctx context.Context, s interface{}) []string
+	// Draft declares the transition without enabling it: Fire rejects it
+	// with DraftTransitionError, while introspection shows it when called
+	// with IncludeDraft.
+	Draft bool
 }
 
 type Events []EventTransition
 
 type fsm struct {
+	parent        *FSM
+	tag           reflect.Type
+	name          string
 	column        string
-	transitions   map[eventKey]State
-	initialStates map[State][]string
-	guards        map[string][]Guard
-	callbacks     map[cKey]func(context.Context, *Event) error
-	instanceLocks sync.Map // map[interface{}]*sync.Mutex for per-instance locking
+	provider      string // the MachineProvider that installed it, if any
+	access        fieldAccess
+	accesses      sync.Map // map[reflect.Type]fieldAccess for interface machines
+	tables        atomic.Pointer[table]
+	vars          map[string]varDecl
+	converter     *StateConverter
+	instanceLocks *keyedMutex // shared by the versions of a machine
+	mode          ExecutionMode
+	actors        *actorSystem // shared by the versions of a machine
+	historyMu     sync.Mutex
+	dedupLocks    keyedMutex
+	guardCache    guardCache
+	requireInit   bool
+	validStates   map[State]bool
+	strictStates  bool
+	unambiguous   bool
+	removed       atomic.Bool
+	states        atomic.Pointer[stateConfig]
+	frozen        atomic.Pointer[map[string]bool]
+
+	stateChangedAt string
+	updatedAt      string
+	stamps         sync.Map // map[reflect.Type]stampFields
+
+	uniques []uniqueConstraint
+
+	identity func(interface{}) string
+
+	callbackTimeout time.Duration
+	defaults        defaults
 }
 
 type eventKey struct {
@@ -39,97 +155,387 @@ type eventKey struct {
 	src   State
 }
 
-type cKey struct {
-	event string
-	cType string
-}
-
-func newFSM(column string, events []EventTransition) *fsm {
+func newFSM(parent *FSM, tag reflect.Type, column string, events []EventTransition, options ...RegisterOption) (*fsm, error) {
 	f := &fsm{
+		parent: parent,
+		tag:    tag,
+		name:   tag.String(),
 		column: column,
 	}
-	f.transitions = make(map[eventKey]State)
-	f.guards = make(map[string][]Guard)
-	f.callbacks = make(map[cKey]func(context.Context, *Event) error)
-	f.initialStates = make(map[State][]string)
+	f.vars = make(map[string]varDecl)
+	f.instanceLocks = &keyedMutex{}
+	f.actors = &actorSystem{}
 
-	for _, e := range events {
-		if e.Guards != nil {
-			f.guards[e.Name] = e.Guards
+	for _, option := range options {
+		option(f)
+	}
+	if tag.Kind() != reflect.Interface {
+		f.access = f.resolveField(tag)
+		if _, err := f.stampsFor(tag); err != nil {
+			return nil, err
 		}
+	}
 
-		if e.After != nil {
-			f.callbacks[cKey{event: e.Name, cType: "after"}] = e.After
+	if f.strictStates && len(f.validStates) == 0 {
+		return nil, SchemaError{Reason: "StrictStates without WithStates"}
+	}
+
+	if parent.dedupWindow > 0 {
+		if err := f.requireIdentity("WithDedupWindow"); err != nil {
+			return nil, err
+		}
+	}
+	if parent.dedupStore != nil {
+		if err := f.requireIdentity("WithDedupStore"); err != nil {
+			return nil, err
+		}
+	}
+	if parent.historyLimit > 0 {
+		if err := f.requireIdentity("WithHistory"); err != nil {
+			return nil, err
+		}
+	}
+	if len(f.vars) > 0 {
+		if err := f.requireIdentity("DeclareVar"); err != nil {
+			return nil, err
+		}
+	}
+	if len(f.uniques) > 0 {
+		if err := f.requireIdentity("Unique"); err != nil {
+			return nil, err
 		}
+	}
+
+	t, err := f.compile(events)
+	if err != nil {
+		return nil, err
+	}
+	f.tables.Store(t)
 
-		if e.Before != nil {
-			f.callbacks[cKey{event: e.Name, cType: "before"}] = e.Before
+	return f, nil
+}
+
+func (f *fsm) Fire(ctx context.Context, s interface{}, event string, options ...Option) error {
+	_, err := f.FireE(ctx, s, event, options...)
+	return err
+}
+
+func (f *fsm) FireE(ctx context.Context, s interface{}, event string, options ...Option) (FireResult, error) {
+	args := &Options{}
+	for _, option := range options {
+		option(args)
+	}
+
+	if args.DedupKey != "" {
+		return f.fireOnce(ctx, s, event, args)
+	}
+
+	return f.fireE(ctx, s, event, args)
+}
+
+func (f *fsm) fireE(ctx context.Context, s interface{}, event string, args *Options) (FireResult, error) {
+	started := time.Now()
+	a := &attempt{
+		labels:  MetricLabels{Type: f.name, Event: event, Tenant: f.parent.tenant(ctx, s)},
+		reason:  args.Reason,
+		meta:    args.Meta,
+		args:    args.Args,
+		ifState: args.IfState,
+	}
+
+	err := f.fire(ctx, s, event, a)
+	if err != nil {
+		f.parent.metrics.IncFailure(a.labels, errorKind(err))
+	} else {
+		f.parent.metrics.IncTransition(a.labels)
+	}
+	f.parent.metrics.ObserveFire(a.labels, time.Since(started))
+
+	if herr := f.record(ctx, s, a, err); herr != nil && err == nil {
+		return CallbackFailed, herr
+	}
+
+	// Error edges are not followed recursively so two failing error events
+	// can't trigger each other forever.
+	if a.onError != "" && a.callbackFailed && ctx.Value(onErrorKey{}) == nil {
+		if ferr := f.Fire(context.WithValue(ctx, onErrorKey{}, event), s, a.onError); ferr != nil {
+			return Failed, errors.Join(err, ferr)
 		}
+		return Compensated, err
+	}
 
-		for _, src := range e.From {
-			f.transitions[eventKey{event: e.Name, src: src}] = e.To
+	if err != nil {
+		if a.pendingApproval {
+			return PendingApproval, err
+		}
+		if a.moved {
+			return CallbackFailed, err
 		}
+		return Failed, err
 	}
 
-	for eventKey := range f.transitions {
-		f.initialStates[eventKey.src] = append(f.initialStates[eventKey.src], eventKey.event)
+	if perr := f.publish(ctx, s, a); perr != nil {
+		return Completed, perr
 	}
 
-	return f
+	// Propagated events are scheduled, not fired, so a failing related
+	// instance doesn't fail this transition.
+	if perr := f.propagate(ctx, s, State(a.labels.To)); perr != nil {
+		return Completed, perr
+	}
+	return Completed, nil
 }
 
-// getOrCreateInstanceLock returns or creates a mutex for the given instance
-func (f *fsm) getOrCreateInstanceLock(s interface{}) *sync.Mutex {
-	mu, _ := f.instanceLocks.LoadOrStore(s, &sync.Mutex{})
-	return mu.(*sync.Mutex)
+type onErrorKey struct{}
+
+// attempt collects what a single fire call did.
+type attempt struct {
+	labels         MetricLabels
+	reason         string
+	meta           map[string]interface{}
+	args           []interface{}
+	ifState        State
+	callbackFailed bool
+	// onError is the error edge of the transition taken, see
+	// EventTransition.OnError.
+	onError string
+	// pendingApproval is set if a RequireApprovals guard rejected the
+	// transition.
+	pendingApproval bool
+	// moved is set while the destination state written to the instance is
+	// kept, see CallbackFailed.
+	moved bool
 }
 
-func (f *fsm) Fire(ctx context.Context, s interface{}, event string) error {
+func (f *fsm) fire(ctx context.Context, s interface{}, event string, a *attempt) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	state, err := f.getSourceState(s)
+	if err := f.checkFrozen(event); err != nil {
+		return err
+	}
+
+	// Serialize transitions of this specific instance while allowing
+	// concurrent transitions on different instances. The state is read inside
+	// so guards and callbacks see a consistent instance.
+	if f.mode == ExecActor {
+		// The actor gets its own copy of a, so a doesn't escape to the heap
+		// on the common path below.
+		shared := *a
+		err := f.exec(ctx, s, func() error { return f.fireLocked(ctx, s, event, &shared) })
+		*a = shared
+		return err
+	}
+
+	key := f.lockKey(s)
+	m := f.instanceLocks.acquire(key)
+	defer f.instanceLocks.release(key, m)
+
+	return f.fireLocked(ctx, s, event, a)
+}
+
+// fireLocked takes the locks of the instance and its resources for
+// fireExclusive, the caller must serialize the instance.
+func (f *fsm) fireLocked(ctx context.Context, s interface{}, event string, a *attempt) error {
+	unlockInstance, err := f.lockInstance(ctx, s)
 	if err != nil {
 		return err
 	}
+	defer unlockInstance()
 
-	destination, ok := f.transitions[eventKey{event, State(state.String())}]
+	unlock, err := f.lockResources(ctx, s, event)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return f.fireExclusive(ctx, s, event, a)
+}
+
+// fireExclusive performs the transition, the caller must hold the instance.
+func (f *fsm) fireExclusive(ctx context.Context, s interface{}, event string, a *attempt) error {
+	t := f.table()
+	if f.removed.Load() {
+		return UnregisteredError{Type: f.tag}
+	}
+
+	field, state, err := f.getSourceState(s)
+	if err != nil {
+		return err
+	}
+	a.labels.From = string(state)
+
+	if a.ifState != "" && state != a.ifState {
+		return StaleStateError{Event: event, Expected: string(a.ifState), State: string(state)}
+	}
+
+	if state == "" && f.requireInit {
+		return UninitializedStateError{Event: event}
+	}
+
+	if err := f.checkState(event, state); err != nil {
+		return err
+	}
+
+	if f.isFinal(state) {
+		return MachineCompletedError{Event: event, State: string(state)}
+	}
+
+	destination, ok := t.transitions[eventKey{event, state}]
 	if !ok {
+		if _, draft := t.drafts[eventKey{event, state}]; draft {
+			return DraftTransitionError{Event: event, State: string(state)}
+		}
 		return UnknownEventError{event}
 	}
+	a.labels.To = string(destination)
+
+	vars, err := f.loadVars(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	e := &Event{Event: event, Source: s, From: state, Destination: destination, Vars: vars, Reason: a.reason, Meta: a.meta, Args: a.args}
+	f.logAttempt(ctx, e, a.labels.From)
+
+	br, guard, err := f.selectBranch(ctx, e, state, nil, true)
+	if cerr := ctx.Err(); cerr != nil {
+		return cerr
+	}
+	if err != nil && errors.As(err, new(PermissionDeniedError)) {
+		return err
+	}
+	if err != nil || br == nil {
+		f.logRejection(ctx, e, a.labels.From, guard, err)
+		a.pendingApproval = err == nil && f.awaitsApproval(event, guard)
+		return InvalidTransitionError{Event: event, State: string(state), Guard: guard, Err: err}
+	}
+	if err := f.checkQuota(ctx, s, event, br); err != nil {
+		return err
+	}
+	if err := f.checkThrottle(ctx, s, event, br); err != nil {
+		return err
+	}
+	destination = br.to
+	a.onError = br.onError
+	if br.toFunc != nil {
+		if destination, err = f.choose(ctx, e, br); err != nil {
+			return err
+		}
+	}
+	a.labels.To = string(destination)
 
-	e := &Event{Event: event, Source: s, Destination: destination}
+	stamps, err := f.stampsFor(reflect.TypeOf(s))
+	if err != nil {
+		return err
+	}
+	if err := stamps.check(reflect.ValueOf(s).Elem()); err != nil {
+		return err
+	}
 
-	ok, err = f.guardEvent(ctx, e)
+	claim, err := f.claimUnique(ctx, s, state, destination)
 	if err != nil {
 		return err
 	}
+	defer claim.release()
 
-	if !ok {
-		return InvalidTransitionError{event, state.String()}
+	rollback := f.rollback(s, vars)
+	err = f.retry(ctx, br, e, func() error {
+		return f.bounded(ctx, br, event, "before", func(ctx context.Context) error { return br.beforeCallback(ctx, e) })
+	})
+	if err != nil {
+		f.logCallbackError(ctx, e, a.labels.From, "before", err)
+		a.callbackFailed = true
+		return err
+	}
+
+	// Nothing has been written yet, so a caller giving up still leaves the
+	// instance untouched. Once the state is written the transition runs to
+	// completion.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := f.setState(field, f.accessFor(reflect.TypeOf(s)), destination); err != nil {
+		return err
+	}
+	stamps.set(reflect.ValueOf(s).Elem(), f.parent.now(), destination != state)
+	a.moved = true
+
+	if err := clearApprovals(br, vars); err != nil {
+		return err
+	}
+	if err := f.saveVars(ctx, s, vars); err != nil {
+		return err
 	}
 
-	// Lock this specific instance to allow concurrent transitions on different instances
-	mu := f.getOrCreateInstanceLock(s)
-	mu.Lock()
-	defer mu.Unlock()
+	// Unique groups, quotas and throttles are only recorded once the
+	// transition is kept: a failing callback keeps it, a failing invariant
+	// resets it.
+	err = f.callbacks(ctx, br, e, a)
+	if err == nil {
+		if ierr := f.checkInvariants(ctx, e, state); ierr != nil {
+			a.moved = false
+			return errors.Join(ierr, rollback(ctx))
+		}
+	}
 
-	err = f.beforeEventCallbacks(ctx, e)
+	if kerr := f.keep(ctx, s, claim, br); kerr != nil {
+		return errors.Join(err, kerr)
+	}
 	if err != nil {
 		return err
 	}
 
-	state.SetString(string(destination))
+	if err := f.saveVars(ctx, s, vars); err != nil {
+		return err
+	}
 
-	err = f.afterEventCallbacks(ctx, e)
+	return f.completed(ctx, e)
+}
+
+// callbacks runs the callbacks of br once the state of e.Source is written.
+func (f *fsm) callbacks(ctx context.Context, br *branch, e *Event, a *attempt) error {
+	err := f.bounded(ctx, br, e.Event, "enter", func(ctx context.Context) error { return f.entered(ctx, e) })
+	if err != nil {
+		f.logCallbackError(ctx, e, a.labels.From, "enter", err)
+		a.callbackFailed = true
+		return err
+	}
+
+	err = f.retry(ctx, br, e, func() error {
+		return f.bounded(ctx, br, e.Event, "after", func(ctx context.Context) error { return br.afterCallback(ctx, e) })
+	})
 	if err != nil {
+		f.logCallbackError(ctx, e, a.labels.From, "after", err)
+		a.callbackFailed = true
 		return err
 	}
 
+	err = f.bounded(ctx, br, e.Event, "any", func(ctx context.Context) error { return f.transitioned(ctx, e) })
+	if err != nil {
+		f.logCallbackError(ctx, e, a.labels.From, "any", err)
+		a.callbackFailed = true
+		return err
+	}
 	return nil
 }
 
+// keep records the unique groups, quota and throttle of the transition br
+// taken by s.
+func (f *fsm) keep(ctx context.Context, s interface{}, claim *uniqueClaim, br *branch) error {
+	if err := claim.commit(ctx); err != nil {
+		return err
+	}
+
+	if err := f.consumeQuota(ctx, s, br); err != nil {
+		return err
+	}
+
+	return f.recordThrottle(ctx, s, br)
+}
+
 func (f *fsm) MayFire(ctx context.Context, s interface{}, event string, options ...Option) (bool, error) {
 	// Setup options.
 	args := &Options{}
@@ -137,36 +543,47 @@ func (f *fsm) MayFire(ctx context.Context, s interface{}, event string, options
 		option(args)
 	}
 
-	state, err := f.getSourceState(s)
+	_, state, err := f.getSourceState(s)
 	if err != nil {
 		return false, err
 	}
 
-	destination, ok := f.transitions[eventKey{event, State(state.String())}]
-	if !ok {
+	destination, ok := f.destination(eventKey{event, state}, args)
+	if !ok || f.isFinal(state) {
 		return false, nil
 	}
 
-	e := &Event{Event: event, Source: s, Destination: destination}
-
+	e := &Event{Event: event, Source: s, From: state, Destination: destination, Args: args.Args, DryRun: true}
 	if !args.SkipGuards {
-		ok, err = f.guardEvent(ctx, e)
-		if err != nil {
+		if e.Vars, err = f.loadVars(ctx, s); err != nil {
 			return false, err
 		}
 	}
 
-	return ok, nil
+	br, _, err := f.selectBranch(ctx, e, state, args, true)
+	if err != nil && errors.As(err, new(PermissionDeniedError)) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return br != nil, nil
 }
 
 func (f *fsm) GetPermittedEvents(ctx context.Context, s interface{}, options ...Option) ([]string, error) {
-	state, err := f.getSourceState(s)
+	args := &Options{}
+	for _, option := range options {
+		option(args)
+	}
+
+	_, state, err := f.getSourceState(s)
 	if err != nil {
 		return nil, err
 	}
 
-	events, ok := f.initialStates[State(state.String())]
-	if !ok {
+	events := f.eventsFrom(state, args)
+	if len(events) == 0 || f.isFinal(state) {
 		return []string{}, nil
 	}
 
@@ -186,19 +603,24 @@ func (f *fsm) GetPermittedEvents(ctx context.Context, s interface{}, options ...
 }
 
 func (f *fsm) GetPermittedStates(ctx context.Context, s interface{}, options ...Option) ([]State, error) {
-	state, err := f.getSourceState(s)
+	args := &Options{}
+	for _, option := range options {
+		option(args)
+	}
+
+	_, state, err := f.getSourceState(s)
 	if err != nil {
 		return nil, err
 	}
 
-	events, ok := f.initialStates[State(state.String())]
-	if !ok {
+	events := f.eventsFrom(state, args)
+	if len(events) == 0 || f.isFinal(state) {
 		return []State{}, nil
 	}
 
 	permittedStates := []State{}
 	for _, event := range events {
-		destination, ok := f.transitions[eventKey{event, State(state.String())}]
+		destination, ok := f.destination(eventKey{event, state}, args)
 		if !ok {
 			return nil, UnknownEventError{event}
 		}
@@ -208,46 +630,3 @@ func (f *fsm) GetPermittedStates(ctx context.Context, s interface{}, options ...
 
 	return permittedStates, nil
 }
-
-func (f *fsm) getSourceState(s interface{}) (state reflect.Value, err error) {
-	val := reflect.ValueOf(s).Elem()
-
-	if val.Kind() != reflect.Struct {
-		return state, InternalError{}
-	}
-
-	state = val.FieldByName(f.column)
-	if !state.IsValid() && !state.CanSet() && state.Kind() != reflect.String {
-		return state, InternalError{}
-	}
-
-	return
-}
-
-func (f *fsm) guardEvent(ctx context.Context, e *Event) (bool, error) {
-	fns, ok := f.guards[e.Event]
-	if ok {
-		for _, fn := range fns {
-			if ok, err := fn(ctx, e); err != nil || !ok {
-				return false, err
-			}
-		}
-	}
-	return true, nil
-}
-
-func (f *fsm) afterEventCallbacks(ctx context.Context, e *Event) error {
-	fn, ok := f.callbacks[cKey{event: e.Event, cType: "after"}]
-	if ok {
-		return fn(ctx, e)
-	}
-	return nil
-}
-
-func (f *fsm) beforeEventCallbacks(ctx context.Context, e *Event) error {
-	fn, ok := f.callbacks[cKey{event: e.Event, cType: "before"}]
-	if ok {
-		return fn(ctx, e)
-	}
-	return nil
-}
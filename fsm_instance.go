@@ -2,39 +2,244 @@ package fsm
 
 import (
 	"context"
+	"log/slog"
 	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type FSM struct {
-	machines map[reflect.Type]*fsm
+	mu       sync.RWMutex
+	machines map[reflect.Type][]*fsm // ordered by registration, first is the default
+	metrics  Metrics
+	store    StateStore
+	locks    LockProvider
+	// instanceLocks is set by WithInstanceLocks.
+	instanceLocks bool
+
+	// interfaces lists the interface types machines are registered for, in
+	// registration order.
+	interfaces []reflect.Type
+	versions   map[reflect.Type]*versionSet
+	// generation is bumped whenever machines or versions change, so that
+	// handles know to resolve their machine again.
+	generation atomic.Uint64
+
+	logger    *slog.Logger
+	logLevels LogLevels
+
+	historyLimit int
+	auditSink    AuditSink
+
+	registry *Registry
+
+	dedupWindow time.Duration
+	dedupStore  StateStore
+
+	batchConcurrency int
+
+	isUnavailable  func(error) bool
+	guardCacheSize int
+
+	tenantFunc func(context.Context, interface{}) string
+
+	authorizer Authorizer
+
+	middleware []Middleware
+	fire       FireFunc
+
+	clock Clock
+
+	publisher Publisher
+	idFunc    func(interface{}) string
+
+	schedules      ScheduleStore
+	scheduleLoader func(context.Context, ScheduledFire) (interface{}, error)
+	scheduleLease  time.Duration
+	scheduled      sync.Map // map[string]interface{} of instances by scheduled fire ID
 }
 
 // NewFSM func to create FSM
-func NewFSM() *FSM {
-	f := &FSM{}
-	f.machines = make(map[reflect.Type]*fsm)
+func NewFSM(options ...FSMOption) *FSM {
+	store := NewMemoryStore()
+	f := &FSM{metrics: nopMetrics{}, store: store, locks: NewMemoryLockProvider(), clock: systemClock{}, logLevels: DefaultLogLevels, guardCacheSize: DefaultGuardCacheSize, scheduleLease: DefaultScheduleLease}
+	f.schedules = NewMemoryScheduleStore()
+	f.machines = make(map[reflect.Type][]*fsm)
+	f.versions = make(map[reflect.Type]*versionSet)
+	for _, option := range options {
+		option(f)
+	}
+	store.clock = f.clock
+	f.fire = f.chain(f.fireDefault)
 	return f
 }
 
-// Register func to register all event by model reflect type
-func (f *FSM) Register(tag reflect.Type, column string, events []EventTransition) error {
-	f.machines[tag] = newFSM(column, events)
+// Register func to register all event by model reflect type. A type may
+// have one machine per state column; registering a column again replaces its
+// machine. The first registered column is used by Fire, FireOn selects others.
+// Register is safe to call while other goroutines fire events.
+//
+// column names the state field, which may be promoted from an embedded
+// struct, or is a dotted path into nested structs such as "Meta.Status".
+// Nested structs reached through nil pointers make Fire fail.
+//
+// tag may be an interface type, e.g. reflect.TypeOf((*Payable)(nil)).Elem(),
+// to share the machine between all pointer-to-struct types implementing it
+// that have no machine of their own. The column is resolved per concrete
+// type; the first matching interface registered wins.
+func (f *FSM) Register(tag reflect.Type, column string, events []EventTransition, options ...RegisterOption) error {
+	machine, err := newFSM(f, tag, column, events, options...)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.install(tag, machine)
+	return nil
+}
+
+// install adds machine to those of tag, replacing the one of its column.
+// f.mu must be held.
+func (f *FSM) install(tag reflect.Type, machine *fsm) {
+	f.generation.Add(1)
+	previous := f.machines[tag]
+	machines := make([]*fsm, len(previous), len(previous)+1)
+	copy(machines, previous)
+
+	for i, m := range machines {
+		if m.column == machine.column {
+			machines[i] = machine
+			f.machines[tag] = machines
+			return
+		}
+	}
+
+	if len(previous) == 0 && tag.Kind() == reflect.Interface {
+		f.interfaces = append(f.interfaces, tag)
+	}
+	f.machines[tag] = append(machines, machine)
+}
+
+// Unregister func to remove all machines registered for tag. Transitions
+// that already resolved one of them fail with UnregisteredError, later calls
+// behave as if tag was never registered.
+func (f *FSM) Unregister(tag reflect.Type) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	machines, ok := f.machines[tag]
+	if !ok {
+		return UnregisteredError{Type: tag}
+	}
+
+	f.generation.Add(1)
+	for _, m := range machines {
+		m.removed.Store(true)
+	}
+	delete(f.machines, tag)
+	if set, ok := f.versions[tag]; ok {
+		for _, m := range set.machines {
+			m.removed.Store(true)
+		}
+		delete(f.versions, tag)
+	}
+
+	for i, iface := range f.interfaces {
+		if iface == tag {
+			f.interfaces = append(f.interfaces[:i:i], f.interfaces[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// machinesOf returns the machines registered for tag, or those of the first
+// interface tag implements. The slice must not be modified, Register and
+// Unregister replace it instead.
+func (f *FSM) machinesOf(tag reflect.Type) []*fsm {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if machines, ok := f.machines[tag]; ok || tag == nil {
+		return machines
+	}
+
+	for _, iface := range f.interfaces {
+		if tag.Implements(iface) {
+			return f.machines[iface]
+		}
+	}
 	return nil
 }
 
+// machine returns the default machine registered for the type of s, or
+// the version of it selected by WithVersion or the version field of s.
+func (f *FSM) machine(s interface{}, options ...Option) (*fsm, bool) {
+	machines := f.machinesOf(reflect.TypeOf(s))
+	if len(machines) == 0 {
+		return nil, false
+	}
+	if set := f.versionSet(reflect.TypeOf(s)); set != nil {
+		return set.machine(s, options)
+	}
+	return machines[0], true
+}
+
+// machineOn returns the machine registered for column of the type of s.
+func (f *FSM) machineOn(s interface{}, column string) (*fsm, bool) {
+	for _, m := range f.machinesOf(reflect.TypeOf(s)) {
+		if m.column == column {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
 // Fire func to fire event
-func (f *FSM) Fire(ctx context.Context, s interface{}, event string) error {
-	machine, ok := f.machines[reflect.TypeOf(s)]
+func (f *FSM) Fire(ctx context.Context, s interface{}, event string, options ...Option) error {
+	_, err := f.FireE(ctx, s, event, options...)
+	return err
+}
+
+// FireE func to fire event and report how the call ended
+func (f *FSM) FireE(ctx context.Context, s interface{}, event string, options ...Option) (FireResult, error) {
+	return f.fire(ctx, s, event, options...)
+}
+
+// fireDefault fires event on the default machine of s, below middleware.
+func (f *FSM) fireDefault(ctx context.Context, s interface{}, event string, options ...Option) (FireResult, error) {
+	machine, ok := f.machine(s, options...)
 	if !ok {
-		return InternalError{}
+		return Failed, InternalError{}
+	}
+
+	return machine.FireE(ctx, s, event, options...)
+}
+
+// FireOn func to fire event on the machine of the given state column
+func (f *FSM) FireOn(ctx context.Context, s interface{}, column, event string, options ...Option) error {
+	fire := func(ctx context.Context, s interface{}, event string, options ...Option) (FireResult, error) {
+		machine, ok := f.machineOn(s, column)
+		if !ok {
+			return Failed, InternalError{}
+		}
+
+		return machine.FireE(ctx, s, event, options...)
+	}
+	if len(f.middleware) > 0 {
+		fire = f.chain(fire)
 	}
 
-	return machine.Fire(ctx, s, event)
+	_, err := fire(ctx, s, event, options...)
+	return err
 }
 
 // MayFire func return false if event can`t may fire
 func (f *FSM) MayFire(ctx context.Context, s interface{}, event string, options ...Option) (bool, error) {
-	machine, ok := f.machines[reflect.TypeOf(s)]
+	machine, ok := f.machine(s, options...)
 	if !ok {
 		return false, InternalError{}
 	}
@@ -44,7 +249,7 @@ func (f *FSM) MayFire(ctx context.Context, s interface{}, event string, options
 
 // GetPermittedEvents func to return all permitted events
 func (f *FSM) GetPermittedEvents(ctx context.Context, s interface{}, options ...Option) ([]string, error) {
-	machine, ok := f.machines[reflect.TypeOf(s)]
+	machine, ok := f.machine(s, options...)
 	if !ok {
 		return nil, InternalError{}
 	}
@@ -54,7 +259,7 @@ func (f *FSM) GetPermittedEvents(ctx context.Context, s interface{}, options ...
 
 // GetPermittedStates func to return all permitted states
 func (f *FSM) GetPermittedStates(ctx context.Context, s interface{}, options ...Option) ([]State, error) {
-	machine, ok := f.machines[reflect.TypeOf(s)]
+	machine, ok := f.machine(s, options...)
 	if !ok {
 		return nil, InternalError{}
 	}
@@ -62,14 +267,63 @@ func (f *FSM) GetPermittedStates(ctx context.Context, s interface{}, options ...
 	return machine.GetPermittedStates(ctx, s, options...)
 }
 
-// Release removes the instance lock for the given object from memory.
-// This is optional and should be called when an instance is no longer needed
-// to prevent memory accumulation in long-running applications.
-func (f *FSM) Release(s interface{}) {
-	machine, ok := f.machines[reflect.TypeOf(s)]
+// GetPermittedTransitions func to return every transition declared from
+// the current state of s and whether it is permitted, e.g. to render the
+// actions of an entity in a single call
+func (f *FSM) GetPermittedTransitions(ctx context.Context, s interface{}, options ...Option) ([]TransitionInfo, error) {
+	machine, ok := f.machine(s, options...)
 	if !ok {
-		return
+		return nil, InternalError{}
 	}
 
-	machine.instanceLocks.Delete(s)
+	return machine.GetPermittedTransitions(ctx, s, options...)
+}
+
+// MayFireDetailed func to return the result of every guard of event
+func (f *FSM) MayFireDetailed(ctx context.Context, s interface{}, event string, options ...Option) ([]GuardResult, error) {
+	machine, ok := f.machine(s, options...)
+	if !ok {
+		return nil, InternalError{}
+	}
+
+	return machine.MayFireDetailed(ctx, s, event, options...)
+}
+
+// BlockingGuards func to return the names of guards blocking event
+func (f *FSM) BlockingGuards(ctx context.Context, s interface{}, event string, options ...Option) ([]string, error) {
+	machine, ok := f.machine(s, options...)
+	if !ok {
+		return nil, InternalError{}
+	}
+
+	return machine.BlockingGuards(ctx, s, event, options...)
+}
+
+// Release is kept for compatibility. Instance locks are dropped as soon as
+// no transition holds or waits for them, so calling it is no longer needed.
+func (f *FSM) Release(s interface{}) {}
+
+// Stats describes the in-memory bookkeeping of an FSM.
+type Stats struct {
+	// InstanceLocks is the number of instance locks currently held or waited
+	// for across all machines.
+	InstanceLocks int
+	// Actors is the number of running instance goroutines of ExecActor
+	// machines.
+	Actors int
+}
+
+// Stats func to return the current size of the per-instance bookkeeping
+func (f *FSM) Stats() Stats {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var stats Stats
+	for _, machines := range f.machines {
+		for _, machine := range machines {
+			stats.InstanceLocks += machine.instanceLocks.Len()
+			stats.Actors += machine.actors.len()
+		}
+	}
+	return stats
 }
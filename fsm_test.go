@@ -2,6 +2,7 @@ package fsm
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"sync"
 	"testing"
@@ -11,6 +12,12 @@ type TestStruct struct {
 	State State
 }
 
+// byAddress identifies instances by their address, for tests of features
+// requiring an identity that never load an instance again.
+func byAddress(s interface{}) string {
+	return fmt.Sprintf("%p", s)
+}
+
 func IsTestStructValid(ctx context.Context, e *Event) (bool, error) {
 	return true, nil
 }
@@ -310,3 +317,24 @@ func TestReleaseInstance(t *testing.T) {
 		t.Errorf("expected state 'finished', got '%s'", instance.State)
 	}
 }
+
+func TestStatsInstanceLocksReleased(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		if err := fsm.Fire(context.Background(), &TestStruct{State: State("started")}, "make"); err != nil {
+			t.Errorf("Fire() error = %v", err)
+		}
+	}
+
+	if stats := fsm.Stats(); stats.InstanceLocks != 0 {
+		t.Errorf("Stats().InstanceLocks = %d, want 0", stats.InstanceLocks)
+	}
+}
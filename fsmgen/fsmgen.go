@@ -0,0 +1,322 @@
+// Package fsmgen generates reflection-free state machines from a declarative
+// fsm.Schema. The generated code assigns the state field directly and
+// dispatches events with switch statements; guards and callbacks referenced
+// by name in the schema become fields of the generated machine.
+package fsmgen
+
+import (
+	"bytes"
+	"errors"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/ceearrashee/fsm"
+)
+
+// Config describes the code to generate.
+type Config struct {
+	// Package of the generated file.
+	Package string
+	// Type is the model type the machine operates on, e.g. "Order".
+	Type string
+	// Machine is the name of the generated type, Type+"Machine" if empty.
+	Machine string
+	// StateType is the type of the state field, "fsm.State" if empty.
+	StateType string
+	Schema    fsm.Schema
+}
+
+type genBranch struct {
+	From fsm.State
+	To   fsm.State
+}
+
+type genEvent struct {
+	Name     string
+	Branches []genBranch
+	Guards   []string
+	Before   string
+	After    string
+}
+
+// Hooks returns the Before and After callbacks of e that are set.
+func (e genEvent) Hooks() []string {
+	var hooks []string
+	for _, c := range []string{e.Before, e.After} {
+		if c != "" {
+			hooks = append(hooks, c)
+		}
+	}
+	return hooks
+}
+
+type genData struct {
+	Config
+	Events    []genEvent
+	Guards    []string
+	Callbacks []string
+	HasBefore bool
+	HasAfter  bool
+}
+
+// Generate returns the formatted source of the machine described by cfg.
+func Generate(cfg Config) ([]byte, error) {
+	data, err := prepare(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := machineTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// prepare fills in the defaults of cfg and groups the schema by event.
+func prepare(cfg Config) (genData, error) {
+	if cfg.Package == "" || cfg.Type == "" {
+		return genData{}, errors.New("fsmgen: Package and Type are required")
+	}
+	if err := cfg.Schema.Validate(); err != nil {
+		return genData{}, err
+	}
+
+	if cfg.Machine == "" {
+		cfg.Machine = cfg.Type + "Machine"
+	}
+	if cfg.StateType == "" {
+		cfg.StateType = "fsm.State"
+	}
+
+	data := genData{Config: cfg}
+	guards := map[string]bool{}
+	callbacks := map[string]bool{}
+	index := map[string]int{}
+
+	for _, e := range cfg.Schema.Events {
+		// Fire rejects drafts, so they have no generated transitions.
+		if e.Draft {
+			continue
+		}
+		if e.OnError != "" {
+			return genData{}, errors.New("fsmgen: event " + e.Name + " uses on_error, which generated machines don't support")
+		}
+		if e.Quota > 0 {
+			return genData{}, errors.New("fsmgen: event " + e.Name + " uses quota, which generated machines don't support")
+		}
+
+		i, ok := index[e.Name]
+		if !ok {
+			i = len(data.Events)
+			index[e.Name] = i
+			data.Events = append(data.Events, genEvent{Name: e.Name})
+		}
+
+		ev := &data.Events[i]
+		if len(ev.Branches) > 0 && (!equal(ev.Guards, e.Guards) || ev.Before != e.Before || ev.After != e.After) {
+			return genData{}, errors.New("fsmgen: event " + e.Name + " is declared with different hooks")
+		}
+
+		ev.Guards, ev.Before, ev.After = e.Guards, e.Before, e.After
+		for _, src := range e.From {
+			ev.Branches = append(ev.Branches, genBranch{From: src, To: e.To})
+		}
+
+		data.HasBefore = data.HasBefore || e.Before != ""
+		data.HasAfter = data.HasAfter || e.After != ""
+
+		for _, g := range e.Guards {
+			guards[g] = true
+		}
+		for _, c := range []string{e.Before, e.After} {
+			if c != "" {
+				callbacks[c] = true
+			}
+		}
+	}
+
+	data.Guards = sortedKeys(guards)
+	data.Callbacks = sortedKeys(callbacks)
+	return data, nil
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ident turns a schema name like "kyc_passed" into "KycPassed".
+func ident(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var funcs = template.FuncMap{
+	"ident": ident,
+}
+
+var machineTemplate = template.Must(template.New("machine").Funcs(funcs).Parse(`// Code generated by fsmgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+
+	"github.com/ceearrashee/fsm"
+)
+
+// {{.Machine}} is a reflection-free state machine for {{.Type}}.
+type {{.Machine}} struct {
+{{- range .Guards}}
+	Guard{{ident .}} fsm.Guard
+{{- end}}
+{{- range .Callbacks}}
+	Callback{{ident .}} fsm.Callback
+{{- end}}
+}
+
+// Destination returns the state event leads to from the current state of s.
+func (m *{{.Machine}}) Destination(s *{{.Type}}, event string) ({{.StateType}}, bool) {
+	switch event {
+{{- range .Events}}
+	case {{printf "%q" .Name}}:
+		switch s.{{$.Schema.Column}} {
+{{- range .Branches}}
+		case {{printf "%q" .From}}:
+			return {{printf "%q" .To}}, true
+{{- end}}
+		}
+{{- end}}
+	}
+	return "", false
+}
+
+// MayFire reports whether event can be fired on s.
+func (m *{{.Machine}}) MayFire(ctx context.Context, s *{{.Type}}, event string) (bool, error) {
+	to, ok := m.Destination(s, event)
+	if !ok {
+		return false, nil
+	}
+
+	guard, err := m.guard(ctx, &fsm.Event{Event: event, Source: s, Destination: fsm.State(to)})
+	return guard == "", err
+}
+
+// Fire fires event on s.
+func (m *{{.Machine}}) Fire(ctx context.Context, s *{{.Type}}, event string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	to, ok := m.Destination(s, event)
+	if !ok {
+		return fsm.UnknownEventError{Event: event}
+	}
+
+{{- if .Callbacks}}
+	if err := m.callbacks(event); err != nil {
+		return err
+	}
+{{- end}}
+
+	e := &fsm.Event{Event: event, Source: s, Destination: fsm.State(to)}
+	if guard, err := m.guard(ctx, e); err != nil || guard != "" {
+		return fsm.InvalidTransitionError{Event: event, State: string(s.{{.Schema.Column}}), Guard: guard, Err: err}
+	}
+
+{{- if .HasBefore}}
+	switch event {
+{{- range .Events}}{{if .Before}}
+	case {{printf "%q" .Name}}:
+		if err := m.Callback{{ident .Before}}(ctx, e); err != nil {
+			return err
+		}
+{{- end}}{{end}}
+	}
+{{- end}}
+
+	s.{{.Schema.Column}} = to
+{{- if .HasAfter}}
+
+	switch event {
+{{- range .Events}}{{if .After}}
+	case {{printf "%q" .Name}}:
+		return m.Callback{{ident .After}}(ctx, e)
+{{- end}}{{end}}
+	}
+{{- end}}
+
+	return nil
+}
+{{- if .Callbacks}}
+
+// callbacks returns UnknownCallbackError if a callback of event is not set,
+// so Fire fails before changing anything.
+func (m *{{.Machine}}) callbacks(event string) error {
+	switch event {
+{{- range .Events}}{{if or .Before .After}}
+	case {{printf "%q" .Name}}:
+{{- range .Hooks}}
+		if m.Callback{{ident .}} == nil {
+			return fsm.UnknownCallbackError{Event: event, Callback: {{printf "%q" .}}}
+		}
+{{- end}}
+{{- end}}{{end}}
+	}
+	return nil
+}
+{{- end}}
+
+// guard returns the name of the first guard of e rejecting it.
+func (m *{{.Machine}}) guard(ctx context.Context, e *fsm.Event) (string, error) {
+{{- if .Guards}}
+	switch e.Event {
+{{- range .Events}}{{if .Guards}}
+	case {{printf "%q" .Name}}:
+{{- range .Guards}}
+		if m.Guard{{ident .}} == nil {
+			return {{printf "%q" .}}, fsm.UnknownGuardError{Event: e.Event, Guard: {{printf "%q" .}}}
+		}
+		if ok, err := m.Guard{{ident .}}(ctx, e); err != nil || !ok {
+			return {{printf "%q" .}}, err
+		}
+{{- end}}
+{{- end}}{{end}}
+	}
+{{- end}}
+	return "", nil
+}
+`))
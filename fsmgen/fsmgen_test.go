@@ -0,0 +1,87 @@
+package fsmgen
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/ceearrashee/fsm"
+)
+
+func TestGenerateMatchesExample(t *testing.T) {
+	f, err := os.Open("internal/example/order.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	schema, err := fsm.ParseDefinition(f, fsm.FormatYAML)
+	if err != nil {
+		t.Fatalf("ParseDefinition() error = %v", err)
+	}
+
+	src, err := Generate(Config{Package: "example", Type: "Order", Schema: schema})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	want, err := os.ReadFile("internal/example/order_fsm.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(src, want) {
+		t.Error("generated code differs from internal/example/order_fsm.go, run go generate")
+	}
+}
+
+func TestGenerateTestsMatchesExample(t *testing.T) {
+	f, err := os.Open("internal/example/order.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	schema, err := fsm.ParseDefinition(f, fsm.FormatYAML)
+	if err != nil {
+		t.Fatalf("ParseDefinition() error = %v", err)
+	}
+
+	src, err := GenerateTests(Config{Package: "example", Type: "Order", Schema: schema})
+	if err != nil {
+		t.Fatalf("GenerateTests() error = %v", err)
+	}
+
+	want, err := os.ReadFile("internal/example/order_fsm_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(src, want) {
+		t.Error("generated tests differ from internal/example/order_fsm_test.go, run go generate")
+	}
+}
+
+func TestGenerateUnsupported(t *testing.T) {
+	schema := fsm.Schema{Column: "State", Events: []fsm.SchemaEvent{
+		{Name: "make", From: []fsm.State{"started"}, To: "finished"},
+		{Name: "ship", From: []fsm.State{"finished"}, To: "shipped", Draft: true},
+	}}
+	data, err := prepare(Config{Package: "example", Type: "Order", Schema: schema})
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
+	if len(data.Events) != 1 || data.Events[0].Name != "make" {
+		t.Errorf("prepare() events = %+v, want drafts skipped", data.Events)
+	}
+
+	for _, e := range []fsm.SchemaEvent{
+		{Name: "pay", From: []fsm.State{"started"}, To: "paid", OnError: "fail"},
+		{Name: "pay", From: []fsm.State{"started"}, To: "paid", Quota: 1},
+	} {
+		schema := fsm.Schema{Column: "State", Events: []fsm.SchemaEvent{e}}
+		if _, err := Generate(Config{Package: "example", Type: "Order", Schema: schema}); err == nil {
+			t.Errorf("Generate(%+v) error = nil", e)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+// Package example holds a machine generated by fsmgen, used to check the
+// generated code compiles and behaves like the reflection based machine.
+package example
+
+import "github.com/ceearrashee/fsm"
+
+//go:generate go run ../../../cmd/fsmgen -in order.yaml -type Order -out order_fsm.go
+//go:generate go run ../../../cmd/fsmgen -in order.yaml -type Order -tests -out order_fsm_test.go
+
+type Order struct {
+	State fsm.State
+}
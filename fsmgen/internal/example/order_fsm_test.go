@@ -0,0 +1,48 @@
+// Code generated by fsmgen as a starting point, edit it freely.
+
+package example
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ceearrashee/fsm"
+)
+
+// newOrderMachineForTest returns a machine whose guards pass and whose
+// callbacks succeed. Replace the stubs to test the real behaviour.
+func newOrderMachineForTest() *OrderMachine {
+	return &OrderMachine{
+		GuardValid:     func(ctx context.Context, e *fsm.Event) (bool, error) { return true, nil },
+		CallbackNotify: func(ctx context.Context, e *fsm.Event) error { return nil },
+	}
+}
+
+func TestOrderMachineTransitions(t *testing.T) {
+	tests := []struct {
+		state   fsm.State
+		event   string
+		want    fsm.State
+		wantErr bool
+	}{
+		{"started", "make", "finished", false}, // guards: valid
+		{"started", "cancel", "canceled", false},
+		{"finished", "make", "finished", true},
+		{"finished", "cancel", "canceled", false},
+		{"canceled", "make", "canceled", true},
+		{"canceled", "cancel", "canceled", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.state)+"/"+tt.event, func(t *testing.T) {
+			s := &Order{State: tt.state}
+			err := newOrderMachineForTest().Fire(context.Background(), s, tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Fire() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if s.State != tt.want {
+				t.Errorf("State = %v, want %v", s.State, tt.want)
+			}
+		})
+	}
+}
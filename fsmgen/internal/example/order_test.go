@@ -0,0 +1,53 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ceearrashee/fsm"
+)
+
+func TestGeneratedMachine(t *testing.T) {
+	notified := 0
+	m := &OrderMachine{
+		GuardValid: func(ctx context.Context, e *fsm.Event) (bool, error) {
+			return e.Source.(*Order).State == "started", nil
+		},
+		CallbackNotify: func(ctx context.Context, e *fsm.Event) error {
+			notified++
+			return nil
+		},
+	}
+
+	order := &Order{State: "started"}
+	if err := m.Fire(context.Background(), order, "make"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	if order.State != "finished" || notified != 1 {
+		t.Errorf("unexpected state %s and %d notifications", order.State, notified)
+	}
+
+	if err := m.Fire(context.Background(), order, "make"); !errors.As(err, new(fsm.UnknownEventError)) {
+		t.Errorf("expected 'UnknownEventError', got %v", err)
+	}
+
+	if err := m.Fire(context.Background(), order, "cancel"); err != nil || order.State != "canceled" {
+		t.Errorf("Fire(cancel) = %v, state %s", err, order.State)
+	}
+}
+
+func TestGeneratedMachineMissingCallback(t *testing.T) {
+	m := &OrderMachine{
+		GuardValid: func(ctx context.Context, e *fsm.Event) (bool, error) { return true, nil },
+	}
+
+	order := &Order{State: "started"}
+	if err := m.Fire(context.Background(), order, "make"); !errors.As(err, new(fsm.UnknownCallbackError)) {
+		t.Errorf("expected 'UnknownCallbackError', got %v", err)
+	}
+	if order.State != "started" {
+		t.Errorf("State = %s, want started", order.State)
+	}
+}
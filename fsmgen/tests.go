@@ -0,0 +1,132 @@
+package fsmgen
+
+import (
+	"bytes"
+	"go/format"
+	"text/template"
+
+	"github.com/ceearrashee/fsm"
+)
+
+type genCase struct {
+	State  fsm.State
+	Event  string
+	Want   fsm.State
+	Err    bool
+	Guards []string
+}
+
+type genTestData struct {
+	genData
+	Cases []genCase
+}
+
+// GenerateTests returns the source of a table-driven test of the machine
+// Generate produces for cfg, with one case for every pair of state and
+// event. Declared pairs expect their destination, others an error. Guards
+// are stubbed to pass, so the expectations are meant to be edited as the
+// stubs are replaced with real guards and callbacks.
+func GenerateTests(cfg Config) ([]byte, error) {
+	data, err := prepare(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	td := genTestData{genData: data}
+	for _, state := range states(cfg.Schema) {
+		for _, ev := range data.Events {
+			c := genCase{State: state, Event: ev.Name, Err: true, Want: state}
+			for _, br := range ev.Branches {
+				if br.From == state {
+					c.Want, c.Err, c.Guards = br.To, false, ev.Guards
+					break
+				}
+			}
+			td.Cases = append(td.Cases, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := testTemplate.Execute(&buf, td); err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// states returns the states of s, those listed in s.States or else every
+// source and destination in declaration order.
+func states(s fsm.Schema) []fsm.State {
+	if len(s.States) > 0 {
+		return s.States
+	}
+
+	seen := map[fsm.State]bool{}
+	var states []fsm.State
+	add := func(state fsm.State) {
+		if !seen[state] {
+			seen[state] = true
+			states = append(states, state)
+		}
+	}
+	for _, e := range s.Events {
+		for _, src := range e.From {
+			add(src)
+		}
+		add(e.To)
+	}
+	return states
+}
+
+var testTemplate = template.Must(template.New("tests").Funcs(funcs).Parse(`// Code generated by fsmgen as a starting point, edit it freely.
+
+package {{.Package}}
+
+import (
+	"context"
+	"testing"
+{{- if or .Guards .Callbacks}}
+
+	"github.com/ceearrashee/fsm"
+{{- end}}
+)
+
+// new{{.Machine}}ForTest returns a machine whose guards pass and whose
+// callbacks succeed. Replace the stubs to test the real behaviour.
+func new{{.Machine}}ForTest() *{{.Machine}} {
+	return &{{.Machine}}{
+{{- range .Guards}}
+		Guard{{ident .}}: func(ctx context.Context, e *fsm.Event) (bool, error) { return true, nil },
+{{- end}}
+{{- range .Callbacks}}
+		Callback{{ident .}}: func(ctx context.Context, e *fsm.Event) error { return nil },
+{{- end}}
+	}
+}
+
+func Test{{.Machine}}Transitions(t *testing.T) {
+	tests := []struct {
+		state   {{.StateType}}
+		event   string
+		want    {{.StateType}}
+		wantErr bool
+	}{
+{{- range .Cases}}
+		{ {{- printf "%q" .State}}, {{printf "%q" .Event}}, {{printf "%q" .Want}}, {{.Err}}}, {{- if .Guards}} // guards: {{range $i, $g := .Guards}}{{if $i}}, {{end}}{{$g}}{{end}}{{end}}
+{{- end}}
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.state)+"/"+tt.event, func(t *testing.T) {
+			s := &{{.Type}}{ {{- .Schema.Column}}: tt.state}
+			err := new{{.Machine}}ForTest().Fire(context.Background(), s, tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Fire() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if s.{{.Schema.Column}} != tt.want {
+				t.Errorf("{{.Schema.Column}} = %v, want %v", s.{{.Schema.Column}}, tt.want)
+			}
+		})
+	}
+}
+`))
@@ -0,0 +1,240 @@
+// Package fsmhttp exposes the machines of an fsm.FSM over HTTP, turning it
+// into a small workflow service:
+//
+//	GET  /machines                                   list machines and their schemas
+//	GET  /machines/{type}/states/{state}/events      events declared from a state
+//	GET  /machines/{type}/entities/{id}/events       events permitted for an entity
+//	POST /machines/{type}/entities/{id}/events/{event} fire an event on an entity
+//	GET  /openapi.json                               OpenAPI document of the above
+//
+// Entities are read and written through a Loader.
+package fsmhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/ceearrashee/fsm"
+)
+
+// ErrNotFound is returned by a Loader for unknown entities.
+var ErrNotFound = errors.New("fsmhttp: entity not found")
+
+// Loader reads and writes the entities of a machine type by ID.
+type Loader interface {
+	Load(ctx context.Context, typ, id string) (interface{}, error)
+	Save(ctx context.Context, typ, id string, entity interface{}) error
+}
+
+// Server is an http.Handler serving the machines of FSM.
+type Server struct {
+	FSM *fsm.FSM
+	// Types maps the names used in URLs to the tags the machines are
+	// registered for, e.g. "order" to reflect.TypeOf((*Order)(nil)).
+	Types  map[string]reflect.Type
+	Loader Loader
+	// Version is reported as the version of the API by GET /openapi.json.
+	Version string
+
+	once sync.Once
+	mux  *http.ServeMux
+}
+
+// Machine describes a machine in the GET /machines response.
+type Machine struct {
+	Name   string     `json:"name"`
+	Schema fsm.Schema `json:"schema"`
+}
+
+// FireRequest is the optional body of an event POST.
+type FireRequest struct {
+	Reason string                 `json:"reason,omitempty"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+}
+
+// FireResponse is returned by a successful event POST.
+type FireResponse struct {
+	Result string               `json:"result"`
+	States map[string]fsm.State `json:"states"`
+}
+
+// EventsResponse lists events. Guarded holds the events declared from a
+// state whose guards or roles must be checked against an entity and caller.
+type EventsResponse struct {
+	Events  []string `json:"events"`
+	Guarded []string `json:"guarded,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.once.Do(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /machines", s.machines)
+		mux.HandleFunc("GET /machines/{type}/states/{state}/events", s.stateEvents)
+		mux.HandleFunc("GET /machines/{type}/entities/{id}/events", s.entityEvents)
+		mux.HandleFunc("POST /machines/{type}/entities/{id}/events/{event}", s.fire)
+		mux.HandleFunc("GET /openapi.json", s.openAPI)
+		s.mux = mux
+	})
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) machines(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(s.Types))
+	for name := range s.Types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	machines := make([]Machine, 0, len(names))
+	for _, name := range names {
+		schema, err := s.FSM.Schema(s.Types[name])
+		if err != nil {
+			continue
+		}
+		machines = append(machines, Machine{Name: name, Schema: schema})
+	}
+	writeJSON(w, http.StatusOK, machines)
+}
+
+func (s *Server) stateEvents(w http.ResponseWriter, r *http.Request) {
+	tag, ok := s.Types[r.PathValue("type")]
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("unknown machine type"))
+		return
+	}
+
+	p, err := s.FSM.Permitted(tag, fsm.State(r.PathValue("state")))
+	if err != nil {
+		writeError(w, status(err), err)
+		return
+	}
+	events := p.Events()
+	if events == nil {
+		events = []string{}
+	}
+	writeJSON(w, http.StatusOK, EventsResponse{Events: events, Guarded: p.Guarded()})
+}
+
+func (s *Server) entityEvents(w http.ResponseWriter, r *http.Request) {
+	entity, err := s.load(r)
+	if err != nil {
+		writeError(w, status(err), err)
+		return
+	}
+
+	events, err := s.FSM.GetPermittedEvents(r.Context(), entity)
+	if err != nil {
+		writeError(w, status(err), err)
+		return
+	}
+	sort.Strings(events)
+	writeJSON(w, http.StatusOK, EventsResponse{Events: events})
+}
+
+func (s *Server) fire(w http.ResponseWriter, r *http.Request) {
+	var req FireRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	entity, err := s.load(r)
+	if err != nil {
+		writeError(w, status(err), err)
+		return
+	}
+
+	options := []fsm.Option{fsm.WithReason(req.Reason)}
+	if req.Meta != nil {
+		options = append(options, fsm.WithMeta(req.Meta))
+	}
+
+	before, err := s.FSM.Regions(entity)
+	if err != nil {
+		writeError(w, status(err), err)
+		return
+	}
+
+	result, err := s.FSM.FireE(r.Context(), entity, r.PathValue("event"), options...)
+	states, rerr := s.FSM.Regions(entity)
+	if rerr != nil {
+		writeError(w, status(rerr), errors.Join(err, rerr))
+		return
+	}
+
+	// An entity that moved is saved even if a step after the transition
+	// failed, so the stored copy doesn't fall behind the one in memory.
+	if err != nil && maps.Equal(before, states) {
+		writeError(w, status(err), err)
+		return
+	}
+
+	if serr := s.Loader.Save(r.Context(), r.PathValue("type"), r.PathValue("id"), entity); serr != nil {
+		writeError(w, http.StatusInternalServerError, serr)
+		return
+	}
+	if err != nil {
+		writeError(w, status(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, FireResponse{Result: result.String(), States: states})
+}
+
+func (s *Server) load(r *http.Request) (interface{}, error) {
+	typ := r.PathValue("type")
+	if _, ok := s.Types[typ]; !ok {
+		return nil, ErrNotFound
+	}
+	return s.Loader.Load(r.Context(), typ, r.PathValue("id"))
+}
+
+// status maps the errors of the fsm package to HTTP status codes.
+func status(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.As(err, new(fsm.UnknownEventError)), errors.As(err, new(fsm.InternalError)):
+		return http.StatusNotFound
+	case errors.As(err, new(fsm.PermissionDeniedError)):
+		return http.StatusForbidden
+	case errors.As(err, new(fsm.QuotaExceededError)), errors.As(err, new(fsm.ThrottledError)):
+		return http.StatusTooManyRequests
+	case errors.As(err, new(fsm.InvalidTransitionError)), errors.As(err, new(fsm.MachineCompletedError)),
+		errors.As(err, new(fsm.MachineFrozenError)), errors.As(err, new(fsm.DraftTransitionError)),
+		errors.As(err, new(fsm.StateConflictError)), errors.As(err, new(fsm.VersionConflictError)),
+		errors.As(err, new(fsm.StaleStateError)):
+		return http.StatusConflict
+	case errors.As(err, new(fsm.UnknownStateError)), errors.As(err, new(fsm.UninitializedStateError)):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes err with code, telling throttled callers when to retry.
+func writeError(w http.ResponseWriter, code int, err error) {
+	var throttled fsm.ThrottledError
+	if errors.As(err, &throttled) {
+		seconds := int64(math.Ceil(throttled.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	}
+	writeJSON(w, code, errorResponse{Error: err.Error()})
+}
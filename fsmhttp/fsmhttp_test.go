@@ -0,0 +1,193 @@
+package fsmhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ceearrashee/fsm"
+)
+
+type Order struct {
+	State fsm.State
+}
+
+type mapLoader map[string]*Order
+
+func (l mapLoader) Load(ctx context.Context, typ, id string) (interface{}, error) {
+	order, ok := l[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copy := *order
+	return &copy, nil
+}
+
+func (l mapLoader) Save(ctx context.Context, typ, id string, entity interface{}) error {
+	l[id] = entity.(*Order)
+	return nil
+}
+
+func newServer(t *testing.T) (*httptest.Server, mapLoader) {
+	tag := reflect.TypeOf((*Order)(nil))
+	f := fsm.NewFSM()
+	if err := f.Register(tag, "State", fsm.Events{{
+		Name: "pay",
+		From: []fsm.State{"created"},
+		To:   fsm.State("paid"),
+	}}); err != nil {
+		t.Fatalf("fsm.Register() error = %v", err)
+	}
+
+	loader := mapLoader{"1": {State: "created"}}
+	srv := httptest.NewServer(&Server{FSM: f, Types: map[string]reflect.Type{"order": tag}, Loader: loader})
+	t.Cleanup(srv.Close)
+	return srv, loader
+}
+
+func TestServerFire(t *testing.T) {
+	srv, loader := newServer(t)
+
+	resp, err := http.Post(srv.URL+"/machines/order/entities/1/events/pay", "application/json", strings.NewReader(`{"reason":"checkout"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var got FireResponse
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST = %d, %v", resp.StatusCode, err)
+	}
+	if got.Result != "completed" || got.States["State"] != "paid" || loader["1"].State != "paid" {
+		t.Errorf("POST = %+v, stored %v", got, loader["1"].State)
+	}
+
+	resp, err = http.Post(srv.URL+"/machines/order/entities/1/events/pay", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("POST again = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestServerFireSavesMovedEntity(t *testing.T) {
+	tag := reflect.TypeOf((*Order)(nil))
+	f := fsm.NewFSM()
+	if err := f.Register(tag, "State", fsm.Events{{
+		Name: "pay",
+		From: []fsm.State{"created"},
+		To:   fsm.State("paid"),
+		After: func(ctx context.Context, e *fsm.Event) error {
+			return errors.New("receipt not sent")
+		},
+	}}); err != nil {
+		t.Fatalf("fsm.Register() error = %v", err)
+	}
+	loader := mapLoader{"1": {State: "created"}}
+	srv := httptest.NewServer(&Server{FSM: f, Types: map[string]reflect.Type{"order": tag}, Loader: loader})
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/machines/order/entities/1/events/pay", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError || loader["1"].State != "paid" {
+		t.Errorf("POST = %d, stored %v, want %d and paid", resp.StatusCode, loader["1"].State, http.StatusInternalServerError)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	for _, tt := range []struct {
+		err        error
+		code       int
+		retryAfter string
+	}{
+		{fmt.Errorf("fire: %w", fsm.ThrottledError{Event: "pay", RetryAfter: 1500 * time.Millisecond}), http.StatusTooManyRequests, "2"},
+		{fsm.StaleStateError{}, http.StatusConflict, ""},
+		{fsm.UnknownStateError{}, http.StatusUnprocessableEntity, ""},
+		{fsm.UninitializedStateError{}, http.StatusUnprocessableEntity, ""},
+	} {
+		w := httptest.NewRecorder()
+		writeError(w, status(tt.err), tt.err)
+		if w.Code != tt.code || w.Header().Get("Retry-After") != tt.retryAfter {
+			t.Errorf("writeError(%T) = %d, Retry-After %q, want %d, %q", tt.err, w.Code, w.Header().Get("Retry-After"), tt.code, tt.retryAfter)
+		}
+	}
+}
+
+func TestServerEvents(t *testing.T) {
+	srv, _ := newServer(t)
+
+	for path, want := range map[string][]string{
+		"/machines/order/states/created/events": {"pay"},
+		"/machines/order/entities/1/events":     {"pay"},
+		"/machines/order/states/paid/events":    {},
+	} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got EventsResponse
+		err = json.NewDecoder(resp.Body).Decode(&got)
+		resp.Body.Close()
+		if err != nil || !reflect.DeepEqual(got.Events, want) {
+			t.Errorf("GET %s = %v, %v, want %v", path, got.Events, err, want)
+		}
+	}
+
+	resp, err := http.Get(srv.URL + "/machines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var machines []Machine
+	if err := json.NewDecoder(resp.Body).Decode(&machines); err != nil || len(machines) != 1 || machines[0].Name != "order" {
+		t.Errorf("GET /machines = %+v, %v", machines, err)
+	}
+}
+
+func TestServerOpenAPI(t *testing.T) {
+	srv, _ := newServer(t)
+
+	resp, err := http.Get(srv.URL + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Parameters []struct {
+				Name   string `json:"name"`
+				Schema struct {
+					Enum []string `json:"enum"`
+				} `json:"schema"`
+			} `json:"parameters"`
+		} `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil || doc.OpenAPI != "3.1.0" {
+		t.Fatalf("GET /openapi.json = %+v, %v", doc, err)
+	}
+
+	post := doc.Paths["/machines/order/entities/{id}/events/{event}"]["post"]
+	if len(post.Parameters) != 2 || !reflect.DeepEqual(post.Parameters[1].Schema.Enum, []string{"pay"}) {
+		t.Errorf("POST parameters = %+v, want event enum [pay]", post.Parameters)
+	}
+	get := doc.Paths["/machines/order/states/{state}/events"]["get"]
+	if len(get.Parameters) != 1 || !reflect.DeepEqual(get.Parameters[0].Schema.Enum, []string{"created", "paid"}) {
+		t.Errorf("GET parameters = %+v, want state enum [created paid]", get.Parameters)
+	}
+}
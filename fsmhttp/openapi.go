@@ -0,0 +1,120 @@
+package fsmhttp
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/ceearrashee/fsm"
+)
+
+// openAPI serves an OpenAPI 3.1 document of the routes of s with a path per
+// machine type, restricting states and events to those the machine declares.
+// The JSON Schema of each machine (see fsm.JSONSchema) is a component named
+// after its type.
+func (s *Server) openAPI(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(s.Types))
+	for name := range s.Types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	paths := map[string]interface{}{
+		"/machines": map[string]interface{}{
+			"get": operation("List machines and their schemas", nil, nil, map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "object"},
+			}),
+		},
+	}
+	schemas := map[string]interface{}{
+		"FireRequest": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"reason": map[string]interface{}{"type": "string"},
+				"meta":   map[string]interface{}{"type": "object"},
+			},
+		},
+		"FireResponse": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"result": map[string]interface{}{"type": "string"},
+				"states": map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}},
+			},
+		},
+		"EventsResponse": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"events":  stringArray(),
+				"guarded": stringArray(),
+			},
+		},
+		"Error": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"error": map[string]interface{}{"type": "string"}},
+		},
+	}
+
+	for _, name := range names {
+		schema, err := s.FSM.Schema(s.Types[name])
+		if err != nil {
+			continue
+		}
+		machine := fsm.JSONSchema(schema, fsm.ExportOptions{Title: name})
+		schemas[name] = machine
+
+		properties := machine["properties"].(map[string]interface{})
+		state, event := properties[schema.Column], properties["event"]
+		id := parameter("id", map[string]interface{}{"type": "string"})
+		events := ref("EventsResponse")
+
+		paths["/machines/"+name+"/states/{state}/events"] = map[string]interface{}{
+			"get": operation("Events declared from a state", []interface{}{parameter("state", state)}, nil, events),
+		}
+		paths["/machines/"+name+"/entities/{id}/events"] = map[string]interface{}{
+			"get": operation("Events permitted for an entity", []interface{}{id}, nil, events),
+		}
+		paths["/machines/"+name+"/entities/{id}/events/{event}"] = map[string]interface{}{
+			"post": operation("Fire an event on an entity", []interface{}{id, parameter("event", event)}, ref("FireRequest"), ref("FireResponse")),
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"openapi":    "3.1.0",
+		"info":       map[string]interface{}{"title": "fsmhttp", "version": s.Version},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	})
+}
+
+func operation(summary string, parameters []interface{}, body, response interface{}) map[string]interface{} {
+	op := map[string]interface{}{
+		"summary": summary,
+		"responses": map[string]interface{}{
+			"200":     map[string]interface{}{"description": "OK", "content": jsonContent(response)},
+			"default": map[string]interface{}{"description": "Error", "content": jsonContent(ref("Error"))},
+		},
+	}
+	if parameters != nil {
+		op["parameters"] = parameters
+	}
+	if body != nil {
+		op["requestBody"] = map[string]interface{}{"required": false, "content": jsonContent(body)}
+	}
+	return op
+}
+
+func parameter(name string, schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"name": name, "in": "path", "required": true, "schema": schema}
+}
+
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func stringArray() map[string]interface{} {
+	return map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}
+}
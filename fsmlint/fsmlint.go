@@ -0,0 +1,288 @@
+// Package fsmlint checks declarative fsm.Schema definitions against
+// workflow design rules, e.g. naming conventions or a maximum number of
+// events leaving a state. The rules are configured through Config; custom
+// rules can be added as Rule functions.
+package fsmlint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+
+	"github.com/ceearrashee/fsm"
+	"gopkg.in/yaml.v3"
+)
+
+// Config selects and parameterizes the built-in rules. Zero values disable
+// a rule.
+type Config struct {
+	// StatePattern is a regular expression every state must match.
+	StatePattern string `json:"state_pattern,omitempty" yaml:"state_pattern,omitempty"`
+	// EventPattern is a regular expression every event must match.
+	EventPattern string `json:"event_pattern,omitempty" yaml:"event_pattern,omitempty"`
+	// MaxFanOut is the maximum number of events leaving a single state.
+	MaxFanOut int `json:"max_fan_out,omitempty" yaml:"max_fan_out,omitempty"`
+	// RequireTerminal requires at least one state without outgoing events.
+	RequireTerminal bool `json:"require_terminal,omitempty" yaml:"require_terminal,omitempty"`
+	// TerminalStates must be reached by some event and have no outgoing
+	// events.
+	TerminalStates []fsm.State `json:"terminal_states,omitempty" yaml:"terminal_states,omitempty"`
+	// ForbiddenEdges lists transitions no event may make.
+	ForbiddenEdges []Edge `json:"forbidden_edges,omitempty" yaml:"forbidden_edges,omitempty"`
+}
+
+// Edge is a transition between two states.
+type Edge struct {
+	From fsm.State `json:"from" yaml:"from"`
+	To   fsm.State `json:"to" yaml:"to"`
+}
+
+// Issue is a rule violation. Event and State are empty if the issue is not
+// about a specific event or state.
+type Issue struct {
+	Rule    string
+	Event   string
+	State   fsm.State
+	Message string
+}
+
+func (i Issue) String() string {
+	return i.Rule + ": " + i.Message
+}
+
+// Rule checks schema and returns its violations.
+type Rule func(schema fsm.Schema) []Issue
+
+// Linter checks schemas against a rule set.
+type Linter struct {
+	rules []Rule
+}
+
+// ParseConfig decodes a Config from r.
+func ParseConfig(r io.Reader, format fsm.Format) (Config, error) {
+	var cfg Config
+
+	switch format {
+	case fsm.FormatJSON:
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return Config{}, err
+		}
+	case fsm.FormatYAML:
+		dec := yaml.NewDecoder(r)
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil && err != io.EOF {
+			return Config{}, err
+		}
+	default:
+		return Config{}, fmt.Errorf("fsmlint: unknown config format %d", format)
+	}
+
+	return cfg, nil
+}
+
+// New returns a Linter running the rules enabled in cfg followed by extra.
+func New(cfg Config, extra ...Rule) (*Linter, error) {
+	l := &Linter{}
+
+	if cfg.StatePattern != "" {
+		re, err := regexp.Compile(cfg.StatePattern)
+		if err != nil {
+			return nil, fmt.Errorf("fsmlint: state pattern: %w", err)
+		}
+		l.rules = append(l.rules, StateNaming(re))
+	}
+
+	if cfg.EventPattern != "" {
+		re, err := regexp.Compile(cfg.EventPattern)
+		if err != nil {
+			return nil, fmt.Errorf("fsmlint: event pattern: %w", err)
+		}
+		l.rules = append(l.rules, EventNaming(re))
+	}
+
+	if cfg.MaxFanOut > 0 {
+		l.rules = append(l.rules, MaxFanOut(cfg.MaxFanOut))
+	}
+
+	if cfg.RequireTerminal {
+		l.rules = append(l.rules, RequireTerminal())
+	}
+
+	if len(cfg.TerminalStates) > 0 {
+		l.rules = append(l.rules, TerminalStates(cfg.TerminalStates...))
+	}
+
+	if len(cfg.ForbiddenEdges) > 0 {
+		l.rules = append(l.rules, ForbiddenEdges(cfg.ForbiddenEdges...))
+	}
+
+	l.rules = append(l.rules, extra...)
+	return l, nil
+}
+
+// Lint runs every rule on schema and returns the issues in rule order.
+func (l *Linter) Lint(schema fsm.Schema) []Issue {
+	issues := []Issue{}
+	for _, rule := range l.rules {
+		issues = append(issues, rule(schema)...)
+	}
+	return issues
+}
+
+// states returns every state used by schema in sorted order.
+func states(schema fsm.Schema) []fsm.State {
+	seen := make(map[fsm.State]bool)
+	for _, state := range schema.States {
+		seen[state] = true
+	}
+	for _, e := range schema.Events {
+		for _, src := range e.From {
+			seen[src] = true
+		}
+		seen[e.To] = true
+	}
+
+	list := make([]fsm.State, 0, len(seen))
+	for state := range seen {
+		list = append(list, state)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
+
+// fanOut returns the names of the events leaving each state.
+func fanOut(schema fsm.Schema) map[fsm.State][]string {
+	out := make(map[fsm.State][]string)
+	for _, e := range schema.Events {
+		for _, src := range e.From {
+			out[src] = append(out[src], e.Name)
+		}
+	}
+	return out
+}
+
+// StateNaming requires every state to match re.
+func StateNaming(re *regexp.Regexp) Rule {
+	return func(schema fsm.Schema) []Issue {
+		var issues []Issue
+		for _, state := range states(schema) {
+			if !re.MatchString(string(state)) {
+				issues = append(issues, Issue{
+					Rule:    "state-naming",
+					State:   state,
+					Message: fmt.Sprintf("state %q does not match %s", state, re),
+				})
+			}
+		}
+		return issues
+	}
+}
+
+// EventNaming requires every event to match re.
+func EventNaming(re *regexp.Regexp) Rule {
+	return func(schema fsm.Schema) []Issue {
+		var issues []Issue
+		for _, e := range schema.Events {
+			if !re.MatchString(e.Name) {
+				issues = append(issues, Issue{
+					Rule:    "event-naming",
+					Event:   e.Name,
+					Message: fmt.Sprintf("event %q does not match %s", e.Name, re),
+				})
+			}
+		}
+		return issues
+	}
+}
+
+// MaxFanOut limits the number of events leaving a single state.
+func MaxFanOut(max int) Rule {
+	return func(schema fsm.Schema) []Issue {
+		out := fanOut(schema)
+
+		var issues []Issue
+		for _, state := range states(schema) {
+			if n := len(out[state]); n > max {
+				issues = append(issues, Issue{
+					Rule:    "max-fan-out",
+					State:   state,
+					Message: fmt.Sprintf("state %q has %d outgoing events, at most %d allowed", state, n, max),
+				})
+			}
+		}
+		return issues
+	}
+}
+
+// RequireTerminal requires at least one state without outgoing events.
+func RequireTerminal() Rule {
+	return func(schema fsm.Schema) []Issue {
+		out := fanOut(schema)
+		for _, state := range states(schema) {
+			if len(out[state]) == 0 {
+				return nil
+			}
+		}
+		return []Issue{{Rule: "require-terminal", Message: "machine has no terminal state"}}
+	}
+}
+
+// TerminalStates requires each of terminal to be the destination of some
+// event and to have no outgoing events.
+func TerminalStates(terminal ...fsm.State) Rule {
+	return func(schema fsm.Schema) []Issue {
+		out := fanOut(schema)
+		reached := make(map[fsm.State]bool)
+		for _, e := range schema.Events {
+			reached[e.To] = true
+		}
+
+		var issues []Issue
+		for _, state := range terminal {
+			switch {
+			case !reached[state]:
+				issues = append(issues, Issue{
+					Rule:    "terminal-states",
+					State:   state,
+					Message: fmt.Sprintf("terminal state %q is never reached", state),
+				})
+			case len(out[state]) > 0:
+				issues = append(issues, Issue{
+					Rule:    "terminal-states",
+					State:   state,
+					Message: fmt.Sprintf("terminal state %q has outgoing events %v", state, out[state]),
+				})
+			}
+		}
+		return issues
+	}
+}
+
+// ForbiddenEdges rejects events transitioning along any of edges.
+func ForbiddenEdges(edges ...Edge) Rule {
+	forbidden := make(map[Edge]bool, len(edges))
+	for _, edge := range edges {
+		forbidden[edge] = true
+	}
+
+	return func(schema fsm.Schema) []Issue {
+		var issues []Issue
+		for _, e := range schema.Events {
+			for _, src := range e.From {
+				if forbidden[Edge{From: src, To: e.To}] {
+					issues = append(issues, Issue{
+						Rule:    "forbidden-edge",
+						Event:   e.Name,
+						State:   src,
+						Message: fmt.Sprintf("event %q transitions from %q to %q", e.Name, src, e.To),
+					})
+				}
+			}
+		}
+		return issues
+	}
+}
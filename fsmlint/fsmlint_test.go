@@ -0,0 +1,83 @@
+package fsmlint
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ceearrashee/fsm"
+)
+
+const config = `
+state_pattern: "^[a-z_]+$"
+event_pattern: "^[a-z]+$"
+max_fan_out: 2
+require_terminal: true
+terminal_states: [delivered, archived]
+forbidden_edges:
+  - from: created
+    to: delivered
+`
+
+func TestLint(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(config), fsm.FormatYAML)
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+
+	l, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	schema := fsm.Schema{
+		Column: "State",
+		Events: []fsm.SchemaEvent{
+			{Name: "pay", From: []fsm.State{"created"}, To: "paid"},
+			{Name: "cancel", From: []fsm.State{"created", "paid"}, To: "Canceled"},
+			{Name: "skip", From: []fsm.State{"created"}, To: "delivered"},
+			{Name: "ship_it", From: []fsm.State{"paid"}, To: "delivered"},
+		},
+	}
+
+	var got []string
+	for _, issue := range l.Lint(schema) {
+		got = append(got, issue.String())
+	}
+
+	want := []string{
+		`state-naming: state "Canceled" does not match ^[a-z_]+$`,
+		`event-naming: event "ship_it" does not match ^[a-z]+$`,
+		`max-fan-out: state "created" has 3 outgoing events, at most 2 allowed`,
+		`terminal-states: terminal state "archived" is never reached`,
+		`forbidden-edge: event "skip" transitions from "created" to "delivered"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Lint() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintCustomRule(t *testing.T) {
+	noQuota := func(schema fsm.Schema) []Issue {
+		var issues []Issue
+		for _, e := range schema.Events {
+			if e.Quota == 0 {
+				issues = append(issues, Issue{Rule: "quota", Event: e.Name, Message: e.Name + " has no quota"})
+			}
+		}
+		return issues
+	}
+
+	l, err := New(Config{}, noQuota)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	issues := l.Lint(fsm.Schema{Column: "State", Events: []fsm.SchemaEvent{
+		{Name: "pay", From: []fsm.State{"created"}, To: "paid"},
+		{Name: "refund", From: []fsm.State{"paid"}, To: "refunded", Quota: 1},
+	}})
+	if len(issues) != 1 || issues[0].Event != "pay" {
+		t.Errorf("Lint() = %v, want one issue for pay", issues)
+	}
+}
@@ -0,0 +1,39 @@
+// Package fsmplugin loads fsm machine providers from Go plugins. It is kept
+// out of package fsm because importing package plugin links binaries
+// dynamically against libc.
+package fsmplugin
+
+import (
+	"fmt"
+	"plugin"
+
+	"github.com/ceearrashee/fsm"
+)
+
+// Load opens the Go plugin at path and registers its exported Provider
+// symbol, which must implement fsm.MachineProvider, with
+// fsm.RegisterProvider.
+func Load(path string) error {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return err
+	}
+
+	sym, err := p.Lookup("Provider")
+	if err != nil {
+		return err
+	}
+
+	// A plugin exports variables as pointers to them.
+	if ptr, ok := sym.(*fsm.MachineProvider); ok {
+		sym = *ptr
+	}
+
+	provider, ok := sym.(fsm.MachineProvider)
+	if !ok {
+		return fmt.Errorf("fsmplugin: plugin %s: Provider is %T, not a MachineProvider", path, sym)
+	}
+
+	fsm.RegisterProvider(provider)
+	return nil
+}
@@ -0,0 +1,56 @@
+package fsmtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ceearrashee/fsm"
+)
+
+// AssertTransition fires event on s and fails t unless it succeeds and
+// leaves s in want.
+func AssertTransition(t testing.TB, f *fsm.FSM, s interface{}, event string, want fsm.State, options ...fsm.Option) {
+	t.Helper()
+
+	if err := f.Fire(context.Background(), s, event, options...); err != nil {
+		t.Errorf("Fire(%s) error = %v", event, err)
+		return
+	}
+
+	got, err := f.CurrentState(s)
+	if err != nil {
+		t.Errorf("CurrentState() error = %v", err)
+		return
+	}
+	if got != want {
+		t.Errorf("Fire(%s) state = %v, want %v", event, got, want)
+	}
+}
+
+// AssertBlocked fires event on s and fails t unless it is rejected with an
+// fsm.InvalidTransitionError or fsm.UnknownEventError and s keeps its state.
+// It returns the error Fire returned.
+func AssertBlocked(t testing.TB, f *fsm.FSM, s interface{}, event string, options ...fsm.Option) error {
+	t.Helper()
+
+	before, err := f.CurrentState(s)
+	if err != nil {
+		t.Errorf("CurrentState() error = %v", err)
+		return err
+	}
+
+	err = f.Fire(context.Background(), s, event, options...)
+	switch {
+	case errors.As(err, new(fsm.InvalidTransitionError)), errors.As(err, new(fsm.UnknownEventError)):
+	case err == nil:
+		t.Errorf("Fire(%s) from %v succeeded, want it blocked", event, before)
+	default:
+		t.Errorf("Fire(%s) error = %v, want it blocked", event, err)
+	}
+
+	if after, _ := f.CurrentState(s); after != before {
+		t.Errorf("Fire(%s) moved a blocked instance from %v to %v", event, before, after)
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package fsmtest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ceearrashee/fsm"
+)
+
+// FakeClock is an fsm.TimerClock only moving when told to, for use with
+// fsm.WithClock in tests of time based behavior such as stuck detection,
+// retry backoffs, callback timeouts and scheduled fires.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	next    int
+	timers  map[int]timer
+	changed *sync.Cond
+}
+
+type timer struct {
+	id int
+	at time.Time
+	fn func()
+}
+
+var _ fsm.TimerClock = (*FakeClock)(nil)
+
+// NewFakeClock func to create FakeClock starting at now
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now, timers: make(map[int]timer)}
+	c.changed = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc calls fn once the clock moved d ahead, on the goroutine moving
+// it.
+func (c *FakeClock) AfterFunc(d time.Duration, fn func()) func() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if d <= 0 {
+		go fn()
+		return func() bool { return false }
+	}
+
+	id := c.next
+	c.next++
+	c.timers[id] = timer{id: id, at: c.now.Add(d), fn: fn}
+	c.changed.Broadcast()
+
+	return func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		_, pending := c.timers[id]
+		delete(c.timers, id)
+		return pending
+	}
+}
+
+// Advance moves the clock forward by d, running the timers due by then in
+// order before it returns.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.set(c.now.Add(d))
+}
+
+// Set moves the clock to now, running the timers due by then in order
+// before it returns.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	c.set(now)
+}
+
+// set moves the clock with c.mu held, unlocking it.
+func (c *FakeClock) set(now time.Time) {
+	c.now = now
+	var due []timer
+	for id, t := range c.timers {
+		if !t.at.After(now) {
+			due = append(due, t)
+			delete(c.timers, id)
+		}
+	}
+	c.changed.Broadcast()
+	c.mu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].at.Equal(due[j].at) {
+			return due[i].at.Before(due[j].at)
+		}
+		return due[i].id < due[j].id
+	})
+	for _, t := range due {
+		t.fn()
+	}
+}
+
+// BlockUntil waits until n timers are pending, e.g. until the code under
+// test started waiting before moving the clock past its deadline.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.changed.Wait()
+	}
+}
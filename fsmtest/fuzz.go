@@ -0,0 +1,175 @@
+package fsmtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ceearrashee/fsm"
+)
+
+// LockTimeout bounds how long FuzzFire and FuzzDefinition wait for a
+// sequence of events, failing the input as leaking a lock once exceeded.
+var LockTimeout = 5 * time.Second
+
+// unknownEvent is fired by the fuzzers next to the declared events.
+const unknownEvent = "fsmtest.unknown"
+
+// FuzzFire fuzzes event sequences fired on instances made by factory. Each
+// byte of an input selects one of the events of the default machine of tag,
+// or an undeclared one. Inputs fail if Fire panics, moves the instance to a
+// state the machine does not declare or does not return within LockTimeout.
+// Call it from a fuzz test:
+//
+//	func FuzzOrder(f *testing.F) {
+//		fsmtest.FuzzFire(f, machine, reflect.TypeOf((*Order)(nil)), newOrder)
+//	}
+func FuzzFire(f *testing.F, m *fsm.FSM, tag reflect.Type, factory func() interface{}) {
+	schema, err := m.Schema(tag)
+	if err != nil {
+		f.Fatalf("Schema() error = %v", err)
+	}
+
+	for i := range eventNames(schema) {
+		f.Add([]byte{byte(i)})
+	}
+
+	f.Fuzz(func(t *testing.T, seq []byte) {
+		s := factory()
+		start, err := m.CurrentState(s)
+		if err != nil {
+			t.Fatalf("CurrentState() error = %v", err)
+		}
+		checkSequence(t, m, schema, s, start, seq)
+	})
+}
+
+type fuzzEntity struct {
+	State fsm.State
+}
+
+// FuzzDefinition fuzzes JSON definitions and event sequences fired on
+// them. Definitions may reference the guards "pass", "reject" and "error"
+// and the callbacks "ok" and "error". A definition LoadDefinition accepts
+// is registered for a struct with a State column, an instance starting in
+// the first source state is created and the sequence is checked as by
+// FuzzFire.
+func FuzzDefinition(f *testing.F) {
+	f.Add([]byte(`{"column":"State","events":[{"name":"pay","from":["created"],"to":"paid","guards":["pass"]},{"name":"ship","from":["paid"],"to":"shipped","after":"error","on_error":"fail"},{"name":"fail","from":["shipped"],"to":"failed"}]}`), []byte{0, 1, 2, 3})
+	f.Add([]byte(`{"column":"State","states":["a","b"],"events":[{"name":"go","from":["a","b"],"to":"b","guards":["reject","error"],"before":"ok"}]}`), []byte{0, 0})
+
+	f.Fuzz(func(t *testing.T, def, seq []byte) {
+		m := fsm.NewFSM(fsm.WithRegistry(fuzzRegistry()))
+		tag := reflect.TypeOf((*fuzzEntity)(nil))
+		if err := m.LoadDefinition(tag, bytes.NewReader(def), fsm.FormatJSON); err != nil {
+			return
+		}
+
+		schema, err := m.Schema(tag)
+		if err != nil {
+			t.Fatalf("Schema() error = %v", err)
+		}
+
+		var start fsm.State
+		if len(schema.Events) > 0 {
+			start = schema.Events[0].From[0]
+		}
+		checkSequence(t, m, schema, &fuzzEntity{State: start}, start, seq)
+	})
+}
+
+func fuzzRegistry() *fsm.Registry {
+	errFuzz := errors.New("fsmtest: fuzz error")
+
+	r := fsm.NewRegistry()
+	_ = r.RegisterGuard("pass", func(ctx context.Context, e *fsm.Event) (bool, error) { return true, nil })
+	_ = r.RegisterGuard("reject", func(ctx context.Context, e *fsm.Event) (bool, error) { return false, nil })
+	_ = r.RegisterGuard("error", func(ctx context.Context, e *fsm.Event) (bool, error) { return false, errFuzz })
+	_ = r.RegisterCallback("ok", func(ctx context.Context, e *fsm.Event) error { return nil })
+	_ = r.RegisterCallback("error", func(ctx context.Context, e *fsm.Event) error { return errFuzz })
+	return r
+}
+
+// eventNames returns the events of schema in sorted order followed by an
+// undeclared one.
+func eventNames(schema fsm.Schema) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, e := range schema.Events {
+		if !seen[e.Name] {
+			seen[e.Name] = true
+			names = append(names, e.Name)
+		}
+	}
+	sort.Strings(names)
+	return append(names, unknownEvent)
+}
+
+// declaredStates returns the states of schema, those listed in States or
+// else every source and destination, and start.
+func declaredStates(schema fsm.Schema, start fsm.State) map[fsm.State]bool {
+	states := map[fsm.State]bool{start: true}
+	for _, state := range schema.States {
+		states[state] = true
+	}
+	if len(schema.States) == 0 {
+		for _, e := range schema.Events {
+			for _, src := range e.From {
+				states[src] = true
+			}
+			states[e.To] = true
+		}
+	}
+	return states
+}
+
+// checkSequence fires the events selected by seq on s and checks the
+// invariants documented on FuzzFire.
+func checkSequence(t *testing.T, m *fsm.FSM, schema fsm.Schema, s interface{}, start fsm.State, seq []byte) {
+	names := eventNames(schema)
+	declared := declaredStates(schema, start)
+	ctx := context.Background()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		var fired []string
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("panic firing %v: %v", fired, r)
+			}
+		}()
+
+		for _, b := range seq {
+			event := names[int(b)%len(names)]
+			fired = append(fired, event)
+			_ = m.Fire(ctx, s, event)
+
+			state, err := m.CurrentState(s)
+			if err != nil {
+				t.Errorf("CurrentState() after %v error = %v", fired, err)
+				return
+			}
+			if !declared[state] {
+				t.Errorf("state after %v = %v, not declared by the machine", fired, state)
+				return
+			}
+		}
+
+		// Snapshot takes the instance lock, so it blocks if Fire leaked it.
+		if _, err := m.Snapshot(ctx, s); err != nil {
+			t.Errorf("Snapshot() error = %v", err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(LockTimeout):
+		t.Fatalf("events %v did not complete within %v, a lock was not released", seq, LockTimeout)
+	}
+}
@@ -0,0 +1,34 @@
+package fsmtest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ceearrashee/fsm"
+)
+
+func FuzzOrder(f *testing.F) {
+	m := fsm.NewFSM()
+	tag := reflect.TypeOf((*Order)(nil))
+	if err := m.Register(tag, "State", fsm.Events{{
+		Name: "pay",
+		From: []fsm.State{"created"},
+		To:   fsm.State("paid"),
+	}, {
+		Name: "ship",
+		From: []fsm.State{"paid"},
+		To:   fsm.State("shipped"),
+	}, {
+		Name: "cancel",
+		From: []fsm.State{"created", "paid"},
+		To:   fsm.State("canceled"),
+	}}); err != nil {
+		f.Errorf("fsm.Register() error = %v", err)
+	}
+
+	FuzzFire(f, m, tag, func() interface{} { return &Order{State: "created"} })
+}
+
+func FuzzLoadedDefinition(f *testing.F) {
+	FuzzDefinition(f)
+}
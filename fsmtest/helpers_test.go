@@ -0,0 +1,165 @@
+package fsmtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ceearrashee/fsm"
+)
+
+type Order struct {
+	State fsm.State
+}
+
+func TestHelpers(t *testing.T) {
+	var rec Recorder
+	clock := NewFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+
+	f := fsm.NewFSM(fsm.WithClock(clock), fsm.WithHistory(10), fsm.WithIDFunc(func(s interface{}) string { return "order-1" }))
+	tag := reflect.TypeOf((*Order)(nil))
+	if err := f.Register(tag, "State", fsm.Events{{
+		Name:   "pay",
+		From:   []fsm.State{"created"},
+		To:     fsm.State("paid"),
+		Before: rec.Callback("before pay"),
+		After:  rec.Callback("after pay"),
+	}, {
+		Name: "ship",
+		From: []fsm.State{"paid"},
+		To:   fsm.State("shipped"),
+		Guards: []fsm.Guard{func(ctx context.Context, e *fsm.Event) (bool, error) {
+			return e.Reason == "stocked", nil
+		}},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := f.OnEnter(tag, "paid", rec.Callback("enter paid")); err != nil {
+		t.Errorf("OnEnter() error = %v", err)
+	}
+
+	order := &Order{State: "created"}
+	AssertBlocked(t, f, order, "ship")
+
+	clock.Advance(time.Hour)
+	AssertTransition(t, f, order, "pay", "paid")
+	AssertBlocked(t, f, order, "ship")
+	AssertTransition(t, f, order, "ship", "shipped", fsm.WithReason("stocked"))
+
+	if want := []string{"before pay", "enter paid", "after pay"}; !slices.Equal(rec.Names(), want) {
+		t.Errorf("Names() = %v, want %v", rec.Names(), want)
+	}
+
+	history, err := f.History(context.Background(), order)
+	if err != nil || len(history) == 0 || !history[len(history)-1].Time.Equal(clock.Now()) {
+		t.Errorf("History() = %v, %v, want entries at %v", history, err, clock.Now())
+	}
+}
+
+func TestFakeClockTimers(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+
+	attempts := 0
+	f := fsm.NewFSM(fsm.WithClock(clock), fsm.WithIDFunc(func(s interface{}) string { return "order-1" }))
+	tag := reflect.TypeOf((*Order)(nil))
+	if err := f.Register(tag, "State", fsm.Events{{
+		Name: "charge",
+		From: []fsm.State{"created"},
+		To:   fsm.State("charged"),
+		Retry: fsm.RetryPolicy{MaxAttempts: 2, Backoff: func(n int) time.Duration {
+			return time.Minute
+		}},
+		Before: func(ctx context.Context, e *fsm.Event) error {
+			if attempts++; attempts == 1 {
+				return errors.New("gateway unavailable")
+			}
+			return nil
+		},
+	}, {
+		Name:            "ship",
+		From:            []fsm.State{"charged"},
+		To:              fsm.State("shipped"),
+		CallbackTimeout: time.Hour,
+		Before: func(ctx context.Context, e *fsm.Event) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}, {
+		Name: "cancel",
+		From: []fsm.State{"charged"},
+		To:   fsm.State("canceled"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	order := &Order{State: "created"}
+	fire := func(event string) <-chan error {
+		done := make(chan error, 1)
+		go func() { done <- f.Fire(ctx, order, event) }()
+		return done
+	}
+
+	done := fire("charge")
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	if err := <-done; err != nil || attempts != 2 {
+		t.Errorf("Fire() after backoff error = %v, attempts = %d", err, attempts)
+	}
+
+	done = fire("ship")
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+	if err := <-done; !errors.As(err, new(fsm.CallbackTimeoutError)) {
+		t.Errorf("Fire() error = %v, want CallbackTimeoutError", err)
+	}
+
+	if _, err := f.FireAfter(ctx, order, "cancel", 24*time.Hour); err != nil {
+		t.Errorf("FireAfter() error = %v", err)
+	}
+	clock.Advance(24 * time.Hour)
+	if report, err := f.FireDue(ctx); err != nil || report.Fired != 1 || order.State != "canceled" {
+		t.Errorf("FireDue() = %+v, %v, State = %v", report, err, order.State)
+	}
+}
+
+func TestAssertBlockedWrapped(t *testing.T) {
+	wrap := func(next fsm.FireFunc) fsm.FireFunc {
+		return func(ctx context.Context, s interface{}, event string, options ...fsm.Option) (fsm.FireResult, error) {
+			result, err := next(ctx, s, event, options...)
+			if err != nil {
+				err = fmt.Errorf("orders: %w", err)
+			}
+			return result, err
+		}
+	}
+	f := fsm.NewFSM(fsm.WithMiddleware(wrap))
+	if err := f.Register(reflect.TypeOf((*Order)(nil)), "State", fsm.Events{{
+		Name: "ship",
+		From: []fsm.State{"paid"},
+		To:   fsm.State("shipped"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	if err := AssertBlocked(t, f, &Order{State: "created"}, "ship"); !errors.As(err, new(fsm.UnknownEventError)) {
+		t.Errorf("AssertBlocked() = %v, want a wrapped UnknownEventError", err)
+	}
+}
+
+func TestFakeClockOrder(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	var fired []string
+	clock.AfterFunc(2*time.Minute, func() { fired = append(fired, "late") })
+	clock.AfterFunc(time.Minute, func() { fired = append(fired, "early") })
+	clock.AfterFunc(time.Minute, func() { fired = append(fired, "early again") })
+
+	clock.Advance(time.Hour)
+	if want := []string{"early", "early again", "late"}; !slices.Equal(fired, want) {
+		t.Errorf("Advance() fired %v, want %v", fired, want)
+	}
+}
@@ -0,0 +1,84 @@
+// Package fsmtest provides test helpers for code built on package fsm.
+package fsmtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ceearrashee/fsm"
+)
+
+// Call is a method call recorded by MockMachine.
+type Call struct {
+	Method string
+	Source interface{}
+	Event  string
+}
+
+// MockMachine is an fsm.Machine whose behavior is set through its func
+// fields. Methods with a nil func succeed with zero values. Every call is
+// recorded and available from Calls.
+type MockMachine struct {
+	FireFunc               func(ctx context.Context, s interface{}, event string, options ...fsm.Option) error
+	MayFireFunc            func(ctx context.Context, s interface{}, event string, options ...fsm.Option) (bool, error)
+	GetPermittedEventsFunc func(ctx context.Context, s interface{}, options ...fsm.Option) ([]string, error)
+	GetPermittedStatesFunc func(ctx context.Context, s interface{}, options ...fsm.Option) ([]fsm.State, error)
+	ReleaseFunc            func(s interface{})
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ fsm.Machine = (*MockMachine)(nil)
+
+func (m *MockMachine) record(method string, s interface{}, event string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Source: s, Event: event})
+}
+
+// Calls returns the recorded calls in order.
+func (m *MockMachine) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+func (m *MockMachine) Fire(ctx context.Context, s interface{}, event string, options ...fsm.Option) error {
+	m.record("Fire", s, event)
+	if m.FireFunc == nil {
+		return nil
+	}
+	return m.FireFunc(ctx, s, event, options...)
+}
+
+func (m *MockMachine) MayFire(ctx context.Context, s interface{}, event string, options ...fsm.Option) (bool, error) {
+	m.record("MayFire", s, event)
+	if m.MayFireFunc == nil {
+		return false, nil
+	}
+	return m.MayFireFunc(ctx, s, event, options...)
+}
+
+func (m *MockMachine) GetPermittedEvents(ctx context.Context, s interface{}, options ...fsm.Option) ([]string, error) {
+	m.record("GetPermittedEvents", s, "")
+	if m.GetPermittedEventsFunc == nil {
+		return []string{}, nil
+	}
+	return m.GetPermittedEventsFunc(ctx, s, options...)
+}
+
+func (m *MockMachine) GetPermittedStates(ctx context.Context, s interface{}, options ...fsm.Option) ([]fsm.State, error) {
+	m.record("GetPermittedStates", s, "")
+	if m.GetPermittedStatesFunc == nil {
+		return []fsm.State{}, nil
+	}
+	return m.GetPermittedStatesFunc(ctx, s, options...)
+}
+
+func (m *MockMachine) Release(s interface{}) {
+	m.record("Release", s, "")
+	if m.ReleaseFunc != nil {
+		m.ReleaseFunc(s)
+	}
+}
@@ -0,0 +1,35 @@
+package fsmtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ceearrashee/fsm"
+)
+
+func TestMockMachine(t *testing.T) {
+	errBlocked := errors.New("blocked")
+
+	var m fsm.Machine = &MockMachine{
+		FireFunc: func(ctx context.Context, s interface{}, event string, options ...fsm.Option) error {
+			if event == "ship" {
+				return errBlocked
+			}
+			return nil
+		},
+	}
+
+	obj := struct{}{}
+	if err := m.Fire(context.Background(), &obj, "pay"); err != nil {
+		t.Errorf("Fire(pay) error = %v", err)
+	}
+	if err := m.Fire(context.Background(), &obj, "ship"); err != errBlocked {
+		t.Errorf("Fire(ship) error = %v, want %v", err, errBlocked)
+	}
+
+	calls := m.(*MockMachine).Calls()
+	if len(calls) != 2 || calls[1].Method != "Fire" || calls[1].Event != "ship" {
+		t.Errorf("unexpected calls %+v", calls)
+	}
+}
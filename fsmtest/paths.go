@@ -0,0 +1,148 @@
+package fsmtest
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ceearrashee/fsm"
+)
+
+// Path is a sequence of transitions through a machine, usable as a table
+// driven test case.
+type Path struct {
+	// Name joins the events of the path, e.g. "pay>ship". Events with
+	// several destinations from a state are followed by the one taken, e.g.
+	// "pay>ship(backordered)".
+	Name  string
+	Start fsm.State
+	Steps []fsm.Transition
+}
+
+// End returns the state the path ends in.
+func (p Path) End() fsm.State {
+	if len(p.Steps) == 0 {
+		return p.Start
+	}
+	return p.Steps[len(p.Steps)-1].To
+}
+
+// Paths enumerates the paths through schema starting at start, leaving out
+// drafts. With maxDepth zero or less a path ends at a state without
+// transitions or with the first transition returning to a state it already
+// visited, so every reachable transition is covered by a finite set of
+// paths. Otherwise paths may revisit states and end after maxDepth steps.
+// Paths are ordered by the declaration order of their events.
+func Paths(schema fsm.Schema, start fsm.State, maxDepth int) []Path {
+	from := make(map[fsm.State][]fsm.Transition)
+	branches := make(map[fsm.Transition]int)
+	for _, e := range schema.Events {
+		if e.Draft {
+			continue
+		}
+		for _, src := range e.From {
+			from[src] = append(from[src], fsm.Transition{Event: e.Name, From: src, To: e.To})
+			branches[fsm.Transition{Event: e.Name, From: src}]++
+		}
+	}
+
+	newPath := func(steps []fsm.Transition) Path {
+		names := make([]string, len(steps))
+		for i, t := range steps {
+			names[i] = t.Event
+			if branches[fsm.Transition{Event: t.Event, From: t.From}] > 1 {
+				names[i] += "(" + string(t.To) + ")"
+			}
+		}
+		return Path{Name: strings.Join(names, ">"), Start: start, Steps: append([]fsm.Transition(nil), steps...)}
+	}
+
+	var paths []Path
+	visited := map[fsm.State]bool{start: true}
+	var steps []fsm.Transition
+
+	var walk func(state fsm.State)
+	walk = func(state fsm.State) {
+		next := from[state]
+		if len(next) == 0 || (maxDepth > 0 && len(steps) == maxDepth) {
+			paths = append(paths, newPath(steps))
+			return
+		}
+
+		for _, t := range next {
+			steps = append(steps, t)
+			if maxDepth <= 0 && visited[t.To] {
+				paths = append(paths, newPath(steps))
+			} else {
+				seen := visited[t.To]
+				visited[t.To] = true
+				walk(t.To)
+				visited[t.To] = seen
+			}
+			steps = steps[:len(steps)-1]
+		}
+	}
+	walk(start)
+
+	return paths
+}
+
+type branchKey struct{}
+
+// RunPaths runs a subtest per path of Paths through the default machine of
+// tag, starting at the state of the instances made by factory. Each subtest
+// fires the events of its path on a new instance and checks the state after
+// every step.
+//
+// Paths are run against a copy of the machine whose guards are replaced by
+// one passing exactly the transition the path takes, so guarded branches
+// are covered without setting up the data they check. Before and After
+// callbacks run as declared; hooks and options given to f outside the
+// transitions, e.g. OnEnter, are not copied.
+func RunPaths(t *testing.T, f *fsm.FSM, tag reflect.Type, factory func() interface{}, maxDepth int) {
+	t.Helper()
+
+	schema, err := f.Schema(tag)
+	if err != nil {
+		t.Fatalf("Schema() error = %v", err)
+	}
+	events, err := f.Events(tag)
+	if err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+
+	stub := func(ctx context.Context, e *fsm.Event) (bool, error) {
+		want, ok := ctx.Value(branchKey{}).(fsm.State)
+		return !ok || e.Destination == want, nil
+	}
+	for i := range events {
+		events[i].Guards = []fsm.Guard{stub}
+		events[i].GuardNames = nil
+	}
+
+	stubbed := fsm.NewFSM()
+	if err := stubbed.Register(tag, schema.Column, events); err != nil {
+		t.Fatalf("fsm.Register() error = %v", err)
+	}
+
+	start, err := stubbed.CurrentState(factory())
+	if err != nil {
+		t.Fatalf("CurrentState() error = %v", err)
+	}
+
+	for _, path := range Paths(schema, start, maxDepth) {
+		t.Run(path.Name, func(t *testing.T) {
+			s := factory()
+			for _, step := range path.Steps {
+				ctx := context.WithValue(context.Background(), branchKey{}, step.To)
+				if err := stubbed.Fire(ctx, s, step.Event); err != nil {
+					t.Fatalf("Fire(%s) from %v error = %v", step.Event, step.From, err)
+				}
+				if got, _ := stubbed.CurrentState(s); got != step.To {
+					t.Fatalf("Fire(%s) from %v state = %v, want %v", step.Event, step.From, got, step.To)
+				}
+			}
+		})
+	}
+}
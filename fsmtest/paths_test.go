@@ -0,0 +1,56 @@
+package fsmtest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ceearrashee/fsm"
+)
+
+var reviewEvents = fsm.Schema{Column: "State", Events: []fsm.SchemaEvent{
+	{Name: "submit", From: []fsm.State{"draft"}, To: "review"},
+	{Name: "approve", From: []fsm.State{"review"}, To: "approved"},
+	{Name: "reject", From: []fsm.State{"review"}, To: "draft"},
+}}
+
+func TestPaths(t *testing.T) {
+	var names []string
+	for _, p := range Paths(reviewEvents, "draft", 0) {
+		names = append(names, p.Name)
+	}
+	if want := []string{"submit>approve", "submit>reject"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Paths() = %v, want %v", names, want)
+	}
+
+	paths := Paths(reviewEvents, "draft", 3)
+	if len(paths) != 2 || paths[1].Name != "submit>reject>submit" || paths[1].End() != "review" {
+		t.Errorf("Paths() with depth 3 = %+v", paths)
+	}
+}
+
+func TestRunPaths(t *testing.T) {
+	f := fsm.NewFSM()
+	tag := reflect.TypeOf((*Order)(nil))
+	never := func(ctx context.Context, e *fsm.Event) (bool, error) { return false, nil }
+	if err := f.Register(tag, "State", fsm.Events{{
+		Name: "pay",
+		From: []fsm.State{"created"},
+		To:   fsm.State("paid"),
+	}, {
+		Name:     "ship",
+		From:     []fsm.State{"paid"},
+		To:       fsm.State("shipped"),
+		Priority: 1,
+		Guards:   []fsm.Guard{never},
+	}, {
+		Name:   "ship",
+		From:   []fsm.State{"paid"},
+		To:     fsm.State("backordered"),
+		Guards: []fsm.Guard{never},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	RunPaths(t, f, tag, func() interface{} { return &Order{State: "created"} }, 0)
+}
@@ -0,0 +1,70 @@
+package fsmtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ceearrashee/fsm"
+)
+
+// Invocation is a callback call captured by Recorder.
+type Invocation struct {
+	// Callback is the name the callback was wrapped under.
+	Callback    string
+	Event       string
+	Source      interface{}
+	Destination fsm.State
+}
+
+// Recorder captures the callbacks it wraps as they are invoked, e.g. to
+// assert the order Before, OnEnter and After hooks ran in.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Invocation
+}
+
+// Callback returns a callback recording its calls under name.
+func (r *Recorder) Callback(name string) fsm.Callback {
+	return r.Wrap(name, nil)
+}
+
+// Wrap returns a callback recording its calls under name before passing
+// them to fn, if not nil.
+func (r *Recorder) Wrap(name string, fn fsm.Callback) fsm.Callback {
+	return func(ctx context.Context, e *fsm.Event) error {
+		r.mu.Lock()
+		r.calls = append(r.calls, Invocation{Callback: name, Event: e.Event, Source: e.Source, Destination: e.Destination})
+		r.mu.Unlock()
+
+		if fn == nil {
+			return nil
+		}
+		return fn(ctx, e)
+	}
+}
+
+// Calls returns the recorded invocations in order.
+func (r *Recorder) Calls() []Invocation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Invocation(nil), r.calls...)
+}
+
+// Names returns the names of the recorded invocations in order.
+func (r *Recorder) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, len(r.calls))
+	for i, call := range r.calls {
+		names[i] = call.Callback
+	}
+	return names
+}
+
+// Reset forgets the recorded invocations.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
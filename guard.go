@@ -0,0 +1,252 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"sort"
+)
+
+// namedGuard is a guard together with the name it is reported under.
+type namedGuard struct {
+	name string
+	fn   Guard
+	cost GuardCost
+	// approval is set for guards made by RequireApprovals.
+	approval bool
+}
+
+// GuardCost hints how expensive a guard is to evaluate. Guards of a
+// transition run in ascending cost, so cheap local checks reject before
+// remote calls are made; guards of equal cost keep their declared order.
+// Any value may be used to impose an explicit order.
+type GuardCost int
+
+const (
+	CostCheap     GuardCost = -1
+	CostDefault   GuardCost = 0
+	CostExpensive GuardCost = 1
+)
+
+// And returns a guard passing only if all guards pass. Evaluation stops at
+// the first rejection or error.
+func And(guards ...Guard) Guard {
+	return func(ctx context.Context, e *Event) (bool, error) {
+		for _, g := range guards {
+			if ok, err := g(ctx, e); err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
+
+// Or returns a guard passing if any of guards passes. Evaluation stops at
+// the first pass or error.
+func Or(guards ...Guard) Guard {
+	return func(ctx context.Context, e *Event) (bool, error) {
+		for _, g := range guards {
+			if ok, err := g(ctx, e); err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+}
+
+// Not returns a guard passing if g rejects. Errors are passed through.
+func Not(g Guard) Guard {
+	return func(ctx context.Context, e *Event) (bool, error) {
+		ok, err := g(ctx, e)
+		if err != nil {
+			return false, err
+		}
+		return !ok, nil
+	}
+}
+
+// GuardName returns the fully qualified function name of g. Guards are
+// reported under this name by AssumeGuard and BlockingGuards unless they
+// were referenced through EventTransition.GuardNames or are registered in
+// the Registry of the FSM.
+func GuardName(g Guard) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(g).Pointer())
+	if fn == nil {
+		return ""
+	}
+	return fn.Name()
+}
+
+// namedGuards resolves the guards of e, looking up GuardNames in registry,
+// and orders them by GuardCosts.
+func namedGuards(registry *Registry, e EventTransition) ([]namedGuard, error) {
+	guards := make([]namedGuard, 0, len(e.Guards)+len(e.GuardNames))
+
+	for _, g := range e.Guards {
+		name, ok := "", false
+		if registry != nil {
+			name, ok = registry.guardName(g)
+		}
+		if !ok {
+			name = GuardName(g)
+		}
+		guards = append(guards, namedGuard{name: name, fn: g, approval: isApprovalGuard(g)})
+	}
+
+	for _, name := range e.GuardNames {
+		var g Guard
+		ok := false
+		if registry != nil {
+			g, ok = registry.Guard(name)
+		}
+		if !ok {
+			return nil, UnknownGuardError{Event: e.Name, Guard: name}
+		}
+		guards = append(guards, namedGuard{name: name, fn: g, approval: isApprovalGuard(g)})
+	}
+
+	for i := range guards {
+		guards[i].cost = e.GuardCosts[guards[i].name]
+	}
+	sort.SliceStable(guards, func(i, j int) bool {
+		return guards[i].cost < guards[j].cost
+	})
+
+	return guards, nil
+}
+
+// GuardOrder func to return the names of the guards of event of the default
+// machine of tag in the order they are evaluated
+func (f *FSM) GuardOrder(tag reflect.Type, event string) ([]string, error) {
+	machine, err := f.defaultMachine(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return machine.guardOrder(event), nil
+}
+
+func (f *fsm) guardOrder(event string) []string {
+	t := f.table()
+	names := make([]string, 0, len(t.guards[event]))
+	for _, g := range t.guards[event] {
+		names = append(names, g.name)
+	}
+	return names
+}
+
+// evalGuard runs g of br unless args assume its outcome, applying the
+// fallback policy of br if g reports its dependencies are unavailable.
+func (f *fsm) evalGuard(ctx context.Context, br *branch, g namedGuard, e *Event, args *Options) (bool, error) {
+	if args != nil {
+		if pass, ok := args.Assumptions[g.name]; ok {
+			return pass, nil
+		}
+	}
+
+	var ok bool
+	err := f.retry(ctx, br, e, func() error {
+		return f.bounded(ctx, br, e.Event, g.name, func(ctx context.Context) (err error) {
+			ok, err = g.fn(ctx, e)
+			return err
+		})
+	})
+
+	policy := br.unavailable
+	if policy.Mode == FallbackNone {
+		return ok, err
+	}
+
+	if err == nil {
+		if policy.Mode == FallbackCached {
+			f.guardCache.store(f.guardCacheKey(g, e), cachedGuard{passed: ok, at: f.parent.now()}, f.parent.guardCacheSize)
+		}
+		return ok, nil
+	}
+
+	if !f.parent.unavailable(err) {
+		return ok, err
+	}
+
+	switch policy.Mode {
+	case FallbackAllow:
+		return true, nil
+	case FallbackCached:
+		if cached, found := f.guardCache.load(f.guardCacheKey(g, e), policy.MaxAge, f.parent.now()); found {
+			return cached.passed, nil
+		}
+		return false, nil
+	default:
+		return false, nil
+	}
+}
+
+// GuardResult is the outcome of a single guard, see MayFireDetailed.
+type GuardResult struct {
+	Name   string
+	Passed bool
+	// Err is the error returned by the guard, if any.
+	Err error
+}
+
+// MayFireDetailed evaluates every guard of event on s, without stopping at
+// the first rejection, and returns their results in evaluation order. It
+// returns UnknownEventError if event can't be fired from the current state.
+func (f *fsm) MayFireDetailed(ctx context.Context, s interface{}, event string, options ...Option) ([]GuardResult, error) {
+	t := f.table()
+	args := &Options{}
+	for _, option := range options {
+		option(args)
+	}
+
+	_, state, err := f.getSourceState(s)
+	if err != nil {
+		return nil, err
+	}
+
+	destination, ok := f.destination(eventKey{event, state}, args)
+	if !ok {
+		return nil, UnknownEventError{event}
+	}
+
+	vars, err := f.loadVars(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Event{Event: event, Source: s, From: state, Destination: destination, Vars: vars, Args: args.Args, DryRun: true}
+
+	results := []GuardResult{}
+	for _, br := range t.branches[eventKey{event, state}] {
+		if br.draft && !args.includeDraft() {
+			continue
+		}
+		e.Destination = br.to
+		for _, g := range br.guards {
+			ok, err := f.evalGuard(ctx, br, g, e, args)
+			results = append(results, GuardResult{Name: g.name, Passed: ok && err == nil, Err: err})
+		}
+	}
+
+	return results, nil
+}
+
+// BlockingGuards returns the names of the guards that currently prevent
+// event from being fired on s, including guards returning an error. Guards
+// are evaluated without side effects on s, so AssumeGuard can be used to
+// explore what would unblock the instance.
+func (f *fsm) BlockingGuards(ctx context.Context, s interface{}, event string, options ...Option) ([]string, error) {
+	results, err := f.MayFireDetailed(ctx, s, event, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	blocking := []string{}
+	for _, r := range results {
+		if !r.Passed {
+			blocking = append(blocking, r.Name)
+		}
+	}
+
+	return blocking, nil
+}
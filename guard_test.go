@@ -0,0 +1,142 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWhatIfGuards(t *testing.T) {
+	testStruct := &TestStruct{
+		State: State("started"),
+	}
+
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:   "make",
+		From:   []State{"started"},
+		To:     State("finished"),
+		Guards: []Guard{IsTestStructValid, IsTestStructInvalid},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	blocking, err := fsm.BlockingGuards(context.Background(), testStruct, "make")
+	if err != nil {
+		t.Errorf("fsm.BlockingGuards() error = %v", err)
+	}
+
+	name := GuardName(IsTestStructInvalid)
+	if len(blocking) != 1 || blocking[0] != name {
+		t.Errorf("expected blocking guards to be [%s], got %v", name, blocking)
+	}
+
+	permittedEvents, err := fsm.GetPermittedEvents(context.Background(), testStruct, AssumeGuard(name, true))
+	if err != nil {
+		t.Errorf("fsm.GetPermittedEvents() error = %v", err)
+	}
+
+	if len(permittedEvents) != 1 {
+		t.Error("expected permitted events to be ['make'] when guard is assumed to pass")
+	}
+
+	if testStruct.State != State("started") {
+		t.Error("expected state to stay 'started'")
+	}
+}
+
+func TestGuardCombinators(t *testing.T) {
+	ctx := context.Background()
+	e := &Event{}
+
+	cases := []struct {
+		name  string
+		guard Guard
+		want  bool
+	}{
+		{"and", And(IsTestStructValid, IsTestStructInvalid), false},
+		{"or", Or(IsTestStructInvalid, IsTestStructValid), true},
+		{"not", Not(IsTestStructInvalid), true},
+	}
+
+	for _, c := range cases {
+		if ok, err := c.guard(ctx, e); err != nil || ok != c.want {
+			t.Errorf("%s: got (%v, %v), want %v", c.name, ok, err, c.want)
+		}
+	}
+}
+
+func TestNamedGuards(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.RegisterGuard("kyc_passed", IsTestStructInvalid); err != nil {
+		t.Errorf("registry.RegisterGuard() error = %v", err)
+	}
+	if err := registry.RegisterGuard("kyc_passed", IsTestStructValid); err == nil {
+		t.Error("expected duplicate guard name to fail")
+	}
+
+	fsm := NewFSM(WithRegistry(registry))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:       "make",
+		From:       []State{"started"},
+		To:         State("finished"),
+		GuardNames: []string{"kyc_passed"},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	err := fsm.Fire(context.Background(), &TestStruct{State: State("started")}, "make")
+	if e, ok := err.(InvalidTransitionError); !ok || e.Guard != "kyc_passed" {
+		t.Errorf("expected 'InvalidTransitionError' naming kyc_passed, got %v", err)
+	}
+
+	if err := NewFSM().Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:       "make",
+		From:       []State{"started"},
+		To:         State("finished"),
+		GuardNames: []string{"kyc_passed"},
+	}}); err == nil {
+		t.Error("expected unknown guard name to fail registration")
+	}
+}
+
+func TestDetailedGuardRejection(t *testing.T) {
+	errNotCaptured := errors.New("payment not captured")
+	paymentCaptured := func(ctx context.Context, e *Event) (bool, error) {
+		return false, errNotCaptured
+	}
+
+	registry := NewRegistry()
+	if err := registry.RegisterGuard("payment_captured", paymentCaptured); err != nil {
+		t.Errorf("registry.RegisterGuard() error = %v", err)
+	}
+
+	fsm := NewFSM(WithRegistry(registry))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:       "ship",
+		From:       []State{"started"},
+		To:         State("shipped"),
+		Guards:     []Guard{IsTestStructValid},
+		GuardNames: []string{"payment_captured"},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("started")}
+
+	err := fsm.Fire(context.Background(), testStruct, "ship")
+	var invalid InvalidTransitionError
+	if !errors.As(err, &invalid) || invalid.Guard != "payment_captured" || !errors.Is(err, errNotCaptured) {
+		t.Errorf("expected 'InvalidTransitionError' wrapping the guard error, got %v", err)
+	}
+
+	results, err := fsm.MayFireDetailed(context.Background(), testStruct, "ship")
+	if err != nil {
+		t.Errorf("fsm.MayFireDetailed() error = %v", err)
+	}
+
+	if len(results) != 2 || !results[0].Passed || results[1].Passed || results[1].Err != errNotCaptured {
+		t.Errorf("unexpected guard results %+v", results)
+	}
+}
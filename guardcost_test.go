@@ -0,0 +1,63 @@
+package fsm
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGuardCostOrder(t *testing.T) {
+	var order []string
+	guard := func(name string) Guard {
+		return func(ctx context.Context, e *Event) (bool, error) {
+			order = append(order, name)
+			return true, nil
+		}
+	}
+
+	registry := NewRegistry()
+	for _, name := range []string{"remoteCredit", "hasItems", "inStock"} {
+		if err := registry.RegisterGuard(name, guard(name)); err != nil {
+			t.Errorf("RegisterGuard() error = %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	fsm := NewFSM(WithRegistry(registry), WithLogger(logger))
+	if err := fsm.Register(tag, "State", Events{{
+		Name:       "make",
+		From:       []State{"started"},
+		To:         State("finished"),
+		GuardNames: []string{"remoteCredit", "hasItems", "inStock"},
+		GuardCosts: map[string]GuardCost{"remoteCredit": CostExpensive, "hasItems": CostCheap},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	want := []string{"hasItems", "inStock", "remoteCredit"}
+
+	got, err := fsm.GuardOrder(tag, "make")
+	if err != nil {
+		t.Errorf("GuardOrder() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GuardOrder() = %v, want %v", got, want)
+	}
+
+	if err := fsm.Fire(context.Background(), &TestStruct{State: State("started")}, "make"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("evaluated %v, want %v", order, want)
+	}
+
+	if !strings.Contains(buf.String(), "guards=\"[hasItems inStock remoteCredit]\"") {
+		t.Errorf("attempt log %q does not list the guard order", buf.String())
+	}
+}
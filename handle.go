@@ -0,0 +1,97 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+)
+
+// Handle binds an instance to the machine Fire uses for it, see FSM.For.
+type Handle struct {
+	f      *FSM
+	s      interface{}
+	fire   FireFunc
+	cached atomic.Pointer[handleMachine]
+}
+
+// handleMachine is the machine a Handle resolved at generation.
+type handleMachine struct {
+	generation uint64
+	machine    *fsm
+}
+
+// For func to return a handle on s, e.g. to fire many events on the same
+// instance in a loop. The handle resolves the machine of s once and again
+// only after Register, RegisterVersion or Unregister changed the machines.
+// Versioned types are resolved on every call. A Handle is safe for
+// concurrent use
+func (f *FSM) For(s interface{}) *Handle {
+	h := &Handle{f: f, s: s}
+	h.fire = f.chain(h.fireMachine)
+	return h
+}
+
+// machine returns the machine of the instance, resolving it if the cached
+// one is stale.
+func (h *Handle) machine(options []Option) (*fsm, bool) {
+	generation := h.f.generation.Load()
+	if c := h.cached.Load(); c != nil && c.generation == generation {
+		return c.machine, true
+	}
+
+	machine, ok := h.f.machine(h.s, options...)
+	if ok && h.f.versionSet(reflect.TypeOf(h.s)) == nil {
+		h.cached.Store(&handleMachine{generation: generation, machine: machine})
+	}
+	return machine, ok
+}
+
+// fireMachine fires event on the machine of the instance, below middleware.
+func (h *Handle) fireMachine(ctx context.Context, s interface{}, event string, options ...Option) (FireResult, error) {
+	machine, ok := h.machine(options)
+	if !ok {
+		return Failed, InternalError{}
+	}
+
+	return machine.FireE(ctx, s, event, options...)
+}
+
+// Fire func to fire event on the instance, see FSM.Fire
+func (h *Handle) Fire(ctx context.Context, event string, options ...Option) error {
+	_, err := h.fire(ctx, h.s, event, options...)
+	return err
+}
+
+// Can func return false if event can`t may fire on the instance, see
+// FSM.MayFire
+func (h *Handle) Can(ctx context.Context, event string, options ...Option) (bool, error) {
+	machine, ok := h.machine(options)
+	if !ok {
+		return false, InternalError{}
+	}
+
+	return machine.MayFire(ctx, h.s, event, options...)
+}
+
+// State func to return the current state of the instance, see
+// FSM.CurrentState
+func (h *Handle) State() (State, error) {
+	machine, ok := h.machine(nil)
+	if !ok {
+		return "", InternalError{}
+	}
+
+	_, state, err := machine.getSourceState(h.s)
+	return state, err
+}
+
+// Permitted func to return all events permitted on the instance, see
+// FSM.GetPermittedEvents
+func (h *Handle) Permitted(ctx context.Context, options ...Option) ([]string, error) {
+	machine, ok := h.machine(options)
+	if !ok {
+		return nil, InternalError{}
+	}
+
+	return machine.GetPermittedEvents(ctx, h.s, options...)
+}
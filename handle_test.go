@@ -0,0 +1,72 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestHandle(t *testing.T) {
+	var fired []string
+	fsm := NewFSM(WithMiddleware(func(next FireFunc) FireFunc {
+		return func(ctx context.Context, s interface{}, event string, options ...Option) (FireResult, error) {
+			fired = append(fired, event)
+			return next(ctx, s, event, options...)
+		}
+	}))
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "pay",
+		From: []State{"created"},
+		To:   State("paid"),
+	}, {
+		Name: "cancel",
+		From: []State{"created", "paid"},
+		To:   State("cancelled"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	order := &TestStruct{State: "created"}
+	h := fsm.For(order)
+	if events, err := h.Permitted(ctx); err != nil || !slices.Equal(events, []string{"pay", "cancel"}) {
+		t.Errorf("Permitted() = %v, %v", events, err)
+	}
+	if ok, err := h.Can(ctx, "pay"); !ok || err != nil {
+		t.Errorf("Can() = %v, %v", ok, err)
+	}
+	if err := h.Fire(ctx, "pay"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if state, err := h.State(); state != "paid" || err != nil {
+		t.Errorf("State() = %q, %v", state, err)
+	}
+	if !slices.Equal(fired, []string{"pay"}) {
+		t.Errorf("middleware saw %v", fired)
+	}
+
+	// Registering the column again replaces the machine of the handle.
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "refund",
+		From: []State{"paid"},
+		To:   State("refunded"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if ok, _ := h.Can(ctx, "cancel"); ok {
+		t.Error("Can() = true for an event of the replaced machine")
+	}
+	if err := h.Fire(ctx, "refund"); err != nil || order.State != "refunded" {
+		t.Errorf("Fire() = %v, state %q", err, order.State)
+	}
+
+	if err := fsm.Unregister(tag); err != nil {
+		t.Errorf("Unregister() error = %v", err)
+	}
+	if _, err := h.State(); !errors.As(err, new(InternalError)) {
+		t.Errorf("State() error = %v, want InternalError", err)
+	}
+}
@@ -0,0 +1,174 @@
+package fsm
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// HistoryEntry records a single Fire call on an instance.
+type HistoryEntry struct {
+	Type  string    `json:"type"`
+	Event string    `json:"event"`
+	From  State     `json:"from"`
+	To    State     `json:"to,omitempty"`
+	Time  time.Time `json:"time"`
+	Error string    `json:"error,omitempty"`
+	// Final is set if the transition succeeded into a final state, see
+	// FSM.MarkFinal.
+	Final bool `json:"final,omitempty"`
+	// Tenant is the tenant the instance belongs to, see WithTenant.
+	Tenant string `json:"tenant,omitempty"`
+	// Instance identifies the instance within the process.
+	Instance string `json:"instance,omitempty"`
+	// Reason and Meta are those given with WithReason and WithMeta.
+	Reason string                 `json:"reason,omitempty"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+}
+
+// AuditSink receives every history entry as it is recorded, e.g. to stream
+// it to an external audit log.
+type AuditSink interface {
+	Record(ctx context.Context, entry HistoryEntry) error
+}
+
+func (f *fsm) historyKey(s interface{}) string {
+	return "history:" + f.instanceKey(s)
+}
+
+// record appends the outcome of a Fire call to the history of s and passes
+// it to the audit sink.
+func (f *fsm) record(ctx context.Context, s interface{}, a *attempt, err error) error {
+	max, sink := f.parent.historyLimit, f.parent.auditSink
+	if max <= 0 && sink == nil {
+		return nil
+	}
+
+	entry := HistoryEntry{
+		Type:     f.name,
+		Event:    a.labels.Event,
+		From:     State(a.labels.From),
+		To:       State(a.labels.To),
+		Time:     f.parent.now(),
+		Tenant:   a.labels.Tenant,
+		Instance: f.instanceKey(s),
+		Reason:   a.reason,
+		Meta:     a.meta,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Final = f.isFinal(entry.To)
+	}
+
+	if max > 0 {
+		f.historyMu.Lock()
+		herr := f.appendHistory(ctx, s, entry, max)
+		f.historyMu.Unlock()
+		if herr != nil {
+			return herr
+		}
+	}
+
+	if sink != nil {
+		return sink.Record(ctx, entry)
+	}
+	return nil
+}
+
+func (f *fsm) appendHistory(ctx context.Context, s interface{}, entry HistoryEntry, max int) error {
+	if ds, ok := f.parent.store.(DeltaStore); ok {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return ds.Append(ctx, f.historyKey(s), data, max)
+	}
+
+	entries, err := f.History(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	entries = append(entries, entry)
+	if len(entries) > max {
+		entries = entries[len(entries)-max:]
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return f.parent.store.Save(ctx, f.historyKey(s), data, 0)
+}
+
+// History returns the recorded transitions of s, oldest first.
+func (f *fsm) History(ctx context.Context, s interface{}) ([]HistoryEntry, error) {
+	if ds, ok := f.parent.store.(DeltaStore); ok {
+		list, err := ds.LoadList(ctx, f.historyKey(s))
+		if err != nil {
+			return nil, err
+		}
+
+		entries := make([]HistoryEntry, len(list))
+		for i, data := range list {
+			if err := json.Unmarshal(data, &entries[i]); err != nil {
+				return nil, err
+			}
+		}
+		return entries, nil
+	}
+
+	data, err := f.parent.store.Load(ctx, f.historyKey(s))
+	if err != nil || data == nil {
+		return []HistoryEntry{}, err
+	}
+
+	var entries []HistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// enteredAt returns when s entered its current state: its
+// WithStateChangedAt field if the machine sets one, the time of the last
+// successful transition in its history otherwise, zero if there is none.
+// History is only found again for reloaded instances if they have an
+// identity, so without the field it returns IdentityRequiredError for
+// option unless WithIdentity or WithIDFunc is set.
+func (f *FSM) enteredAt(ctx context.Context, s interface{}, option string) (time.Time, error) {
+	machine, ok := f.machine(s)
+	if !ok {
+		return time.Time{}, InternalError{}
+	}
+
+	if machine.stateChangedAt != "" {
+		return machine.stateChangedAtOf(s)
+	}
+	if err := machine.requireIdentity(option); err != nil {
+		return time.Time{}, err
+	}
+
+	history, err := machine.History(ctx, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Error == "" {
+			return history[i].Time, nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+// History func to return the recorded transitions of s, oldest first
+func (f *FSM) History(ctx context.Context, s interface{}) ([]HistoryEntry, error) {
+	machine, ok := f.machine(s)
+	if !ok {
+		return nil, InternalError{}
+	}
+
+	return machine.History(ctx, s)
+}
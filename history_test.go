@@ -0,0 +1,69 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type sliceSink struct {
+	entries []HistoryEntry
+}
+
+func (s *sliceSink) Record(ctx context.Context, entry HistoryEntry) error {
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func TestHistory(t *testing.T) {
+	sink := &sliceSink{}
+	fsm := NewFSM(WithHistory(2), WithAuditSink(sink), WithIDFunc(byAddress))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+	}, {
+		Name: "reset",
+		From: []State{"finished"},
+		To:   State("started"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("started")}
+	ctx := context.Background()
+	for _, event := range []string{"make", "reset", "reset"} {
+		_ = fsm.Fire(ctx, testStruct, event)
+	}
+
+	history, err := fsm.History(ctx, testStruct)
+	if err != nil {
+		t.Errorf("fsm.History() error = %v", err)
+	}
+
+	if len(history) != 2 {
+		t.Fatalf("expected history to be bounded to 2 entries, got %d", len(history))
+	}
+	if history[0].Event != "reset" || history[0].From != "finished" || history[0].To != "started" {
+		t.Errorf("unexpected entry %+v", history[0])
+	}
+	if history[1].Error == "" {
+		t.Errorf("expected failed entry to carry the error, got %+v", history[1])
+	}
+
+	if len(sink.entries) != 3 {
+		t.Errorf("expected sink to receive 3 entries, got %d", len(sink.entries))
+	}
+}
+
+func TestHistoryRequiresIdentity(t *testing.T) {
+	events := Events{{Name: "go", From: []State{"a"}, To: "b"}}
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := NewFSM(WithHistory(10)).Register(tag, "State", events); !errors.As(err, new(IdentityRequiredError)) {
+		t.Errorf("Register() error = %v, want IdentityRequiredError", err)
+	}
+	if err := NewFSM(WithHistory(10)).Register(tag, "State", events, WithIdentity(byAddress)); err != nil {
+		t.Errorf("Register() with WithIdentity error = %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// HookOption configures a callback added with OnAnyTransition.
+//
+// The hooks of a transition run in phases, each completing before the next
+// starts, and Fire stops at the first phase failing:
+//
+//  1. guards, by GuardCost and then declaration order
+//  2. WithDefaultBefore callbacks, then Before
+//  3. the state field is written
+//  4. the OnEnter callback of the destination
+//  5. After, then WithDefaultAfter callbacks
+//  6. OnAnyTransition callbacks, by HookPriority and then registration order
+//  7. invariants, then OnComplete if a final state was entered
+//  8. history and audit records, then the Publisher, then scheduling
+//     propagated events
+type HookOption func(*hook)
+
+// HookPriority orders the callback among the OnAnyTransition callbacks:
+// higher priorities run first, equal ones in registration order. The
+// default is zero.
+func HookPriority(priority int) HookOption {
+	return func(h *hook) {
+		h.priority = priority
+	}
+}
+
+// Concurrent runs the callback concurrently with the other Concurrent
+// callbacks of the same priority, e.g. independent cache invalidations. All
+// of them run to completion; their errors are joined.
+func Concurrent() HookOption {
+	return func(h *hook) {
+		h.concurrent = true
+	}
+}
+
+type hook struct {
+	fn         Callback
+	priority   int
+	concurrent bool
+}
+
+// addHook inserts h into hooks by priority, after those of equal priority.
+func addHook(hooks []hook, h hook) []hook {
+	hooks = append(hooks, h)
+	sort.SliceStable(hooks, func(i, j int) bool {
+		return hooks[i].priority > hooks[j].priority
+	})
+	return hooks
+}
+
+// runHooks runs hooks in order, running adjacent concurrent hooks of equal
+// priority together, and stops at the first failing step.
+func runHooks(ctx context.Context, e *Event, hooks []hook) error {
+	for i := 0; i < len(hooks); {
+		h := hooks[i]
+		if !h.concurrent {
+			if err := h.fn(ctx, e); err != nil {
+				return err
+			}
+			i++
+			continue
+		}
+
+		j := i + 1
+		for j < len(hooks) && hooks[j].concurrent && hooks[j].priority == h.priority {
+			j++
+		}
+		if err := runConcurrently(ctx, e, hooks[i:j]); err != nil {
+			return err
+		}
+		i = j
+	}
+	return nil
+}
+
+func runConcurrently(ctx context.Context, e *Event, hooks []hook) error {
+	errs := make([]error, len(hooks))
+	var wg sync.WaitGroup
+	for i, h := range hooks {
+		wg.Go(func() {
+			errs[i] = h.fn(ctx, e)
+		})
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,74 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestHookOrder(t *testing.T) {
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	fsm := NewFSM()
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "pay",
+		From: []State{"created"},
+		To:   State("paid"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	var mu sync.Mutex
+	var calls []string
+	record := func(name string, err error) Callback {
+		return func(ctx context.Context, e *Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	// Both concurrent hooks wait for each other, so they only complete if
+	// they run together.
+	var both sync.WaitGroup
+	both.Add(2)
+	rendezvous := func(name string, err error) Callback {
+		return func(ctx context.Context, e *Event) error {
+			both.Done()
+			both.Wait()
+			return record(name, err)(ctx, e)
+		}
+	}
+
+	errCache := errors.New("cache down")
+	hooks := []struct {
+		fn      Callback
+		options []HookOption
+	}{
+		{record("audit", nil), nil},
+		{record("metrics", nil), []HookOption{HookPriority(10)}},
+		{rendezvous("cache a", nil), []HookOption{HookPriority(5), Concurrent()}},
+		{rendezvous("cache b", errCache), []HookOption{HookPriority(5), Concurrent()}},
+		{record("notify", nil), []HookOption{HookPriority(-1)}},
+	}
+	for _, h := range hooks {
+		if err := fsm.OnAnyTransition(tag, h.fn, h.options...); err != nil {
+			t.Errorf("OnAnyTransition() error = %v", err)
+		}
+	}
+
+	err := fsm.Fire(context.Background(), &TestStruct{State: "created"}, "pay")
+	if !errors.Is(err, errCache) {
+		t.Errorf("Fire() error = %v, want %v", err, errCache)
+	}
+
+	if len(calls) == 3 {
+		slices.Sort(calls[1:])
+	}
+	if want := []string{"metrics", "cache a", "cache b"}; !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
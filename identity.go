@@ -0,0 +1,42 @@
+package fsm
+
+// WithIdentity identifies instances by fn, e.g. their primary key, instead
+// of their address. Instance locks, actors, history, variables, quotas and
+// the LockProvider lock of WithInstanceLocks then key on the entity, so two
+// structs loaded from the same row share them. fn takes precedence over
+// WithIDFunc for the instances of the machine.
+func WithIdentity(fn func(s interface{}) string) RegisterOption {
+	return func(f *fsm) {
+		f.identity = fn
+	}
+}
+
+// identityOf returns the ID of s set by WithIdentity or WithIDFunc, false
+// if neither is set.
+func (f *fsm) identityOf(s interface{}) (string, bool) {
+	if f.identity != nil {
+		return f.identity(s), true
+	}
+	if f.parent.idFunc != nil {
+		return f.parent.idFunc(s), true
+	}
+	return "", false
+}
+
+// requireIdentity returns IdentityRequiredError for option unless instances
+// of f can be identified.
+func (f *fsm) requireIdentity(option string) error {
+	if f.identity == nil && f.parent.idFunc == nil {
+		return IdentityRequiredError{Type: f.tag, Option: option}
+	}
+	return nil
+}
+
+// lockKey returns the key s is locked under, its ID like for instanceKey or
+// else s itself.
+func (f *fsm) lockKey(s interface{}) interface{} {
+	if id, ok := f.identityOf(s); ok {
+		return id
+	}
+	return s
+}
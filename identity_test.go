@@ -0,0 +1,123 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithIdentity(t *testing.T) {
+	type Row struct {
+		ID    string
+		State State
+	}
+
+	var mu sync.Mutex
+	inside, peak := 0, 0
+	block := make(chan struct{})
+
+	locks := &recordingLocks{}
+	fsm := NewFSM(WithHistory(10), WithLockProvider(locks), WithInstanceLocks())
+	if err := fsm.Register(reflect.TypeOf((*Row)(nil)), "State", Events{{
+		Name: "touch",
+		From: []State{"open"},
+		To:   State("open"),
+		Before: func(ctx context.Context, e *Event) error {
+			mu.Lock()
+			inside++
+			peak = max(peak, inside)
+			mu.Unlock()
+			<-block
+			mu.Lock()
+			inside--
+			mu.Unlock()
+			return nil
+		},
+	}}, WithIdentity(func(s interface{}) string { return s.(*Row).ID })); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	a, b := &Row{ID: "42", State: "open"}, &Row{ID: "42", State: "open"}
+
+	var wg sync.WaitGroup
+	for _, row := range []*Row{a, b} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fsm.Fire(ctx, row, "touch"); err != nil {
+				t.Errorf("Fire() error = %v", err)
+			}
+		}()
+	}
+	block <- struct{}{}
+	block <- struct{}{}
+	wg.Wait()
+
+	if peak != 1 {
+		t.Errorf("%d transitions of the same entity ran concurrently", peak)
+	}
+
+	history, err := fsm.History(ctx, &Row{ID: "42"})
+	if err != nil || len(history) != 2 {
+		t.Errorf("History() = %v, %v, want both transitions", history, err)
+	}
+	if want := "instance:" + reflect.TypeOf(a).String() + ":42"; locks.keys[0] != want {
+		t.Errorf("locked %v, want %v", locks.keys, want)
+	}
+}
+
+func TestWithIDFuncLocks(t *testing.T) {
+	type Row struct {
+		ID    string
+		State State
+	}
+
+	var mu sync.Mutex
+	inside, peak := 0, 0
+	entered, block := make(chan struct{}, 2), make(chan struct{})
+
+	fsm := NewFSM(WithIDFunc(func(s interface{}) string { return s.(*Row).ID }))
+	if err := fsm.Register(reflect.TypeOf((*Row)(nil)), "State", Events{{
+		Name: "touch",
+		From: []State{"open"},
+		To:   State("open"),
+		Before: func(ctx context.Context, e *Event) error {
+			mu.Lock()
+			inside++
+			peak = max(peak, inside)
+			mu.Unlock()
+			entered <- struct{}{}
+			<-block
+			mu.Lock()
+			inside--
+			mu.Unlock()
+			return nil
+		},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for _, row := range []*Row{{ID: "42", State: "open"}, {ID: "42", State: "open"}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fsm.Fire(ctx, row, "touch"); err != nil {
+				t.Errorf("Fire() error = %v", err)
+			}
+		}()
+	}
+	// Give the second transition time to overlap the first.
+	<-entered
+	time.Sleep(20 * time.Millisecond)
+	close(block)
+	wg.Wait()
+
+	if peak != 1 {
+		t.Errorf("%d transitions of the same ID ran concurrently", peak)
+	}
+}
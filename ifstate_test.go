@@ -0,0 +1,43 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestIfState(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "finish",
+		From: []State{"started", "paused"},
+		To:   State("finished"),
+	}, {
+		Name: "pause",
+		From: []State{"started"},
+		To:   State("paused"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("started")}
+
+	// Another handler paused the instance after this one loaded it.
+	if err := fsm.Fire(ctx, testStruct, "pause"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	var stale StaleStateError
+	if err := fsm.Fire(ctx, testStruct, "finish", IfState("started")); !errors.As(err, &stale) || stale.State != "paused" {
+		t.Errorf("Fire() error = %v, want StaleStateError", err)
+	}
+	if testStruct.State != "paused" {
+		t.Errorf("State = %v, want paused", testStruct.State)
+	}
+
+	if err := fsm.Fire(ctx, testStruct, "finish", IfState("paused")); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+)
+
+// RequireInitialized rejects Fire on instances whose state field is empty
+// with UninitializedStateError instead of looking up a transition from the
+// empty state.
+func RequireInitialized() RegisterOption {
+	return func(f *fsm) {
+		f.requireInit = true
+	}
+}
+
+// SetInitial func to set the state Init puts new instances of the default
+// machine of tag in
+func (f *FSM) SetInitial(tag reflect.Type, state State) error {
+	return f.updateStates(tag, func(c *stateConfig) {
+		c.initial = state
+	})
+}
+
+// OnEnter func to set the callback run whenever an instance of the default
+// machine of tag enters state, by Init or by a transition. It runs after the
+// state is written and before the After callback of the transition.
+func (f *FSM) OnEnter(tag reflect.Type, state State, fn Callback) error {
+	return f.updateStates(tag, func(c *stateConfig) {
+		c.onEnter[state] = fn
+	})
+}
+
+// Init func to put s in the initial state set with SetInitial and run the
+// OnEnter callback of that state. Instances with a state already are left
+// unchanged.
+func (f *FSM) Init(ctx context.Context, s interface{}) error {
+	machine, ok := f.machine(s)
+	if !ok {
+		return InternalError{}
+	}
+
+	return machine.exec(ctx, s, func() error {
+		return machine.init(ctx, s)
+	})
+}
+
+func (f *fsm) init(ctx context.Context, s interface{}) error {
+	initial := f.stateConfig().initial
+	if initial == "" {
+		return InternalError{}
+	}
+
+	field, state, err := f.getSourceState(s)
+	if err != nil {
+		return err
+	}
+	if state != "" {
+		return nil
+	}
+
+	if err := f.setState(field, f.accessFor(reflect.TypeOf(s)), initial); err != nil {
+		return err
+	}
+
+	vars, err := f.loadVars(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	e := &Event{Source: s, Destination: initial, Vars: vars}
+	if err := f.entered(ctx, e); err != nil {
+		return err
+	}
+	return f.saveVars(ctx, s, vars)
+}
+
+// entered runs the OnEnter callback of the destination of e.
+func (f *fsm) entered(ctx context.Context, e *Event) error {
+	fn, ok := f.stateConfig().onEnter[e.Destination]
+	if !ok {
+		return nil
+	}
+	return fn(ctx, e)
+}
@@ -0,0 +1,73 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestInit(t *testing.T) {
+	fsm := NewFSM()
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	entered := []State{}
+	onEnter := func(ctx context.Context, e *Event) error {
+		entered = append(entered, e.Destination)
+		return nil
+	}
+	if err := fsm.SetInitial(tag, State("started")); err != nil {
+		t.Errorf("SetInitial() error = %v", err)
+	}
+	if err := fsm.OnEnter(tag, State("started"), onEnter); err != nil {
+		t.Errorf("OnEnter() error = %v", err)
+	}
+	if err := fsm.OnEnter(tag, State("finished"), onEnter); err != nil {
+		t.Errorf("OnEnter() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{}
+	if err := fsm.Init(ctx, testStruct); err != nil {
+		t.Errorf("Init() error = %v", err)
+	}
+	if testStruct.State != State("started") {
+		t.Errorf("state after Init() = %q, want started", testStruct.State)
+	}
+
+	if err := fsm.Fire(ctx, testStruct, "make"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	// Init leaves initialized instances alone.
+	if err := fsm.Init(ctx, testStruct); err != nil {
+		t.Errorf("Init() error = %v", err)
+	}
+
+	if want := []State{"started", "finished"}; !reflect.DeepEqual(entered, want) {
+		t.Errorf("entered %v, want %v", entered, want)
+	}
+}
+
+func TestRequireInitialized(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "make",
+		From: []State{""},
+		To:   State("finished"),
+	}}, RequireInitialized()); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	err := fsm.Fire(context.Background(), &TestStruct{}, "make")
+	if !errors.As(err, new(UninitializedStateError)) {
+		t.Errorf("Fire() error = %v, want UninitializedStateError", err)
+	}
+}
@@ -0,0 +1,102 @@
+package fsm
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// MachineInfo describes a registered machine, see FSM.Machines.
+type MachineInfo struct {
+	Type   reflect.Type
+	Column string
+	// Initial is the state set by SetInitial, empty if none.
+	Initial State
+	// States lists the states declared by WithStates or else those used by
+	// transitions, sorted.
+	States []State
+	// Finals lists the states marked final, sorted.
+	Finals      []State
+	Transitions []Transition
+}
+
+// Machines func to describe every registered machine, by type name and then
+// by column in registration order
+func (f *FSM) Machines() []MachineInfo {
+	f.mu.RLock()
+	var machines []*fsm
+	for _, ms := range f.machines {
+		machines = append(machines, ms...)
+	}
+	f.mu.RUnlock()
+
+	sort.SliceStable(machines, func(i, j int) bool {
+		return machines[i].name < machines[j].name
+	})
+
+	infos := make([]MachineInfo, 0, len(machines))
+	for _, m := range machines {
+		infos = append(infos, m.info())
+	}
+	return infos
+}
+
+// Transitions func to return the transitions of the default machine of tag
+// in declaration order, one per source state and destination
+func (f *FSM) Transitions(tag reflect.Type) ([]Transition, error) {
+	machine, err := f.defaultMachine(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return machine.table().schema.edges(false), nil
+}
+
+// States func to return the states of the default machine of tag, see
+// MachineInfo.States
+func (f *FSM) States(tag reflect.Type) ([]State, error) {
+	machine, err := f.defaultMachine(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return machine.statesOf(), nil
+}
+
+func (f *fsm) info() MachineInfo {
+	c := f.stateConfig()
+	finals := make([]State, 0, len(c.finals))
+	for state := range c.finals {
+		finals = append(finals, state)
+	}
+	slices.Sort(finals)
+
+	return MachineInfo{
+		Type:        f.tag,
+		Column:      f.column,
+		Initial:     c.initial,
+		States:      f.statesOf(),
+		Finals:      finals,
+		Transitions: f.table().schema.edges(false),
+	}
+}
+
+func (f *fsm) statesOf() []State {
+	seen := make(map[State]bool, len(f.validStates))
+	for state := range f.validStates {
+		seen[state] = true
+	}
+	if len(seen) == 0 {
+		for _, t := range f.table().schema.edges(false) {
+			seen[t.From], seen[t.To] = true, true
+		}
+		delete(seen, "")
+	}
+
+	states := make([]State, 0, len(seen))
+	for state := range seen {
+		states = append(states, state)
+	}
+	slices.Sort(states)
+	return states
+}
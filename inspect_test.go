@@ -0,0 +1,63 @@
+package fsm
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestMachines(t *testing.T) {
+	fsm := NewFSM()
+	orders := reflect.TypeOf((*Order)(nil))
+	if err := fsm.Register(orders, "PaymentState", Events{{
+		Name: "pay",
+		From: []State{"unpaid"},
+		To:   State("paid"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := fsm.Register(orders, "ShippingState", Events{{
+		Name: "ship",
+		From: []State{"pending"},
+		To:   State("shipped"),
+	}}, WithStates("pending", "shipped", "lost")); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	tests := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tests, "State", Events{{
+		Name: "finish",
+		From: []State{"", "started"},
+		To:   State("finished"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := fsm.SetInitial(tests, "started"); err != nil {
+		t.Errorf("SetInitial() error = %v", err)
+	}
+	if err := fsm.MarkFinal(tests, "finished"); err != nil {
+		t.Errorf("MarkFinal() error = %v", err)
+	}
+
+	machines := fsm.Machines()
+	if len(machines) != 3 {
+		t.Fatalf("Machines() = %+v", machines)
+	}
+	if m := machines[0]; m.Type != orders || m.Column != "PaymentState" || !slices.Equal(m.States, []State{"paid", "unpaid"}) {
+		t.Errorf("Machines()[0] = %+v", m)
+	}
+	if m := machines[1]; m.Column != "ShippingState" || !slices.Equal(m.States, []State{"lost", "pending", "shipped"}) {
+		t.Errorf("Machines()[1] = %+v", m)
+	}
+	if m := machines[2]; m.Type != tests || m.Initial != "started" || !slices.Equal(m.Finals, []State{"finished"}) || len(m.Transitions) != 2 {
+		t.Errorf("Machines()[2] = %+v", m)
+	}
+
+	states, err := fsm.States(tests)
+	if err != nil || !slices.Equal(states, []State{"finished", "started"}) {
+		t.Errorf("States() = %v, %v", states, err)
+	}
+	transitions, err := fsm.Transitions(orders)
+	if want := []Transition{{Event: "pay", From: "unpaid", To: "paid"}}; err != nil || !slices.Equal(transitions, want) {
+		t.Errorf("Transitions() = %v, %v, want %v", transitions, err, want)
+	}
+}
@@ -0,0 +1,89 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type Payable interface {
+	Amount() int
+}
+
+type Invoice struct {
+	Number string
+	State  State
+}
+
+func (*Invoice) Amount() int { return 10 }
+
+type Subscription struct {
+	State State
+	Plan  string
+}
+
+func (*Subscription) Amount() int { return 5 }
+
+func TestRegisterInterface(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*Payable)(nil)).Elem(), "State", Events{{
+		Name: "pay",
+		From: []State{"open"},
+		To:   State("paid"),
+		Guards: []Guard{func(ctx context.Context, e *Event) (bool, error) {
+			return e.Source.(Payable).Amount() > 0, nil
+		}},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	invoice := &Invoice{State: State("open")}
+	if err := fsm.Fire(context.Background(), invoice, "pay"); err != nil {
+		t.Errorf("Fire(invoice) error = %v", err)
+	}
+	if invoice.State != State("paid") {
+		t.Errorf("invoice state = %s, want paid", invoice.State)
+	}
+
+	subscription := &Subscription{State: State("open")}
+	events, err := fsm.GetPermittedEvents(context.Background(), subscription)
+	if err != nil {
+		t.Errorf("GetPermittedEvents() error = %v", err)
+	}
+	if !reflect.DeepEqual(events, []string{"pay"}) {
+		t.Errorf("GetPermittedEvents() = %v, want [pay]", events)
+	}
+	if err := fsm.Fire(context.Background(), subscription, "pay"); err != nil {
+		t.Errorf("Fire(subscription) error = %v", err)
+	}
+
+	if err := fsm.Fire(context.Background(), &TestStruct{State: State("open")}, "pay"); err == nil {
+		t.Errorf("Fire() on a type not implementing Payable succeeded")
+	}
+}
+
+func TestRegisterInterfaceConcreteWins(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*Payable)(nil)).Elem(), "State", Events{{
+		Name: "pay",
+		From: []State{"open"},
+		To:   State("paid"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := fsm.Register(reflect.TypeOf((*Invoice)(nil)), "State", Events{{
+		Name: "pay",
+		From: []State{"open"},
+		To:   State("settled"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	invoice := &Invoice{State: State("open")}
+	if err := fsm.Fire(context.Background(), invoice, "pay"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if invoice.State != State("settled") {
+		t.Errorf("invoice state = %s, want settled", invoice.State)
+	}
+}
@@ -0,0 +1,67 @@
+package fsm
+
+import (
+	"context"
+	"maps"
+	"reflect"
+)
+
+// Invariant checks a property every instance must keep, e.g. that a shipped
+// order has a tracking number, and returns an error describing a violation.
+type Invariant func(ctx context.Context, s interface{}) error
+
+// Invariant func to add a check run on instances of the default machine of
+// tag after every successful transition, once all callbacks ran. If it
+// fails the instance and its variables are reset to their values before the
+// transition, unique groups, quotas and throttles are left as they were and
+// Fire returns InvariantViolationError. Other side effects of callbacks are
+// kept.
+func (f *FSM) Invariant(tag reflect.Type, fn Invariant) error {
+	return f.updateStates(tag, func(c *stateConfig) {
+		c.invariants = append(c.invariants, fn)
+	})
+}
+
+// checkInvariants runs the invariants in the order they were added,
+// stopping at the first violation.
+func (f *fsm) checkInvariants(ctx context.Context, e *Event, from State) error {
+	for _, fn := range f.stateConfig().invariants {
+		if err := fn(ctx, e.Source); err != nil {
+			return InvariantViolationError{Event: e.Event, State: string(from), Err: err}
+		}
+	}
+	return nil
+}
+
+// rollback copies s and vars and returns a function restoring the copies.
+// Nothing is copied if the machine has no invariants.
+func (f *fsm) rollback(s interface{}, vars *Vars) func(ctx context.Context) error {
+	if len(f.stateConfig().invariants) == 0 {
+		return func(context.Context) error { return nil }
+	}
+
+	elem := reflect.ValueOf(s).Elem()
+	saved := reflect.New(elem.Type()).Elem()
+	saved.Set(elem)
+
+	var values map[string]interface{}
+	if vars != nil {
+		values = maps.Clone(vars.values)
+	}
+
+	return func(ctx context.Context) error {
+		elem.Set(saved)
+		if vars == nil {
+			return nil
+		}
+
+		vars.values = maps.Clone(values)
+		for name, decl := range vars.decls {
+			if _, ok := vars.values[name]; !ok {
+				vars.values[name] = decl.initial
+			}
+			vars.changed[name] = true
+		}
+		return f.saveVars(ctx, s, vars)
+	}
+}
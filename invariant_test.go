@@ -0,0 +1,61 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestInvariant(t *testing.T) {
+	type Shipment struct {
+		State    State
+		Tracking string
+	}
+
+	fsm := NewFSM(WithIDFunc(byAddress))
+	tag := reflect.TypeOf((*Shipment)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "ship",
+		From: []State{"packed"},
+		To:   State("shipped"),
+		Before: func(ctx context.Context, e *Event) error {
+			e.Source.(*Shipment).Tracking = e.Reason
+			return nil
+		},
+		After: func(ctx context.Context, e *Event) error {
+			return e.Vars.Set("attempts", Var[int](e.Vars, "attempts")+1)
+		},
+	}}, DeclareVar("attempts", 0)); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	errNoTracking := errors.New("shipped without tracking number")
+	if err := fsm.Invariant(tag, func(ctx context.Context, s interface{}) error {
+		if sh := s.(*Shipment); sh.State == "shipped" && sh.Tracking == "" {
+			return errNoTracking
+		}
+		return nil
+	}); err != nil {
+		t.Errorf("fsm.Invariant() error = %v", err)
+	}
+
+	ctx := context.Background()
+	s := &Shipment{State: "packed"}
+	if err := fsm.Fire(ctx, s, "ship"); !errors.As(err, new(InvariantViolationError)) || !errors.Is(err, errNoTracking) {
+		t.Errorf("Fire() error = %v, want InvariantViolationError", err)
+	}
+	if s.State != "packed" {
+		t.Errorf("State = %v, want rolled back to packed", s.State)
+	}
+	if vars, _ := fsm.Vars(ctx, s); Var[int](vars, "attempts") != 0 {
+		t.Errorf("attempts = %v, want rolled back to 0", Var[int](vars, "attempts"))
+	}
+
+	if err := fsm.Fire(ctx, s, "ship", WithReason("1Z999")); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if s.State != "shipped" || s.Tracking != "1Z999" {
+		t.Errorf("after Fire() = %+v", s)
+	}
+}
@@ -0,0 +1,77 @@
+package fsm
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+)
+
+// JSONSchema returns a JSON Schema (draft 2020-12) of the objects
+// {"<column>": state, "event": event} accepted by s: the state is one of the
+// states of s and the event one declared from it, so API clients can
+// validate actions before sending them. Drafts are included with
+// opts.IncludeDraft.
+func JSONSchema(s Schema, opts ExportOptions) map[string]interface{} {
+	edges := s.edges(false)
+	if opts.IncludeDraft {
+		edges = append(edges, s.edges(true)...)
+	}
+
+	states := append([]State(nil), s.States...)
+	eventsFrom := make(map[State][]string)
+	var events []string
+	for _, t := range edges {
+		if t.From != "" {
+			states = append(states, t.From)
+		}
+		states = append(states, t.To)
+		eventsFrom[t.From] = append(eventsFrom[t.From], t.Event)
+		events = append(events, t.Event)
+	}
+	states = sortedUnique(states)
+
+	var rules []interface{}
+	for _, state := range states {
+		// Nothing may be fired from states without transitions.
+		allowed := sortedUnique(eventsFrom[state])
+		rules = append(rules, map[string]interface{}{
+			"if": map[string]interface{}{
+				"properties": map[string]interface{}{s.Column: map[string]interface{}{"const": state}},
+			},
+			"then": map[string]interface{}{
+				"properties": map[string]interface{}{"event": map[string]interface{}{"enum": allowed}},
+			},
+		})
+	}
+
+	schema := map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   opts.heading(),
+		"type":    "object",
+		"properties": map[string]interface{}{
+			s.Column: map[string]interface{}{"type": "string", "enum": states},
+			"event":  map[string]interface{}{"type": "string", "enum": sortedUnique(events)},
+		},
+		"required": []string{s.Column, "event"},
+	}
+	if opts.Version != "" {
+		schema["$comment"] = "version " + opts.Version
+	}
+	if len(rules) > 0 {
+		schema["allOf"] = rules
+	}
+	return schema
+}
+
+// WriteJSONSchema writes JSONSchema of s as indented JSON.
+func WriteJSONSchema(w io.Writer, s Schema, opts ExportOptions) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(JSONSchema(s, opts))
+}
+
+func sortedUnique[T ~string](values []T) []T {
+	values = append([]T{}, values...)
+	slices.Sort(values)
+	return slices.Compact(values)
+}
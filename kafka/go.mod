@@ -0,0 +1,16 @@
+module github.com/ceearrashee/fsm/kafka
+
+go 1.25
+
+require (
+	github.com/ceearrashee/fsm v0.0.0
+	github.com/segmentio/kafka-go v0.4.51
+)
+
+require (
+	github.com/klauspost/compress v1.15.9 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/ceearrashee/fsm => ../
@@ -0,0 +1,37 @@
+// Package kafka implements fsm.Publisher on top of kafka-go.
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ceearrashee/fsm"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// Writer is the part of *kafkago.Writer the Publisher uses.
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafkago.Message) error
+}
+
+// Publisher writes every transition as a JSON message keyed by the instance
+// ID, so transitions of one instance keep their order within a partition.
+type Publisher struct {
+	w Writer
+}
+
+// New creates a Publisher writing to w, typically a *kafkago.Writer with
+// its Topic set.
+func New(w Writer) *Publisher {
+	return &Publisher{w: w}
+}
+
+// Publish implements fsm.Publisher.
+func (p *Publisher) Publish(ctx context.Context, event fsm.TransitionEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return p.w.WriteMessages(ctx, kafkago.Message{Key: []byte(event.ID), Value: value, Time: event.Time})
+}
@@ -0,0 +1,72 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ceearrashee/fsm"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+type fakeWriter struct {
+	msgs []kafkago.Message
+	err  error
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafkago.Message) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+type Order struct {
+	ID    string
+	State fsm.State
+}
+
+func TestPublisher(t *testing.T) {
+	w := &fakeWriter{}
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	event := fsm.TransitionEvent{Type: "order", ID: "o-1", Event: "pay", From: "created", To: "paid", Time: at}
+
+	if err := New(w).Publish(context.Background(), event); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if len(w.msgs) != 1 {
+		t.Fatalf("wrote %d messages, want 1", len(w.msgs))
+	}
+
+	msg := w.msgs[0]
+	var got fsm.TransitionEvent
+	if err := json.Unmarshal(msg.Value, &got); err != nil {
+		t.Errorf("message value error = %v", err)
+	}
+	if string(msg.Key) != "o-1" || !msg.Time.Equal(at) || !reflect.DeepEqual(got, event) {
+		t.Errorf("wrote key %q at %v with %+v, want o-1 at %v with %+v", msg.Key, msg.Time, got, at, event)
+	}
+}
+
+func TestPublisherWithFSM(t *testing.T) {
+	w := &fakeWriter{err: errors.New("broker down")}
+	f := fsm.NewFSM(fsm.WithPublisher(New(w)), fsm.WithIDFunc(func(s interface{}) string {
+		return s.(*Order).ID
+	}))
+	if err := f.Register(reflect.TypeOf((*Order)(nil)), "State", fsm.Events{{
+		Name: "pay",
+		From: []fsm.State{"created"},
+		To:   fsm.State("paid"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	order := &Order{ID: "o-1", State: "created"}
+	if err := f.Fire(context.Background(), order, "pay"); !errors.Is(err, w.err) || order.State != "paid" {
+		t.Errorf("Fire() = %v, %v, want paid and the write error", order.State, err)
+	}
+}
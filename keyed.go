@@ -0,0 +1,112 @@
+package fsm
+
+import "sync"
+
+// keyedMutex hands out one mutex per key and forgets it once nobody holds
+// or waits for it. With fifo set, waiters acquire a key in arrival order.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[interface{}]*refMutex
+	fifo  bool
+}
+
+type refMutex struct {
+	sync.Locker
+	mu   sync.Mutex
+	refs int
+}
+
+// refMutexes recycles the plain mutexes of keys nobody holds anymore, so
+// locking an uncontended key doesn't allocate.
+var refMutexes = sync.Pool{New: func() interface{} {
+	m := &refMutex{}
+	m.Locker = &m.mu
+	return m
+}}
+
+// Lock locks key and returns the function unlocking it.
+func (k *keyedMutex) Lock(key interface{}) func() {
+	m := k.acquire(key)
+	return func() { k.release(key, m) }
+}
+
+// acquire locks key, which must be released with the returned mutex.
+func (k *keyedMutex) acquire(key interface{}) *refMutex {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[interface{}]*refMutex)
+	}
+	m, ok := k.locks[key]
+	if !ok {
+		if k.fifo {
+			m = &refMutex{Locker: &fifoMutex{}}
+		} else {
+			m = refMutexes.Get().(*refMutex)
+		}
+		k.locks[key] = m
+	}
+	m.refs++
+	k.mu.Unlock()
+
+	m.Lock()
+	return m
+}
+
+// release unlocks key locked by acquire.
+func (k *keyedMutex) release(key interface{}, m *refMutex) {
+	m.Unlock()
+
+	k.mu.Lock()
+	m.refs--
+	if m.refs == 0 {
+		delete(k.locks, key)
+		if !k.fifo {
+			refMutexes.Put(m)
+		}
+	}
+	k.mu.Unlock()
+}
+
+// Len returns the number of keys currently held or waited for.
+func (k *keyedMutex) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.locks)
+}
+
+// fifoMutex is a mutex handing itself to waiters in arrival order, so no
+// caller is starved under contention at the cost of throughput.
+type fifoMutex struct {
+	mu      sync.Mutex
+	locked  bool
+	waiters []chan struct{}
+}
+
+func (m *fifoMutex) Lock() {
+	m.mu.Lock()
+	if !m.locked {
+		m.locked = true
+		m.mu.Unlock()
+		return
+	}
+	ch := make(chan struct{})
+	m.waiters = append(m.waiters, ch)
+	m.mu.Unlock()
+
+	<-ch
+}
+
+func (m *fifoMutex) Unlock() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.waiters) == 0 {
+		m.locked = false
+		return
+	}
+
+	// Ownership passes to the next waiter directly, locked stays set.
+	ch := m.waiters[0]
+	m.waiters = m.waiters[1:]
+	close(ch)
+}
@@ -0,0 +1,101 @@
+package fsm
+
+import (
+	"context"
+	"sort"
+)
+
+// LockProvider locks named resources, e.g. across processes. Lock blocks
+// until key is held or ctx is done and returns the function releasing it.
+type LockProvider interface {
+	Lock(ctx context.Context, key string) (unlock func(), err error)
+}
+
+// MemoryLockProvider is an in-process LockProvider, the default.
+type MemoryLockProvider struct {
+	locks keyedMutex
+}
+
+// NewMemoryLockProvider func to create MemoryLockProvider
+func NewMemoryLockProvider() *MemoryLockProvider {
+	return &MemoryLockProvider{}
+}
+
+func (m *MemoryLockProvider) Lock(ctx context.Context, key string) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	acquired := make(chan *refMutex, 1)
+	go func() { acquired <- m.locks.acquire(key) }()
+
+	select {
+	case lock := <-acquired:
+		return func() { m.locks.release(key, lock) }, nil
+	case <-ctx.Done():
+		// The lock is released as soon as the abandoned wait gets it.
+		go func() { m.locks.release(key, <-acquired) }()
+		return nil, ctx.Err()
+	}
+}
+
+// WithInstanceLocks makes Fire hold the LockProvider lock of the instance
+// besides the in-process one, keyed by its type and ID (see WithIDFunc), so
+// replicas sharing a LockProvider, e.g. one backed by Redis, serialize the
+// transitions of an entity between them.
+func WithInstanceLocks() FSMOption {
+	return func(f *FSM) {
+		f.instanceLocks = true
+	}
+}
+
+// lockInstance takes the LockProvider lock of s if WithInstanceLocks is set.
+func (f *fsm) lockInstance(ctx context.Context, s interface{}) (func(), error) {
+	if !f.parent.instanceLocks {
+		return func() {}, nil
+	}
+	return f.parent.locks.Lock(ctx, "instance:"+f.name+":"+f.instanceID(s))
+}
+
+// lockResources locks the resources the transitions of event leaving the
+// state of s declare, in sorted order and after the instance lock, so
+// transitions sharing resources can't deadlock.
+func (f *fsm) lockResources(ctx context.Context, s interface{}, event string) (func(), error) {
+	t := f.table()
+	if len(t.resources) == 0 {
+		return func() {}, nil
+	}
+	// An unreadable state is reported by fireExclusive.
+	_, state, err := f.getSourceState(s)
+	if err != nil {
+		return func() {}, nil
+	}
+
+	var keys []string
+	for _, fn := range t.resources[eventKey{event, state}] {
+		keys = append(keys, fn(ctx, s)...)
+	}
+	sort.Strings(keys)
+
+	unlocks := make([]func(), 0, len(keys))
+	release := func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+
+	for i, key := range keys {
+		if i > 0 && key == keys[i-1] {
+			continue
+		}
+
+		unlock, err := f.parent.locks.Lock(ctx, "resource:"+key)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		unlocks = append(unlocks, unlock)
+	}
+
+	return release, nil
+}
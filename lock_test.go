@@ -0,0 +1,145 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingLocks struct {
+	mu   sync.Mutex
+	keys []string
+	MemoryLockProvider
+}
+
+func (r *recordingLocks) Lock(ctx context.Context, key string) (func(), error) {
+	r.mu.Lock()
+	r.keys = append(r.keys, key)
+	r.mu.Unlock()
+	return r.MemoryLockProvider.Lock(ctx, key)
+}
+
+func TestTransitionResourceLocks(t *testing.T) {
+	locks := &recordingLocks{}
+	fsm := NewFSM(WithLockProvider(locks))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "reserve",
+		From: []State{"started"},
+		To:   State("finished"),
+		Locks: func(ctx context.Context, s interface{}) []string {
+			return []string{"sku:b", "sku:a", "sku:b"}
+		},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	if err := fsm.Fire(context.Background(), &TestStruct{State: State("started")}, "reserve"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	want := []string{"resource:sku:a", "resource:sku:b"}
+	if !reflect.DeepEqual(locks.keys, want) {
+		t.Errorf("locked %v, want %v", locks.keys, want)
+	}
+	if n := locks.locks.Len(); n != 0 {
+		t.Errorf("%d resources still locked", n)
+	}
+}
+
+func TestTransitionResourceLocksSerialize(t *testing.T) {
+	fsm := NewFSM()
+
+	var mu sync.Mutex
+	inside, max := 0, 0
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "reserve",
+		From: []State{"started"},
+		To:   State("finished"),
+		Locks: func(ctx context.Context, s interface{}) []string {
+			return []string{"sku:a"}
+		},
+		Before: func(ctx context.Context, e *Event) error {
+			mu.Lock()
+			inside++
+			if inside > max {
+				max = inside
+			}
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			inside--
+			mu.Unlock()
+			return nil
+		},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fsm.Fire(context.Background(), &TestStruct{State: State("started")}, "reserve"); err != nil {
+				t.Errorf("Fire() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if max != 1 {
+		t.Errorf("%d transitions held sku:a at once, want 1", max)
+	}
+}
+
+func TestInstanceLocks(t *testing.T) {
+	locks := &recordingLocks{}
+	fsm := NewFSM(WithLockProvider(locks), WithInstanceLocks(), WithIDFunc(func(s interface{}) string {
+		return "order-1"
+	}))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "finish",
+		From: []State{"started"},
+		To:   State("finished"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	if err := fsm.Fire(context.Background(), &TestStruct{State: State("started")}, "finish"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	want := []string{"instance:*fsm.TestStruct:order-1"}
+	if !reflect.DeepEqual(locks.keys, want) {
+		t.Errorf("locked %v, want %v", locks.keys, want)
+	}
+	if n := locks.locks.Len(); n != 0 {
+		t.Errorf("%d instances still locked", n)
+	}
+}
+
+func TestMemoryLockProviderCancel(t *testing.T) {
+	locks := NewMemoryLockProvider()
+	unlock, err := locks.Lock(context.Background(), "sku:a")
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := locks.Lock(ctx, "sku:a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Lock() of a held key error = %v, want context.DeadlineExceeded", err)
+	}
+
+	unlock()
+	unlock, err = locks.Lock(context.Background(), "sku:a")
+	if err != nil {
+		t.Fatalf("Lock() after release error = %v", err)
+	}
+	unlock()
+}
@@ -0,0 +1,74 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogLevels selects the level each kind of log record is written at.
+type LogLevels struct {
+	Attempt   slog.Level
+	Rejection slog.Level
+	Callback  slog.Level
+}
+
+// DefaultLogLevels logs attempts at debug, guard rejections at info and
+// callback errors at error level.
+var DefaultLogLevels = LogLevels{
+	Attempt:   slog.LevelDebug,
+	Rejection: slog.LevelInfo,
+	Callback:  slog.LevelError,
+}
+
+func (f *fsm) log(ctx context.Context, level slog.Level, msg string, e *Event, from string, attrs ...slog.Attr) {
+	logger := f.parent.logger
+	if logger == nil || !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs = append(attrs,
+		slog.String("type", f.name),
+		slog.String("event", e.Event),
+		slog.String("from", from),
+		slog.String("to", string(e.Destination)),
+	)
+	if tenant := f.parent.tenant(ctx, e.Source); tenant != "" {
+		attrs = append(attrs, slog.String("tenant", tenant))
+	}
+	logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+func (f *fsm) logAttempt(ctx context.Context, e *Event, from string) {
+	t := f.table()
+	if len(t.guards[e.Event]) == 0 {
+		f.log(ctx, f.parent.logLevels.Attempt, "fsm: fire", e, from)
+		return
+	}
+	f.log(ctx, f.parent.logLevels.Attempt, "fsm: fire", e, from, slog.Any("guards", guardList(t.guards[e.Event])))
+}
+
+// guardList logs the names of guards in evaluation order, resolved only if
+// the record is written.
+type guardList []namedGuard
+
+func (l guardList) LogValue() slog.Value {
+	names := make([]string, len(l))
+	for i, g := range l {
+		names[i] = g.name
+	}
+	return slog.AnyValue(names)
+}
+
+func (f *fsm) logRejection(ctx context.Context, e *Event, from, guard string, err error) {
+	if err != nil {
+		f.log(ctx, f.parent.logLevels.Rejection, "fsm: guard rejected transition", e, from,
+			slog.String("guard", guard), slog.Any("error", err))
+		return
+	}
+	f.log(ctx, f.parent.logLevels.Rejection, "fsm: guard rejected transition", e, from, slog.String("guard", guard))
+}
+
+func (f *fsm) logCallbackError(ctx context.Context, e *Event, from, callback string, err error) {
+	f.log(ctx, f.parent.logLevels.Callback, "fsm: callback failed", e, from,
+		slog.String("callback", callback), slog.Any("error", err))
+}
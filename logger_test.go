@@ -0,0 +1,39 @@
+package fsm
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoggerGuardRejection(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	fsm := NewFSM(WithLogger(logger), WithLogLevels(LogLevels{
+		Attempt:   slog.LevelDebug,
+		Rejection: slog.LevelWarn,
+		Callback:  slog.LevelError,
+	}))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:   "make",
+		From:   []State{"started"},
+		To:     State("finished"),
+		Guards: []Guard{IsTestStructInvalid},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	_ = fsm.Fire(context.Background(), &TestStruct{State: State("started")}, "make")
+
+	out := buf.String()
+	if !strings.Contains(out, "level=DEBUG msg=\"fsm: fire\"") {
+		t.Errorf("expected attempt record, got %q", out)
+	}
+	if !strings.Contains(out, "level=WARN msg=\"fsm: guard rejected transition\"") || !strings.Contains(out, "event=make") {
+		t.Errorf("expected rejection record, got %q", out)
+	}
+}
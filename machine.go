@@ -0,0 +1,15 @@
+package fsm
+
+import "context"
+
+// Machine is the part of *FSM used by services to drive transitions. Depend
+// on it instead of *FSM to substitute fsmtest.MockMachine in unit tests.
+type Machine interface {
+	Fire(ctx context.Context, s interface{}, event string, options ...Option) error
+	MayFire(ctx context.Context, s interface{}, event string, options ...Option) (bool, error)
+	GetPermittedEvents(ctx context.Context, s interface{}, options ...Option) ([]string, error)
+	GetPermittedStates(ctx context.Context, s interface{}, options ...Option) ([]State, error)
+	Release(s interface{})
+}
+
+var _ Machine = (*FSM)(nil)
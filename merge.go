@@ -0,0 +1,68 @@
+package fsm
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// Clone returns a copy of events that can be modified without affecting
+// events, e.g. to derive a per-tenant workflow. Functions are shared.
+func (events Events) Clone() Events {
+	if events == nil {
+		return nil
+	}
+
+	c := make(Events, len(events))
+	for i, e := range events {
+		e.From = slices.Clone(e.From)
+		e.Guards = slices.Clone(e.Guards)
+		e.GuardNames = slices.Clone(e.GuardNames)
+		e.GuardCosts = maps.Clone(e.GuardCosts)
+		e.AllowedRoles = slices.Clone(e.AllowedRoles)
+		e.Labels = maps.Clone(e.Labels)
+		e.Targets = slices.Clone(e.Targets)
+		c[i] = e
+	}
+	return c
+}
+
+// Events func to return a copy of the transitions of the default machine of
+// tag, including those added with AddTransition
+func (f *FSM) Events(tag reflect.Type) (Events, error) {
+	machine, err := f.defaultMachine(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return Events(machine.table().events).Clone(), nil
+}
+
+// Merge returns base extended by overrides. A transition of overrides
+// replaces those of base with the same name leaving the same state; base
+// transitions left without a From state are dropped. Merge fails with
+// MergeConflictError if overrides declare the same name and From state
+// twice.
+func Merge(base, overrides Events) (Events, error) {
+	overridden := make(map[eventKey]bool)
+	for _, e := range overrides {
+		for _, src := range e.From {
+			key := eventKey{e.Name, src}
+			if overridden[key] {
+				return nil, MergeConflictError{Event: e.Name, State: string(src)}
+			}
+			overridden[key] = true
+		}
+	}
+
+	merged := make(Events, 0, len(base)+len(overrides))
+	for _, e := range base.Clone() {
+		e.From = slices.DeleteFunc(e.From, func(src State) bool {
+			return overridden[eventKey{e.Name, src}]
+		})
+		if len(e.From) > 0 {
+			merged = append(merged, e)
+		}
+	}
+	return append(merged, overrides.Clone()...), nil
+}
@@ -0,0 +1,80 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	base := Events{{
+		Name: "approve",
+		From: []State{"submitted", "escalated"},
+		To:   State("approved"),
+	}, {
+		Name: "reject",
+		From: []State{"submitted"},
+		To:   State("rejected"),
+	}}
+
+	merged, err := Merge(base, Events{{
+		Name: "approve",
+		From: []State{"submitted"},
+		To:   State("in_review"),
+	}})
+	if err != nil {
+		t.Errorf("Merge() error = %v", err)
+	}
+
+	fsm := NewFSM()
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", merged); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	for from, want := range map[State]State{"submitted": "in_review", "escalated": "approved"} {
+		s := &TestStruct{State: from}
+		if err := fsm.Fire(ctx, s, "approve"); err != nil || s.State != want {
+			t.Errorf("Fire() from %s = %v, %v, want %v", from, s.State, err, want)
+		}
+	}
+
+	if len(base[0].From) != 2 {
+		t.Errorf("Merge() modified base: %v", base[0].From)
+	}
+
+	events, err := fsm.Events(tag)
+	if err != nil || len(events) != 3 {
+		t.Errorf("Events() = %v, %v, want 3 transitions", events, err)
+	}
+}
+
+func TestMergeConflict(t *testing.T) {
+	_, err := Merge(nil, Events{
+		{Name: "approve", From: []State{"submitted"}, To: State("a")},
+		{Name: "approve", From: []State{"submitted"}, To: State("b")},
+	})
+	if !errors.As(err, new(MergeConflictError)) {
+		t.Errorf("Merge() error = %v, want MergeConflictError", err)
+	}
+}
+
+func TestEventsClone(t *testing.T) {
+	events := Events{{Name: "pay", From: []State{"created"}, To: State("paid"), Labels: map[string]string{"title": "Pay"}, Targets: []State{"paid"}}}
+	c := events.Clone()
+	c[0].From[0] = "pending"
+	c[0].Labels["title"] = "Pay now"
+	c[0].Targets[0] = "z"
+
+	if events[0].From[0] != "created" {
+		t.Errorf("Clone() shares From with the original")
+	}
+	if events[0].Labels["title"] != "Pay" {
+		t.Errorf("Clone() shares Labels with the original")
+	}
+	if events[0].Targets[0] != "paid" {
+		t.Errorf("Clone() shares Targets with the original")
+	}
+}
@@ -0,0 +1,91 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// MetricLabels identifies the transition a metric sample belongs to.
+type MetricLabels struct {
+	Type  string
+	Event string
+	From  string
+	To    string
+	// Tenant is the tenant the instance belongs to, see WithTenant.
+	Tenant string
+}
+
+// Metrics receives instrumentation for every Fire call.
+type Metrics interface {
+	// IncTransition counts a successful transition.
+	IncTransition(l MetricLabels)
+	// IncFailure counts a failed Fire call, kind classifies the error.
+	IncFailure(l MetricLabels, kind string)
+	// ObserveFire records the duration of a Fire call.
+	ObserveFire(l MetricLabels, d time.Duration)
+}
+
+// Error kinds reported to Metrics.IncFailure.
+const (
+	KindInvalidTransition = "invalid_transition"
+	KindUnknownEvent      = "unknown_event"
+	KindInternal          = "internal"
+	KindCanceled          = "canceled"
+	KindQuotaExceeded     = "quota_exceeded"
+	KindThrottled         = "throttled"
+	KindCallback          = "callback"
+	KindCompleted         = "completed"
+	KindFrozen            = "frozen"
+	KindPermissionDenied  = "permission_denied"
+	KindDraft             = "draft"
+	KindStateConflict     = "state_conflict"
+	KindInvariant         = "invariant"
+	KindStaleState        = "stale_state"
+	KindUnknownState      = "unknown_state"
+	KindTimeout           = "timeout"
+)
+
+type nopMetrics struct{}
+
+func (nopMetrics) IncTransition(MetricLabels)              {}
+func (nopMetrics) IncFailure(MetricLabels, string)         {}
+func (nopMetrics) ObserveFire(MetricLabels, time.Duration) {}
+
+// errorKind classifies err for metrics.
+func errorKind(err error) string {
+	switch {
+	case errors.As(err, new(InvalidTransitionError)), errors.As(err, new(UninitializedStateError)):
+		return KindInvalidTransition
+	case errors.As(err, new(UnknownEventError)):
+		return KindUnknownEvent
+	case errors.As(err, new(InternalError)), errors.As(err, new(UnregisteredError)), errors.As(err, new(IdentityRequiredError)):
+		return KindInternal
+	case errors.As(err, new(MachineCompletedError)):
+		return KindCompleted
+	case errors.As(err, new(PermissionDeniedError)):
+		return KindPermissionDenied
+	case errors.As(err, new(MachineFrozenError)):
+		return KindFrozen
+	case errors.As(err, new(DraftTransitionError)):
+		return KindDraft
+	case errors.As(err, new(StateConflictError)):
+		return KindStateConflict
+	case errors.As(err, new(StaleStateError)):
+		return KindStaleState
+	case errors.As(err, new(UnknownStateError)):
+		return KindUnknownState
+	case errors.As(err, new(InvariantViolationError)):
+		return KindInvariant
+	case errors.As(err, new(QuotaExceededError)):
+		return KindQuotaExceeded
+	case errors.As(err, new(ThrottledError)):
+		return KindThrottled
+	case errors.As(err, new(CallbackTimeoutError)):
+		return KindTimeout
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return KindCanceled
+	default:
+		return KindCallback
+	}
+}
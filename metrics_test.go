@@ -0,0 +1,57 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type recordingMetrics struct {
+	transitions []MetricLabels
+	failures    []string
+	observed    int
+}
+
+func (m *recordingMetrics) IncTransition(l MetricLabels) {
+	m.transitions = append(m.transitions, l)
+}
+
+func (m *recordingMetrics) IncFailure(l MetricLabels, kind string) {
+	m.failures = append(m.failures, kind)
+}
+
+func (m *recordingMetrics) ObserveFire(l MetricLabels, d time.Duration) {
+	m.observed++
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := &recordingMetrics{}
+	fsm := NewFSM(WithMetrics(metrics))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("started")}
+	if err := fsm.Fire(context.Background(), testStruct, "make"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if err := fsm.Fire(context.Background(), testStruct, "make"); err == nil {
+		t.Error("expected second Fire() to fail")
+	}
+
+	want := MetricLabels{Type: "*fsm.TestStruct", Event: "make", From: "started", To: "finished"}
+	if len(metrics.transitions) != 1 || metrics.transitions[0] != want {
+		t.Errorf("transitions = %v, want [%v]", metrics.transitions, want)
+	}
+	if len(metrics.failures) != 1 || metrics.failures[0] != KindUnknownEvent {
+		t.Errorf("failures = %v, want [%s]", metrics.failures, KindUnknownEvent)
+	}
+	if metrics.observed != 2 {
+		t.Errorf("observed = %d, want 2", metrics.observed)
+	}
+}
@@ -0,0 +1,27 @@
+package fsm
+
+import "context"
+
+// FireFunc fires event on s, the signature of FSM.FireE.
+type FireFunc func(ctx context.Context, s interface{}, event string, options ...Option) (FireResult, error)
+
+// Middleware wraps transition execution the way net/http middleware wraps
+// handlers, e.g. for retries, tracing, authorization or rate limiting. It
+// may call next zero or more times.
+type Middleware func(next FireFunc) FireFunc
+
+// WithMiddleware installs middleware around every Fire, FireE and FireOn
+// call. The first middleware is the outermost.
+func WithMiddleware(middleware ...Middleware) FSMOption {
+	return func(f *FSM) {
+		f.middleware = append(f.middleware, middleware...)
+	}
+}
+
+// chain wraps fire in the installed middleware.
+func (f *FSM) chain(fire FireFunc) FireFunc {
+	for i := len(f.middleware) - 1; i >= 0; i-- {
+		fire = f.middleware[i](fire)
+	}
+	return fire
+}
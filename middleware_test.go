@@ -0,0 +1,64 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next FireFunc) FireFunc {
+			return func(ctx context.Context, s interface{}, event string, options ...Option) (FireResult, error) {
+				order = append(order, name+">"+event)
+				result, err := next(ctx, s, event, options...)
+				order = append(order, name+"<"+event)
+				return result, err
+			}
+		}
+	}
+
+	errDenied := errors.New("denied")
+	deny := func(next FireFunc) FireFunc {
+		return func(ctx context.Context, s interface{}, event string, options ...Option) (FireResult, error) {
+			if event == "forbidden" {
+				return Failed, errDenied
+			}
+			return next(ctx, s, event, options...)
+		}
+	}
+
+	fsm := NewFSM(WithMiddleware(trace("outer"), trace("inner")), WithMiddleware(deny))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+	}, {
+		Name: "forbidden",
+		From: []State{"started"},
+		To:   State("finished"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("started")}
+	if err := fsm.Fire(context.Background(), testStruct, "forbidden"); err != errDenied {
+		t.Errorf("Fire() error = %v, want %v", err, errDenied)
+	}
+	if err := fsm.FireOn(context.Background(), testStruct, "State", "make"); err != nil {
+		t.Errorf("FireOn() error = %v", err)
+	}
+
+	want := []string{
+		"outer>forbidden", "inner>forbidden", "inner<forbidden", "outer<forbidden",
+		"outer>make", "inner>make", "inner<make", "outer<make",
+	}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("middleware ran %v, want %v", order, want)
+	}
+	if testStruct.State != State("finished") {
+		t.Errorf("state = %s, want finished", testStruct.State)
+	}
+}
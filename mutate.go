@@ -0,0 +1,75 @@
+package fsm
+
+import (
+	"reflect"
+	"slices"
+)
+
+// AddTransition func to add e to the default machine of tag while events
+// are fired, e.g. to enable a tenant specific transition behind a feature
+// flag. The transitions are validated and compiled again as by Register;
+// on error the machine is left unchanged. Transitions already in flight
+// finish with the previous definition
+func (f *FSM) AddTransition(tag reflect.Type, e EventTransition) error {
+	machine, err := f.defaultMachine(tag)
+	if err != nil {
+		return err
+	}
+
+	return machine.mutate(func(events []EventTransition) ([]EventTransition, error) {
+		return append(events, e), nil
+	})
+}
+
+// RemoveTransition func to remove the transitions of the event name leaving
+// from of the default machine of tag while events are fired. It returns
+// UnknownEventError if there are none
+func (f *FSM) RemoveTransition(tag reflect.Type, name string, from State) error {
+	machine, err := f.defaultMachine(tag)
+	if err != nil {
+		return err
+	}
+
+	return machine.mutate(func(events []EventTransition) ([]EventTransition, error) {
+		found := false
+		kept := events[:0]
+		for _, e := range events {
+			if e.Name == name && slices.Contains(e.From, from) {
+				found = true
+				e.From = slices.DeleteFunc(slices.Clone(e.From), func(s State) bool { return s == from })
+				if len(e.From) == 0 {
+					continue
+				}
+			}
+			kept = append(kept, e)
+		}
+
+		if !found {
+			return nil, UnknownEventError{name}
+		}
+		return kept, nil
+	})
+}
+
+// mutate replaces the transition table of f with the one compiled from the
+// events fn returns. fn gets a copy of the current events and may be called
+// again if another mutation raced with it.
+func (f *fsm) mutate(fn func(events []EventTransition) ([]EventTransition, error)) error {
+	for {
+		old := f.tables.Load()
+
+		events, err := fn(slices.Clone(old.events))
+		if err != nil {
+			return err
+		}
+
+		t, err := f.compile(events)
+		if err != nil {
+			return err
+		}
+
+		if f.tables.CompareAndSwap(old, t) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestAddRemoveTransition(t *testing.T) {
+	fsm := NewFSM()
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "pay",
+		From: []State{"created", "pending"},
+		To:   State("paid"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := fsm.AddTransition(tag, EventTransition{
+		Name: "hold",
+		From: []State{"created"},
+		To:   State("on_hold"),
+	}); err != nil {
+		t.Errorf("AddTransition() error = %v", err)
+	}
+
+	s := &TestStruct{State: "created"}
+	if err := fsm.Fire(ctx, s, "hold"); err != nil || s.State != "on_hold" {
+		t.Errorf("Fire() = %v, %v, want on_hold", s.State, err)
+	}
+
+	if err := fsm.RemoveTransition(tag, "pay", "created"); err != nil {
+		t.Errorf("RemoveTransition() error = %v", err)
+	}
+	if ok, _ := fsm.MayFire(ctx, &TestStruct{State: "created"}, "pay"); ok {
+		t.Errorf("MayFire() = true after RemoveTransition()")
+	}
+	if ok, _ := fsm.MayFire(ctx, &TestStruct{State: "pending"}, "pay"); !ok {
+		t.Errorf("MayFire() = false from the state that was kept")
+	}
+
+	if err := fsm.RemoveTransition(tag, "pay", "created"); !errors.As(err, new(UnknownEventError)) {
+		t.Errorf("RemoveTransition() error = %v, want UnknownEventError", err)
+	}
+	if err := fsm.AddTransition(tag, EventTransition{Name: "broken", From: []State{"created"}}); !errors.As(err, new(SchemaError)) {
+		t.Errorf("AddTransition() error = %v, want SchemaError", err)
+	}
+}
+
+func TestAddTransitionConcurrent(t *testing.T) {
+	fsm := NewFSM()
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fsm.AddTransition(tag, EventTransition{Name: name, From: []State{"start"}, To: State(name)}); err != nil {
+				t.Errorf("AddTransition() error = %v", err)
+			}
+			fsm.Fire(context.Background(), &TestStruct{State: "start"}, name)
+		}()
+	}
+	wg.Wait()
+
+	events, _ := fsm.GetPermittedEvents(context.Background(), &TestStruct{State: "start"})
+	if len(events) != 4 {
+		t.Errorf("GetPermittedEvents() = %v, want 4 events", events)
+	}
+}
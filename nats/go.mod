@@ -0,0 +1,19 @@
+module github.com/ceearrashee/fsm/nats
+
+go 1.25
+
+require (
+	github.com/ceearrashee/fsm v0.0.0
+	github.com/nats-io/nats.go v1.43.0
+)
+
+require (
+	github.com/klauspost/compress v1.18.0 // indirect
+	github.com/nats-io/nkeys v0.4.11 // indirect
+	github.com/nats-io/nuid v1.0.1 // indirect
+	golang.org/x/crypto v0.37.0 // indirect
+	golang.org/x/sys v0.32.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/ceearrashee/fsm => ../
@@ -0,0 +1,45 @@
+// Package nats implements fsm.Publisher on top of the NATS client.
+package nats
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ceearrashee/fsm"
+	natsgo "github.com/nats-io/nats.go"
+)
+
+// Conn is the part of *natsgo.Conn the Publisher uses.
+type Conn interface {
+	PublishMsg(msg *natsgo.Msg) error
+}
+
+// Publisher publishes every transition as a JSON message to a subject.
+type Publisher struct {
+	conn    Conn
+	subject string
+}
+
+// New creates a Publisher sending to subject over conn.
+func New(conn Conn, subject string) *Publisher {
+	return &Publisher{conn: conn, subject: subject}
+}
+
+// Publish implements fsm.Publisher. The event name is sent in the
+// Fsm-Event header so subscribers can filter without decoding.
+func (p *Publisher) Publish(ctx context.Context, event fsm.TransitionEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	msg := natsgo.NewMsg(p.subject)
+	msg.Data = data
+	msg.Header.Set("Fsm-Type", event.Type)
+	msg.Header.Set("Fsm-Event", event.Event)
+	return p.conn.PublishMsg(msg)
+}
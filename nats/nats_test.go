@@ -0,0 +1,66 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ceearrashee/fsm"
+	natsgo "github.com/nats-io/nats.go"
+)
+
+type fakeConn struct {
+	msgs []*natsgo.Msg
+	err  error
+}
+
+func (c *fakeConn) PublishMsg(msg *natsgo.Msg) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.msgs = append(c.msgs, msg)
+	return nil
+}
+
+func TestPublisher(t *testing.T) {
+	conn := &fakeConn{}
+	event := fsm.TransitionEvent{Type: "order", ID: "o-1", Event: "pay", From: "created", To: "paid", Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+
+	if err := New(conn, "orders.transitions").Publish(context.Background(), event); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if len(conn.msgs) != 1 {
+		t.Fatalf("published %d messages, want 1", len(conn.msgs))
+	}
+
+	msg := conn.msgs[0]
+	var got fsm.TransitionEvent
+	if err := json.Unmarshal(msg.Data, &got); err != nil {
+		t.Errorf("message data error = %v", err)
+	}
+	if msg.Subject != "orders.transitions" || !reflect.DeepEqual(got, event) {
+		t.Errorf("published %+v to %q, want %+v to orders.transitions", got, msg.Subject, event)
+	}
+	if msg.Header.Get("Fsm-Type") != "order" || msg.Header.Get("Fsm-Event") != "pay" {
+		t.Errorf("headers = %v, want Fsm-Type order and Fsm-Event pay", msg.Header)
+	}
+}
+
+func TestPublisherErrors(t *testing.T) {
+	conn := &fakeConn{}
+	p := New(conn, "orders.transitions")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.Publish(ctx, fsm.TransitionEvent{Event: "pay"}); !errors.Is(err, context.Canceled) || len(conn.msgs) != 0 {
+		t.Errorf("Publish() with a done ctx = %v, published %d, want context.Canceled", err, len(conn.msgs))
+	}
+
+	conn.err = errors.New("connection closed")
+	if err := p.Publish(context.Background(), fsm.TransitionEvent{Event: "pay"}); !errors.Is(err, conn.err) {
+		t.Errorf("Publish() error = %v, want %v", err, conn.err)
+	}
+}
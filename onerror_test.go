@@ -0,0 +1,43 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestOnErrorTransition(t *testing.T) {
+	errPayment := errors.New("payment declined")
+
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:    "make",
+		From:    []State{"started"},
+		To:      State("finished"),
+		OnError: "fail",
+		Before: func(ctx context.Context, e *Event) error {
+			return errPayment
+		},
+	}, {
+		Name: "fail",
+		From: []State{"started"},
+		To:   State("failed"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("started")}
+	result, err := fsm.FireE(context.Background(), testStruct, "make")
+	if !errors.Is(err, errPayment) {
+		t.Errorf("expected callback error, got %v", err)
+	}
+
+	if result != Compensated {
+		t.Errorf("expected result 'compensated', got '%s'", result)
+	}
+
+	if testStruct.State != State("failed") {
+		t.Errorf("expected state 'failed', got '%s'", testStruct.State)
+	}
+}
@@ -1,7 +1,24 @@
 package fsm
 
+import (
+	"log/slog"
+	"time"
+)
+
 type Options struct {
-	SkipGuards bool
+	SkipGuards  bool
+	Assumptions map[string]bool
+	DedupKey    string
+	Reason      string
+	Meta        map[string]interface{}
+	// IncludeDraft is set by IncludeDraft.
+	IncludeDraft bool
+	// Version is set by WithVersion, zero means unset.
+	Version int
+	// IfState is set by IfState, empty means unset.
+	IfState State
+	// Args is set by WithArgs.
+	Args []interface{}
 }
 
 type Option func(*Options)
@@ -11,3 +28,153 @@ func SkipGuard(value bool) Option {
 		args.SkipGuards = value
 	}
 }
+
+// AssumeGuard evaluates the guard named name (see GuardName) as if it
+// returned pass, without calling it. Use it with MayFire, GetPermittedEvents
+// or BlockingGuards to answer "what if" questions about an instance.
+func AssumeGuard(name string, pass bool) Option {
+	return func(args *Options) {
+		if args.Assumptions == nil {
+			args.Assumptions = make(map[string]bool)
+		}
+		args.Assumptions[name] = pass
+	}
+}
+
+// WithDedupKey makes Fire return NoOp for a repeat of an earlier successful
+// call with the same key, instance and event instead of firing again, or
+// the error of an earlier rejection such as UnknownEventError. Outcomes are
+// kept for the window set by WithDedupWindow, or forever without one. Calls
+// failing otherwise, e.g. with a callback error, may be retried. Instances
+// are recognised by WithIdentity or WithIDFunc, Fire fails with
+// IdentityRequiredError without either.
+func WithDedupKey(key string) Option {
+	return func(args *Options) {
+		args.DedupKey = key
+	}
+}
+
+// WithArgs passes args to the guards and callbacks of the transition in
+// Event.Args.
+func WithArgs(values ...interface{}) Option {
+	return func(args *Options) {
+		args.Args = values
+	}
+}
+
+// WithIdempotencyKey is WithDedupKey, e.g. for the delivery ID of a webhook
+// that may be delivered more than once.
+func WithIdempotencyKey(key string) Option {
+	return WithDedupKey(key)
+}
+
+// WithReason records why the event is fired, e.g. "fraud" for a manual
+// rejection. It is exposed as Event.Reason and kept in the history.
+func WithReason(reason string) Option {
+	return func(args *Options) {
+		args.Reason = reason
+	}
+}
+
+// WithMeta attaches metadata to the transition, exposed as Event.Meta and
+// kept in the history. Values must be JSON encodable to be persisted.
+func WithMeta(meta map[string]interface{}) Option {
+	return func(args *Options) {
+		args.Meta = meta
+	}
+}
+
+// IfState makes Fire fail with StaleStateError unless the instance is in
+// state when its lock is taken, e.g. the state a request handler loaded and
+// showed to the user, so concurrent handlers can't both act on it.
+func IfState(state State) Option {
+	return func(args *Options) {
+		args.IfState = state
+	}
+}
+
+// FSMOption configures an FSM created by NewFSM.
+type FSMOption func(*FSM)
+
+// WithMetrics reports transitions, failures and Fire durations to m.
+func WithMetrics(m Metrics) FSMOption {
+	return func(f *FSM) {
+		f.metrics = m
+	}
+}
+
+// WithStateStore persists per-instance runtime data in store instead of
+// process memory.
+func WithStateStore(store StateStore) FSMOption {
+	return func(f *FSM) {
+		f.store = store
+	}
+}
+
+// WithLockProvider sets the LockProvider locking the resources declared by
+// EventTransition.Locks, an in-process MemoryLockProvider by default.
+func WithLockProvider(locks LockProvider) FSMOption {
+	return func(f *FSM) {
+		f.locks = locks
+	}
+}
+
+// WithLogger logs transition attempts, guard rejections and callback errors
+// to logger at DefaultLogLevels.
+func WithLogger(logger *slog.Logger) FSMOption {
+	return func(f *FSM) {
+		f.logger = logger
+	}
+}
+
+// WithLogLevels overrides the levels used by WithLogger.
+func WithLogLevels(levels LogLevels) FSMOption {
+	return func(f *FSM) {
+		f.logLevels = levels
+	}
+}
+
+// WithHistory keeps the last max transitions of every instance, see
+// FSM.History. Register fails with IdentityRequiredError for machines
+// without WithIdentity unless WithIDFunc is set.
+func WithHistory(max int) FSMOption {
+	return func(f *FSM) {
+		f.historyLimit = max
+	}
+}
+
+// WithAuditSink streams every history entry to sink.
+func WithAuditSink(sink AuditSink) FSMOption {
+	return func(f *FSM) {
+		f.auditSink = sink
+	}
+}
+
+// WithRegistry resolves EventTransition.GuardNames in registry and reports
+// registered guards under their names.
+func WithRegistry(registry *Registry) FSMOption {
+	return func(f *FSM) {
+		f.registry = registry
+	}
+}
+
+// WithDedupWindow remembers the outcome of calls made WithDedupKey for ttl.
+// Register fails with IdentityRequiredError for machines without WithIdentity
+// unless WithIDFunc is set.
+func WithDedupWindow(ttl time.Duration) FSMOption {
+	return func(f *FSM) {
+		f.dedupWindow = ttl
+	}
+}
+
+// WithDedupStore remembers the outcome of calls made WithDedupKey in store
+// instead of the StateStore, e.g. one shared by all processes receiving the
+// same webhooks. Without WithDedupWindow outcomes are kept forever. Calls
+// are only serialized within a process, so concurrent duplicates reaching
+// different processes may still both fire. Like WithDedupWindow it requires
+// WithIdentity or WithIDFunc.
+func WithDedupStore(store StateStore) FSMOption {
+	return func(f *FSM) {
+		f.dedupStore = store
+	}
+}
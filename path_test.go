@@ -0,0 +1,55 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type Audited struct {
+	Status State
+}
+
+type Ticket struct {
+	Audited
+	Meta struct {
+		Review *struct {
+			Status State
+		}
+	}
+}
+
+func TestColumnPath(t *testing.T) {
+	tag := reflect.TypeOf((*Ticket)(nil))
+	fsm := NewFSM()
+	if err := fsm.Register(tag, "Status", Events{{
+		Name: "open",
+		From: []State{"new"},
+		To:   State("open"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := fsm.Register(tag, "Meta.Review.Status", Events{{
+		Name: "approve",
+		From: []State{"pending"},
+		To:   State("approved"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	ticket := &Ticket{Audited: Audited{Status: "new"}}
+	if err := fsm.Fire(ctx, ticket, "open"); err != nil || ticket.Status != "open" {
+		t.Errorf("Fire() on the embedded field = %v, Status = %v", err, ticket.Status)
+	}
+
+	if err := fsm.FireOn(ctx, ticket, "Meta.Review.Status", "approve"); !errors.As(err, new(InternalError)) {
+		t.Errorf("FireOn() through a nil pointer error = %v, want InternalError", err)
+	}
+
+	ticket.Meta.Review = &struct{ Status State }{Status: "pending"}
+	if err := fsm.FireOn(ctx, ticket, "Meta.Review.Status", "approve"); err != nil || ticket.Meta.Review.Status != "approved" {
+		t.Errorf("FireOn() on the nested field = %v, Status = %v", err, ticket.Meta.Review.Status)
+	}
+}
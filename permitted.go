@@ -0,0 +1,95 @@
+package fsm
+
+import (
+	"reflect"
+	"sort"
+)
+
+// PermittedSet is the precomputed set of events declared from a single
+// state. It does not depend on any instance, is immutable and safe to share
+// between goroutines. The set of a final state is empty.
+type PermittedSet struct {
+	state        State
+	destinations map[string]State
+	unguarded    []string
+	guarded      []string
+}
+
+// State returns the source state the set was compiled for.
+func (p *PermittedSet) State() State {
+	return p.state
+}
+
+// Events returns the events that are always permitted from the state because
+// they have neither guards nor AllowedRoles.
+func (p *PermittedSet) Events() []string {
+	return append([]string(nil), p.unguarded...)
+}
+
+// Guarded returns the events whose permission depends on guards or
+// AllowedRoles and must be checked against an instance and caller with
+// MayFire.
+func (p *PermittedSet) Guarded() []string {
+	return append([]string(nil), p.guarded...)
+}
+
+// Has reports whether event is declared from the state, guarded or not.
+func (p *PermittedSet) Has(event string) bool {
+	_, ok := p.destinations[event]
+	return ok
+}
+
+// Destination returns the state event leads to.
+func (p *PermittedSet) Destination(event string) (State, bool) {
+	to, ok := p.destinations[event]
+	return to, ok
+}
+
+// guarded reports whether any branch of key has guards or roles.
+func (f *fsm) guarded(key eventKey) bool {
+	t := f.table()
+	for _, br := range t.branches[key] {
+		if len(br.guards) > 0 || len(br.roles) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fsm) permittedSet(state State) *PermittedSet {
+	// Not cached, states can be marked final after the set was compiled.
+	if f.isFinal(state) {
+		return &PermittedSet{state: state}
+	}
+
+	t := f.table()
+	if p, ok := t.permitted.Load(state); ok {
+		return p.(*PermittedSet)
+	}
+
+	p := &PermittedSet{state: state, destinations: make(map[string]State)}
+	for _, event := range t.initialStates[state] {
+		p.destinations[event] = t.transitions[eventKey{event, state}]
+		if f.guarded(eventKey{event, state}) {
+			p.guarded = append(p.guarded, event)
+		} else {
+			p.unguarded = append(p.unguarded, event)
+		}
+	}
+	sort.Strings(p.unguarded)
+	sort.Strings(p.guarded)
+
+	actual, _ := t.permitted.LoadOrStore(state, p)
+	return actual.(*PermittedSet)
+}
+
+// Permitted func to return the cached PermittedSet of state for the default
+// machine of tag
+func (f *FSM) Permitted(tag reflect.Type, state State) (*PermittedSet, error) {
+	machines := f.machinesOf(tag)
+	if len(machines) == 0 {
+		return nil, InternalError{}
+	}
+
+	return machines[0].permittedSet(state), nil
+}
@@ -0,0 +1,73 @@
+package fsm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPermittedSet(t *testing.T) {
+	tag := reflect.TypeOf((*TestStruct)(nil))
+
+	fsm := NewFSM()
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+	}, {
+		Name:   "cancel",
+		From:   []State{"started"},
+		To:     State("canceled"),
+		Guards: []Guard{IsTestStructValid},
+	}, {
+		Name:         "approve",
+		From:         []State{"started"},
+		To:           State("approved"),
+		AllowedRoles: []string{"manager"},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	set, err := fsm.Permitted(tag, State("started"))
+	if err != nil {
+		t.Errorf("fsm.Permitted() error = %v", err)
+	}
+
+	if events := set.Events(); len(events) != 1 || events[0] != "make" {
+		t.Errorf("expected unguarded events to be ['make'], got %v", events)
+	}
+	if guarded := set.Guarded(); !reflect.DeepEqual(guarded, []string{"approve", "cancel"}) {
+		t.Errorf("expected guarded events to be ['approve' 'cancel'], got %v", guarded)
+	}
+	if to, ok := set.Destination("cancel"); !ok || to != "canceled" {
+		t.Errorf("expected 'cancel' to lead to 'canceled', got %v", to)
+	}
+
+	again, _ := fsm.Permitted(tag, State("started"))
+	if again != set {
+		t.Error("expected PermittedSet to be cached")
+	}
+}
+
+func TestPermittedSetFinal(t *testing.T) {
+	tag := reflect.TypeOf((*TestStruct)(nil))
+
+	fsm := NewFSM()
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "reopen",
+		From: []State{"finished"},
+		To:   State("started"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := fsm.MarkFinal(tag, "finished"); err != nil {
+		t.Errorf("fsm.MarkFinal() error = %v", err)
+	}
+
+	set, err := fsm.Permitted(tag, State("finished"))
+	if err != nil {
+		t.Errorf("fsm.Permitted() error = %v", err)
+	}
+	if events, guarded := set.Events(), set.Guarded(); len(events) != 0 || len(guarded) != 0 || set.Has("reopen") {
+		t.Errorf("expected no events from a final state, got %v and %v", events, guarded)
+	}
+}
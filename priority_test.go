@@ -0,0 +1,152 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEventPriority(t *testing.T) {
+	vip := func(ctx context.Context, e *Event) (bool, error) {
+		return e.Source.(*TestStruct).State == State("started"), nil
+	}
+	never := func(ctx context.Context, e *Event) (bool, error) {
+		return false, nil
+	}
+
+	var ran []string
+	callback := func(name string) func(context.Context, *Event) error {
+		return func(ctx context.Context, e *Event) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:  "route",
+		From:  []State{"started"},
+		To:    State("standard"),
+		After: callback("standard"),
+	}, {
+		Name:     "route",
+		From:     []State{"started"},
+		To:       State("blocked"),
+		Priority: 2,
+		Guards:   []Guard{never},
+		After:    callback("blocked"),
+	}, {
+		Name:     "route",
+		From:     []State{"started"},
+		To:       State("express"),
+		Priority: 1,
+		Guards:   []Guard{vip},
+		After:    callback("express"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("started")}
+	if err := fsm.Fire(context.Background(), testStruct, "route"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if testStruct.State != State("express") {
+		t.Errorf("state = %s, want express", testStruct.State)
+	}
+	if !reflect.DeepEqual(ran, []string{"express"}) {
+		t.Errorf("callbacks ran = %v, want [express]", ran)
+	}
+}
+
+func TestEventPriorityNonePasses(t *testing.T) {
+	never := func(ctx context.Context, e *Event) (bool, error) {
+		return false, nil
+	}
+
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:   "route",
+		From:   []State{"started"},
+		To:     State("a"),
+		Guards: []Guard{never},
+	}, {
+		Name:     "route",
+		From:     []State{"started"},
+		To:       State("b"),
+		Priority: 1,
+		Guards:   []Guard{never},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("started")}
+	if err := fsm.Fire(context.Background(), testStruct, "route"); !errors.As(err, new(InvalidTransitionError)) {
+		t.Errorf("Fire() error = %v, want InvalidTransitionError", err)
+	}
+	if ok, _ := fsm.MayFire(context.Background(), testStruct, "route"); ok {
+		t.Errorf("MayFire() = true, want false")
+	}
+}
+
+func TestRejectAmbiguous(t *testing.T) {
+	fsm := NewFSM()
+	err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "route",
+		From: []State{"started", "paused"},
+		To:   State("a"),
+	}, {
+		Name: "route",
+		From: []State{"paused"},
+		To:   State("b"),
+	}}, RejectAmbiguous())
+	if !errors.As(err, new(AmbiguousTransitionError)) {
+		t.Errorf("fsm.Register() error = %v, want AmbiguousTransitionError", err)
+	}
+}
+
+func TestDefaultBranch(t *testing.T) {
+	highRisk := func(ctx context.Context, e *Event) (bool, error) {
+		return e.Reason == "high risk", nil
+	}
+	lowRisk := func(ctx context.Context, e *Event) (bool, error) {
+		return e.Reason == "low risk", nil
+	}
+
+	events := Events{{
+		Name:    "submit",
+		From:    []State{"draft"},
+		To:      State("manual_review"),
+		Default: true,
+	}, {
+		Name:   "submit",
+		From:   []State{"draft"},
+		To:     State("rejected"),
+		Guards: []Guard{highRisk},
+	}, {
+		Name:     "submit",
+		From:     []State{"draft"},
+		To:       State("approved"),
+		Priority: 1,
+		Guards:   []Guard{lowRisk},
+	}}
+
+	fsm := NewFSM()
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", events, RejectAmbiguous()); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	for reason, want := range map[string]State{"low risk": "approved", "high risk": "rejected", "": "manual_review"} {
+		testStruct := &TestStruct{State: State("draft")}
+		if err := fsm.Fire(ctx, testStruct, "submit", WithReason(reason)); err != nil || testStruct.State != want {
+			t.Errorf("Fire() with %q = %v, %v, want %v", reason, testStruct.State, err, want)
+		}
+	}
+
+	events[1].Default = true
+	if err := fsm.Register(tag, "State", events); !errors.As(err, new(AmbiguousTransitionError)) {
+		t.Errorf("fsm.Register() with two defaults error = %v, want AmbiguousTransitionError", err)
+	}
+}
@@ -0,0 +1,116 @@
+// Package prometheus implements fsm.Metrics, fsm.StuckMetrics and
+// fsm.OccupancyMetrics on top of the Prometheus client.
+package prometheus
+
+import (
+	"time"
+
+	"github.com/ceearrashee/fsm"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics is a Prometheus backed fsm.Metrics.
+type Metrics struct {
+	transitions *prom.CounterVec
+	failures    *prom.CounterVec
+	duration    *prom.HistogramVec
+	stuck       *prom.GaugeVec
+	occupancy   *prom.GaugeVec
+	tenant      bool
+}
+
+// Option configures Metrics.
+type Option func(*Metrics)
+
+// WithTenantLabel adds a "tenant" label to every collector, see
+// fsm.WithTenant.
+func WithTenantLabel() Option {
+	return func(m *Metrics) {
+		m.tenant = true
+	}
+}
+
+// New creates Metrics and registers its collectors with reg.
+func New(reg prom.Registerer, namespace string, options ...Option) (*Metrics, error) {
+	m := &Metrics{}
+	for _, option := range options {
+		option(m)
+	}
+
+	labels := func(names ...string) []string {
+		if m.tenant {
+			names = append(names, "tenant")
+		}
+		return names
+	}
+
+	m.transitions = prom.NewCounterVec(prom.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "fsm",
+		Name:      "transitions_total",
+		Help:      "Number of successful state transitions.",
+	}, labels("type", "event", "from", "to"))
+	m.failures = prom.NewCounterVec(prom.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "fsm",
+		Name:      "failures_total",
+		Help:      "Number of failed Fire calls by error kind.",
+	}, labels("type", "event", "kind"))
+	m.duration = prom.NewHistogramVec(prom.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "fsm",
+		Name:      "fire_duration_seconds",
+		Help:      "Duration of Fire calls.",
+		Buckets:   prom.DefBuckets,
+	}, labels("type", "event"))
+	m.stuck = prom.NewGaugeVec(prom.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "fsm",
+		Name:      "stuck_instances",
+		Help:      "Number of stuck instances found by the last fsm.StuckDetector pass.",
+	}, labels("type", "state", "reason"))
+	m.occupancy = prom.NewGaugeVec(prom.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "fsm",
+		Name:      "instances",
+		Help:      "Number of instances per state listed by the last fsm.StuckDetector pass.",
+	}, labels("type", "state"))
+
+	for _, c := range []prom.Collector{m.transitions, m.failures, m.duration, m.stuck, m.occupancy} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// values appends the tenant of l to values if the tenant label is enabled.
+func (m *Metrics) values(l fsm.MetricLabels, values ...string) []string {
+	if m.tenant {
+		values = append(values, l.Tenant)
+	}
+	return values
+}
+
+func (m *Metrics) IncTransition(l fsm.MetricLabels) {
+	m.transitions.WithLabelValues(m.values(l, l.Type, l.Event, l.From, l.To)...).Inc()
+}
+
+func (m *Metrics) IncFailure(l fsm.MetricLabels, kind string) {
+	m.failures.WithLabelValues(m.values(l, l.Type, l.Event, kind)...).Inc()
+}
+
+func (m *Metrics) ObserveFire(l fsm.MetricLabels, d time.Duration) {
+	m.duration.WithLabelValues(m.values(l, l.Type, l.Event)...).Observe(d.Seconds())
+}
+
+func (m *Metrics) SetStuck(typ, tenant string, state fsm.State, reason fsm.StuckReason, n int) {
+	l := fsm.MetricLabels{Tenant: tenant}
+	m.stuck.WithLabelValues(m.values(l, typ, string(state), string(reason))...).Set(float64(n))
+}
+
+func (m *Metrics) SetOccupancy(typ, tenant string, state fsm.State, n int) {
+	l := fsm.MetricLabels{Tenant: tenant}
+	m.occupancy.WithLabelValues(m.values(l, typ, string(state))...).Set(float64(n))
+}
@@ -0,0 +1,117 @@
+package prometheus
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ceearrashee/fsm"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// value returns the value of the series of the metric name with labels
+// gathered from reg, and whether there is one.
+func value(t *testing.T, reg *prom.Registry, name string, labels map[string]string) (float64, bool) {
+	t.Helper()
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			got := make(map[string]string)
+			for _, pair := range metric.GetLabel() {
+				got[pair.GetName()] = pair.GetValue()
+			}
+			if !reflect.DeepEqual(got, labels) {
+				continue
+			}
+			switch {
+			case metric.GetCounter() != nil:
+				return metric.GetCounter().GetValue(), true
+			case metric.GetGauge() != nil:
+				return metric.GetGauge().GetValue(), true
+			case metric.GetHistogram() != nil:
+				return float64(metric.GetHistogram().GetSampleCount()), true
+			}
+		}
+	}
+	return 0, false
+}
+
+type Order struct {
+	State fsm.State
+}
+
+func TestMetrics(t *testing.T) {
+	reg := prom.NewRegistry()
+	metrics, err := New(reg, "app")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := New(reg, "app"); err == nil {
+		t.Error("New() registering twice error = nil")
+	}
+
+	f := fsm.NewFSM(fsm.WithMetrics(metrics))
+	if err := f.Register(reflect.TypeOf((*Order)(nil)), "State", fsm.Events{{
+		Name: "pay",
+		From: []fsm.State{"created"},
+		To:   fsm.State("paid"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	order := &Order{State: "created"}
+	if err := f.Fire(ctx, order, "pay"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if err := f.Fire(ctx, order, "pay"); err == nil {
+		t.Error("Fire() again error = nil")
+	}
+
+	typ := "*prometheus.Order"
+	if n, _ := value(t, reg, "app_fsm_transitions_total", map[string]string{"type": typ, "event": "pay", "from": "created", "to": "paid"}); n != 1 {
+		t.Errorf("transitions_total = %v, want 1", n)
+	}
+	if n, _ := value(t, reg, "app_fsm_fire_duration_seconds", map[string]string{"type": typ, "event": "pay"}); n != 2 {
+		t.Errorf("fire_duration_seconds observed %v calls, want 2", n)
+	}
+	if n, _ := value(t, reg, "app_fsm_failures_total", map[string]string{"type": typ, "event": "pay", "kind": fsm.KindUnknownEvent}); n != 1 {
+		t.Errorf("failures_total = %v, want 1", n)
+	}
+}
+
+func TestMetricsTenantGauges(t *testing.T) {
+	reg := prom.NewRegistry()
+	metrics, err := New(reg, "app", WithTenantLabel())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	metrics.SetStuck("order", "acme", "paid", fsm.StuckSLABreached, 3)
+	metrics.SetOccupancy("order", "acme", "paid", 5)
+	metrics.IncFailure(fsm.MetricLabels{Type: "order", Event: "ship", Tenant: "acme"}, fsm.KindInvalidTransition)
+	metrics.ObserveFire(fsm.MetricLabels{Type: "order", Event: "ship", Tenant: "acme"}, time.Millisecond)
+
+	for _, tt := range []struct {
+		name   string
+		labels map[string]string
+		want   float64
+	}{
+		{"app_fsm_stuck_instances", map[string]string{"type": "order", "state": "paid", "reason": string(fsm.StuckSLABreached), "tenant": "acme"}, 3},
+		{"app_fsm_instances", map[string]string{"type": "order", "state": "paid", "tenant": "acme"}, 5},
+		{"app_fsm_failures_total", map[string]string{"type": "order", "event": "ship", "kind": fsm.KindInvalidTransition, "tenant": "acme"}, 1},
+		{"app_fsm_fire_duration_seconds", map[string]string{"type": "order", "event": "ship", "tenant": "acme"}, 1},
+	} {
+		if got, ok := value(t, reg, tt.name, tt.labels); !ok || got != tt.want {
+			t.Errorf("%s%v = %v, %v, want %v", tt.name, tt.labels, got, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+)
+
+// Propagation fires Event on the instances Related returns whenever an
+// instance enters a state, e.g. cancelling the line items of a cancelled
+// order. Related instances may be of any registered type with an identity,
+// see WithIdentity and WithIDFunc.
+type Propagation struct {
+	Event   string
+	Related func(ctx context.Context, s interface{}) ([]interface{}, error)
+}
+
+// Propagate func to add a propagation rule run after every successful
+// transition of the default machine of tag into state. The event is
+// scheduled on the related instances to fire right away, so it is kept by
+// the ScheduleStore and run by RunScheduler or FireDue, which reports its
+// failures. Failures to schedule it are returned as PropagationError
+func (f *FSM) Propagate(tag reflect.Type, state State, p Propagation) error {
+	return f.updateStates(tag, func(c *stateConfig) {
+		c.propagations[state] = append(c.propagations[state], p)
+	})
+}
+
+// propagate schedules the events of the propagation rules of state for s in
+// the order the rules were added, stopping at the first rule failing.
+func (f *fsm) propagate(ctx context.Context, s interface{}, state State) error {
+	for _, p := range f.stateConfig().propagations[state] {
+		related, err := p.Related(ctx, s)
+		if err != nil {
+			return err
+		}
+
+		errs := make(map[int]error)
+		now := f.parent.now()
+		for i, r := range related {
+			if _, err := f.parent.FireAt(ctx, r, p.Event, now); err != nil {
+				errs[i] = err
+			}
+		}
+		if len(errs) > 0 {
+			return PropagationError{Event: p.Event, Errors: errs}
+		}
+	}
+	return nil
+}
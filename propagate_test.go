@@ -0,0 +1,85 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type LineItem struct {
+	State State
+}
+
+func TestPropagate(t *testing.T) {
+	order := &TestStruct{State: "open"}
+	items := []interface{}{&LineItem{State: "reserved"}, &LineItem{State: "reserved"}, &LineItem{State: "shipped"}}
+
+	fsm := NewFSM(WithIDFunc(byAddress))
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "cancel",
+		From: []State{"open"},
+		To:   State("canceled"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := fsm.Register(reflect.TypeOf((*LineItem)(nil)), "State", Events{{
+		Name: "release",
+		From: []State{"reserved"},
+		To:   State("released"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	if err := fsm.Propagate(tag, "canceled", Propagation{
+		Event: "release",
+		Related: func(ctx context.Context, s interface{}) ([]interface{}, error) {
+			return items, nil
+		},
+	}); err != nil {
+		t.Errorf("Propagate() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := fsm.Fire(ctx, order, "cancel"); err != nil || order.State != "canceled" {
+		t.Errorf("Fire() = %v, %v, want canceled", order.State, err)
+	}
+	if got := items[0].(*LineItem).State; got != "reserved" {
+		t.Errorf("item 0 State = %v before the scheduler ran, want reserved", got)
+	}
+
+	report, err := fsm.FireDue(ctx)
+	if err != nil || report.Due != 3 || report.Fired != 2 || len(report.Errors) != 1 {
+		t.Errorf("FireDue() = %+v, %v, want the shipped item to fail", report, err)
+	}
+	for i, want := range []State{"released", "released", "shipped"} {
+		if got := items[i].(*LineItem).State; got != want {
+			t.Errorf("item %d State = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestPropagateRequiresIdentity(t *testing.T) {
+	fsm := NewFSM()
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{{Name: "cancel", From: []State{"open"}, To: State("canceled")}}, WithIdentity(byAddress)); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := fsm.Register(reflect.TypeOf((*LineItem)(nil)), "State", Events{{Name: "release", From: []State{"reserved"}, To: State("released")}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := fsm.Propagate(tag, "canceled", Propagation{
+		Event: "release",
+		Related: func(ctx context.Context, s interface{}) ([]interface{}, error) {
+			return []interface{}{&LineItem{State: "reserved"}}, nil
+		},
+	}); err != nil {
+		t.Errorf("Propagate() error = %v", err)
+	}
+
+	var perr PropagationError
+	if err := fsm.Fire(context.Background(), &TestStruct{State: "open"}, "cancel"); !errors.As(err, &perr) || !errors.As(perr.Errors[0], new(IdentityRequiredError)) {
+		t.Errorf("Fire() error = %v, want IdentityRequiredError for the line item", err)
+	}
+}
@@ -0,0 +1,160 @@
+package fsm
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Definition describes the machine registered for a type.
+type Definition struct {
+	Type   reflect.Type
+	Column string
+	Events Events
+	// States optionally declares every state, see WithStates.
+	States []State
+	// Mixin adds Events to the machine another provider defines for Type
+	// instead of defining the machine itself. Column is ignored and States
+	// are added to those of the machine if it declares any.
+	Mixin bool
+}
+
+// options returns the RegisterOptions def implies, followed by options.
+func (def Definition) options(options ...RegisterOption) []RegisterOption {
+	if len(def.States) == 0 {
+		return options
+	}
+	return append([]RegisterOption{WithStates(def.States...)}, options...)
+}
+
+// MachineProvider contributes machine definitions, typically from a domain
+// module or a Go plugin, see RegisterProvider and fsmplugin.Load.
+type MachineProvider interface {
+	Name() string
+	Machines() []Definition
+}
+
+var providers struct {
+	mu   sync.Mutex
+	list []MachineProvider
+}
+
+// RegisterProvider makes p visible to FSM.LoadProviders. It is meant to be
+// called from the init function of the module owning the workflow.
+func RegisterProvider(p MachineProvider) {
+	providers.mu.Lock()
+	defer providers.mu.Unlock()
+	providers.list = append(providers.list, p)
+}
+
+// LoadProviders installs the machines of every provider registered with
+// RegisterProvider.
+func (f *FSM) LoadProviders() error {
+	providers.mu.Lock()
+	list := append([]MachineProvider(nil), providers.list...)
+	providers.mu.Unlock()
+
+	return f.Install(list...)
+}
+
+type contribution struct {
+	provider string
+	def      Definition
+	owners   map[eventKey]string
+}
+
+// Install registers the machines contributed by ps. Mixins are merged into
+// the machine defined for their type. Nothing is registered if two providers
+// define the same type, if a machine for the type and column was already
+// added by Register or an earlier Install, if a mixin targets an undefined
+// type, if two providers declare the same event from the same state or if a
+// machine fails to compile. A provider may declare several branches of an event from one
+// state, see EventTransition.Priority.
+func (f *FSM) Install(ps ...MachineProvider) error {
+	bases := make(map[reflect.Type]*contribution)
+	order := []reflect.Type{}
+	var mixins []contribution
+
+	for _, p := range ps {
+		for _, def := range p.Machines() {
+			if def.Mixin {
+				mixins = append(mixins, contribution{provider: p.Name(), def: def})
+				continue
+			}
+
+			if base, ok := bases[def.Type]; ok {
+				return ProviderConflictError{Type: def.Type, Providers: [2]string{base.provider, p.Name()}}
+			}
+
+			base := &contribution{provider: p.Name(), def: def, owners: make(map[eventKey]string)}
+			base.def.Events = append(Events(nil), def.Events...)
+			base.def.States = append([]State(nil), def.States...)
+			if err := base.claim(p.Name(), def.Events); err != nil {
+				return err
+			}
+
+			bases[def.Type] = base
+			order = append(order, def.Type)
+		}
+	}
+
+	for _, mixin := range mixins {
+		base, ok := bases[mixin.def.Type]
+		if !ok {
+			return ProviderConflictError{Type: mixin.def.Type, Providers: [2]string{"", mixin.provider}}
+		}
+
+		if err := base.claim(mixin.provider, mixin.def.Events); err != nil {
+			return err
+		}
+		base.def.Events = append(base.def.Events, mixin.def.Events...)
+		if len(base.def.States) > 0 {
+			base.def.States = append(base.def.States, mixin.def.States...)
+		}
+	}
+
+	machines := make([]*fsm, 0, len(order))
+	for _, tag := range order {
+		def := bases[tag].def
+		machine, err := newFSM(f, def.Type, def.Column, def.Events, def.options()...)
+		if err != nil {
+			return err
+		}
+		machine.provider = bases[tag].provider
+		machines = append(machines, machine)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, machine := range machines {
+		for _, m := range f.machines[machine.tag] {
+			if m.column == machine.column {
+				return ProviderConflictError{Type: machine.tag, Providers: [2]string{m.provider, machine.provider}, Registered: m.provider == ""}
+			}
+		}
+	}
+	for _, machine := range machines {
+		f.install(machine.tag, machine)
+	}
+	return nil
+}
+
+// claim records provider as the owner of every (event, from) pair in events.
+// Pairs owned by another provider conflict.
+func (c *contribution) claim(provider string, events Events) error {
+	for _, e := range events {
+		for _, src := range e.From {
+			key := eventKey{event: e.Name, src: src}
+			if owner, ok := c.owners[key]; ok && owner != provider {
+				return ProviderConflictError{
+					Type:      c.def.Type,
+					Event:     e.Name,
+					From:      src,
+					Providers: [2]string{owner, provider},
+				}
+			}
+			c.owners[key] = provider
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testProvider struct {
+	name string
+	defs []Definition
+}
+
+func (p testProvider) Name() string           { return p.name }
+func (p testProvider) Machines() []Definition { return p.defs }
+
+func TestInstallProviders(t *testing.T) {
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	base := testProvider{name: "orders", defs: []Definition{{
+		Type:   tag,
+		Column: "State",
+		Events: Events{{Name: "make", From: []State{"started"}, To: State("finished")}},
+	}}}
+	mixin := testProvider{name: "returns", defs: []Definition{{
+		Type:   tag,
+		Mixin:  true,
+		Events: Events{{Name: "return", From: []State{"finished"}, To: State("returned")}},
+	}}}
+
+	fsm := NewFSM()
+	if err := fsm.Install(mixin, base); err != nil {
+		t.Fatalf("fsm.Install() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("started")}
+	for _, event := range []string{"make", "return"} {
+		if err := fsm.Fire(context.Background(), testStruct, event); err != nil {
+			t.Errorf("Fire(%s) error = %v", event, err)
+		}
+	}
+
+	conflicting := testProvider{name: "billing", defs: []Definition{{
+		Type:   tag,
+		Mixin:  true,
+		Events: Events{{Name: "make", From: []State{"started"}, To: State("billed")}},
+	}}}
+
+	var conflict ProviderConflictError
+	if err := NewFSM().Install(base, conflicting); !errors.As(err, &conflict) || conflict.Event != "make" {
+		t.Errorf("expected 'ProviderConflictError' on make, got %v", err)
+	}
+}
+
+func TestInstallAtomic(t *testing.T) {
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	branches := testProvider{name: "orders", defs: []Definition{{
+		Type:   tag,
+		Column: "State",
+		Events: Events{
+			{Name: "route", From: []State{"started"}, To: State("express"), Priority: 1},
+			{Name: "route", From: []State{"started"}, To: State("standard"), Default: true},
+		},
+	}}}
+	fsm := NewFSM()
+	if err := fsm.Install(branches); err != nil {
+		t.Errorf("fsm.Install() with branches error = %v", err)
+	}
+
+	broken := testProvider{name: "memberships", defs: []Definition{{
+		Type:   reflect.TypeOf((*Membership)(nil)),
+		Column: "State",
+		Events: Events{{Name: "route", From: []State{"pending"}, Default: true}, {Name: "route", From: []State{"pending"}, Default: true}},
+	}}}
+	fsm = NewFSM()
+	if err := fsm.Install(branches, broken); err == nil {
+		t.Error("fsm.Install() error = nil")
+	}
+	if len(fsm.Machines()) != 0 {
+		t.Errorf("fsm.Install() registered %d machines despite failing", len(fsm.Machines()))
+	}
+}
+
+func TestInstallKeepsExistingMachines(t *testing.T) {
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	orders := testProvider{name: "orders", defs: []Definition{{
+		Type:   tag,
+		Column: "State",
+		Events: Events{{Name: "make", From: []State{"started"}, To: State("finished")}},
+	}}}
+
+	fsm := NewFSM()
+	if err := fsm.Register(tag, "State", Events{{Name: "cancel", From: []State{"started"}, To: State("canceled")}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	var conflict ProviderConflictError
+	if err := fsm.Install(orders); !errors.As(err, &conflict) || !conflict.Registered {
+		t.Errorf("fsm.Install() over Register error = %v, want a ProviderConflictError", err)
+	}
+
+	fsm = NewFSM()
+	if err := fsm.Install(orders); err != nil {
+		t.Errorf("fsm.Install() error = %v", err)
+	}
+	if err := fsm.Install(orders); !errors.As(err, &conflict) || conflict.Providers != [2]string{"orders", "orders"} {
+		t.Errorf("fsm.Install() twice error = %v, want a ProviderConflictError", err)
+	}
+	if err := fsm.Fire(context.Background(), &TestStruct{State: State("started")}, "make"); err != nil {
+		t.Errorf("Fire() after the failed Install error = %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package fsm
+
+import (
+	"context"
+	"time"
+)
+
+// TransitionEvent is the payload handed to a Publisher after a successful
+// transition.
+type TransitionEvent struct {
+	Type   string                 `json:"type"`
+	ID     string                 `json:"id,omitempty"`
+	Event  string                 `json:"event"`
+	From   State                  `json:"from"`
+	To     State                  `json:"to"`
+	Tenant string                 `json:"tenant,omitempty"`
+	Reason string                 `json:"reason,omitempty"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+	Time   time.Time              `json:"time"`
+}
+
+// Publisher receives every successful transition, e.g. to put it on an
+// event bus. See the kafka and nats packages for adapters.
+type Publisher interface {
+	Publish(ctx context.Context, event TransitionEvent) error
+}
+
+// WithPublisher publishes every successful transition to p. If publishing
+// fails, Fire returns the error even though the instance was transitioned.
+func WithPublisher(p Publisher) FSMOption {
+	return func(f *FSM) {
+		f.publisher = p
+	}
+}
+
+// WithIDFunc extracts the ID of an instance for TransitionEvent, e.g. its
+// primary key. Machines without WithIdentity also key runtime data such as
+// history, variables and quotas on it.
+func WithIDFunc(fn func(s interface{}) string) FSMOption {
+	return func(f *FSM) {
+		f.idFunc = fn
+	}
+}
+
+// publish hands the transition described by a to the publisher, if any.
+func (f *fsm) publish(ctx context.Context, s interface{}, a *attempt) error {
+	p := f.parent.publisher
+	if p == nil {
+		return nil
+	}
+
+	event := TransitionEvent{
+		Type:   f.name,
+		Event:  a.labels.Event,
+		From:   State(a.labels.From),
+		To:     State(a.labels.To),
+		Tenant: a.labels.Tenant,
+		Reason: a.reason,
+		Meta:   a.meta,
+		Time:   f.parent.now(),
+	}
+	if f.identity != nil || f.parent.idFunc != nil {
+		event.ID = f.instanceID(s)
+	}
+	return p.Publish(ctx, event)
+}
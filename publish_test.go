@@ -0,0 +1,64 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type recordingPublisher struct {
+	events []TransitionEvent
+	err    error
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event TransitionEvent) error {
+	p.events = append(p.events, event)
+	return p.err
+}
+
+func TestPublisher(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	p := &recordingPublisher{}
+	fsm := NewFSM(WithPublisher(p), WithClock(fixedClock(now)), WithIDFunc(func(s interface{}) string {
+		return "order-1"
+	}))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "pay",
+		From: []State{"created"},
+		To:   State("paid"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := fsm.Fire(ctx, &TestStruct{State: "created"}, "pay", WithReason("checkout")); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if err := fsm.Fire(ctx, &TestStruct{State: "paid"}, "pay"); err == nil {
+		t.Errorf("Fire() error = nil, want a rejected transition")
+	}
+
+	want := []TransitionEvent{{
+		Type:   "*fsm.TestStruct",
+		ID:     "order-1",
+		Event:  "pay",
+		From:   "created",
+		To:     "paid",
+		Reason: "checkout",
+		Time:   now,
+	}}
+	if !reflect.DeepEqual(p.events, want) {
+		t.Errorf("published %+v, want %+v", p.events, want)
+	}
+
+	p.err = errors.New("bus down")
+	s := &TestStruct{State: "created"}
+	if err := fsm.Fire(ctx, s, "pay"); !errors.Is(err, p.err) {
+		t.Errorf("Fire() error = %v, want %v", err, p.err)
+	}
+	if s.State != "paid" {
+		t.Errorf("State = %v, want paid", s.State)
+	}
+}
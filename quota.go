@@ -0,0 +1,65 @@
+package fsm
+
+import (
+	"context"
+	"strconv"
+)
+
+type actorKey struct{}
+
+// WithActor returns a context carrying the actor firing events, used to
+// enforce EventTransition.Quota.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored by WithActor.
+func ActorFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	return actor, ok
+}
+
+func (f *fsm) quotaKey(ctx context.Context, s interface{}, br *branch) string {
+	actor, _ := ActorFromContext(ctx)
+	return "quota:" + f.instanceKey(s) + ":" + br.id + ":" + actor
+}
+
+func (f *fsm) quotaUsed(ctx context.Context, key string) (int, error) {
+	value, err := f.parent.store.Load(ctx, key)
+	if err != nil || value == nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(value))
+}
+
+// checkQuota returns QuotaExceededError if the actor in ctx already took
+// the transition br of event on s as many times as it allows.
+func (f *fsm) checkQuota(ctx context.Context, s interface{}, event string, br *branch) error {
+	if br.quota == 0 {
+		return nil
+	}
+
+	used, err := f.quotaUsed(ctx, f.quotaKey(ctx, s, br))
+	if err != nil {
+		return err
+	}
+
+	if used >= br.quota {
+		actor, _ := ActorFromContext(ctx)
+		return QuotaExceededError{Event: event, Actor: actor, Limit: br.quota}
+	}
+	return nil
+}
+
+func (f *fsm) consumeQuota(ctx context.Context, s interface{}, br *branch) error {
+	if br.quota == 0 {
+		return nil
+	}
+
+	qkey := f.quotaKey(ctx, s, br)
+	used, err := f.quotaUsed(ctx, qkey)
+	if err != nil {
+		return err
+	}
+	return f.parent.store.Save(ctx, qkey, []byte(strconv.Itoa(used+1)), 0)
+}
@@ -0,0 +1,136 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestQuotaPerActor(t *testing.T) {
+	fsm := NewFSM(WithIDFunc(byAddress))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:  "appeal",
+		From:  []State{"rejected"},
+		To:    State("rejected"),
+		Quota: 2,
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("rejected")}
+	alice := WithActor(context.Background(), "alice")
+	bob := WithActor(context.Background(), "bob")
+
+	for i := 0; i < 2; i++ {
+		if err := fsm.Fire(alice, testStruct, "appeal"); err != nil {
+			t.Fatalf("Fire() #%d error = %v", i, err)
+		}
+	}
+
+	var quotaErr QuotaExceededError
+	if err := fsm.Fire(alice, testStruct, "appeal"); !errors.As(err, &quotaErr) || quotaErr.Actor != "alice" {
+		t.Errorf("expected 'QuotaExceededError' for alice, got %v", err)
+	}
+
+	if err := fsm.Fire(bob, testStruct, "appeal"); err != nil {
+		t.Errorf("Fire() for bob error = %v", err)
+	}
+}
+
+func TestQuotaPerTransition(t *testing.T) {
+	fsm := NewFSM(WithIDFunc(byAddress))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:  "appeal",
+		From:  []State{"rejected"},
+		To:    State("rejected"),
+		Quota: 1,
+	}, {
+		Name: "appeal",
+		From: []State{"open"},
+		To:   State("open"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	alice := WithActor(context.Background(), "alice")
+	testStruct := &TestStruct{State: State("rejected")}
+	if err := fsm.Fire(alice, testStruct, "appeal"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if err := fsm.Fire(alice, testStruct, "appeal"); !errors.As(err, new(QuotaExceededError)) {
+		t.Errorf("Fire() error = %v, want QuotaExceededError", err)
+	}
+
+	testStruct.State = "open"
+	for i := 0; i < 2; i++ {
+		if err := fsm.Fire(alice, testStruct, "appeal"); err != nil {
+			t.Errorf("Fire() from open #%d error = %v", i, err)
+		}
+	}
+}
+
+func TestQuotaAcrossFromStates(t *testing.T) {
+	fsm := NewFSM(WithIDFunc(byAddress))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:  "escalate",
+		From:  []State{"low", "high"},
+		To:    State("high"),
+		Quota: 2,
+	}, {
+		Name: "calm",
+		From: []State{"high"},
+		To:   State("low"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	// Escalating from low and then from high takes the same transition,
+	// whatever state it starts in.
+	alice := WithActor(context.Background(), "alice")
+	testStruct := &TestStruct{State: State("low")}
+	for i := 0; i < 2; i++ {
+		if err := fsm.Fire(alice, testStruct, "escalate"); err != nil {
+			t.Errorf("Fire() #%d error = %v", i, err)
+		}
+	}
+	if err := fsm.Fire(alice, testStruct, "calm"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if err := fsm.Fire(alice, testStruct, "escalate"); !errors.As(err, new(QuotaExceededError)) {
+		t.Errorf("Fire() from low error = %v, want QuotaExceededError", err)
+	}
+}
+
+func TestQuotaPerBranch(t *testing.T) {
+	fsm := NewFSM(WithIDFunc(byAddress))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:     "appeal",
+		From:     []State{"rejected"},
+		To:       State("review"),
+		Priority: 1,
+		Quota:    1,
+		Guards: []Guard{func(ctx context.Context, e *Event) (bool, error) {
+			return e.Meta["urgent"] == "yes", nil
+		}},
+	}, {
+		Name:  "appeal",
+		From:  []State{"rejected"},
+		To:    State("rejected"),
+		Quota: 2,
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	// Only the branch selected by the guards counts against its quota.
+	alice := WithActor(context.Background(), "alice")
+	testStruct := &TestStruct{State: State("rejected")}
+	for i := 0; i < 2; i++ {
+		if err := fsm.Fire(alice, testStruct, "appeal"); err != nil {
+			t.Errorf("Fire() #%d error = %v", i, err)
+		}
+	}
+	if err := fsm.Fire(alice, testStruct, "appeal", WithMeta(map[string]interface{}{"urgent": "yes"})); err != nil || testStruct.State != "review" {
+		t.Errorf("Fire() of the urgent branch = %v in %s, want review", err, testStruct.State)
+	}
+}
@@ -0,0 +1,79 @@
+package fsm
+
+import "reflect"
+
+// ShortestPath func to return the events leading from state from to state
+// to with the fewest transitions in the default machine of tag, ignoring
+// guards and drafts. It returns UnreachableStateError if there is none, and
+// no events if from is to.
+func (f *FSM) ShortestPath(tag reflect.Type, from, to State) ([]string, error) {
+	machine, err := f.defaultMachine(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	path, ok := shortestPath(machine.table().schema.edges(false), from, to, nil)
+	if !ok {
+		return nil, UnreachableStateError{From: string(from), To: string(to)}
+	}
+
+	events := make([]string, len(path))
+	for i, t := range path {
+		events[i] = t.Event
+	}
+	return events, nil
+}
+
+// CanReach func to report whether state to can be reached from state from
+// in the default machine of tag, see ShortestPath
+func (f *FSM) CanReach(tag reflect.Type, from, to State) (bool, error) {
+	machine, err := f.defaultMachine(tag)
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := shortestPath(machine.table().schema.edges(false), from, to, nil)
+	return ok, nil
+}
+
+// shortestPath searches edges breadth first for the shortest path from
+// from to to, following only edges allow accepts if it is not nil. Edges
+// are tried in declaration order, so the result is deterministic.
+func shortestPath(edges []Transition, from, to State, allow func(Transition) bool) ([]Transition, bool) {
+	if from == to {
+		return []Transition{}, true
+	}
+
+	out := make(map[State][]Transition)
+	for _, t := range edges {
+		out[t.From] = append(out[t.From], t)
+	}
+
+	via := map[State]Transition{}
+	seen := map[State]bool{from: true}
+	queue := []State{from}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		for _, t := range out[state] {
+			if seen[t.To] || (allow != nil && !allow(t)) {
+				continue
+			}
+			seen[t.To] = true
+			via[t.To] = t
+
+			if t.To != to {
+				queue = append(queue, t.To)
+				continue
+			}
+
+			var path []Transition
+			for s := to; s != from; s = via[s].From {
+				path = append([]Transition{via[s]}, path...)
+			}
+			return path, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,51 @@
+package fsm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestReachability(t *testing.T) {
+	fsm := NewFSM()
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "pay",
+		From: []State{"created"},
+		To:   State("paid"),
+	}, {
+		Name: "ship",
+		From: []State{"paid"},
+		To:   State("shipped"),
+	}, {
+		Name: "refund",
+		From: []State{"paid", "shipped"},
+		To:   State("refunded"),
+	}, {
+		Name: "cancel",
+		From: []State{"created"},
+		To:   State("canceled"),
+	}, {
+		Name:  "reopen",
+		From:  []State{"canceled"},
+		To:    State("created"),
+		Draft: true,
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	path, err := fsm.ShortestPath(tag, "created", "refunded")
+	if err != nil || !reflect.DeepEqual(path, []string{"pay", "refund"}) {
+		t.Errorf("ShortestPath() = %v, %v, want [pay refund]", path, err)
+	}
+
+	if ok, err := fsm.CanReach(tag, "canceled", "refunded"); ok || err != nil {
+		t.Errorf("CanReach(canceled, refunded) = %v, %v, want false", ok, err)
+	}
+	if _, err := fsm.ShortestPath(tag, "canceled", "paid"); !errors.As(err, new(UnreachableStateError)) {
+		t.Errorf("ShortestPath() error = %v, want UnreachableStateError", err)
+	}
+	if ok, _ := fsm.CanReach(tag, "shipped", "shipped"); !ok {
+		t.Errorf("CanReach(shipped, shipped) = false, want true")
+	}
+}
@@ -0,0 +1,42 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestReasonAndMeta(t *testing.T) {
+	var got *Event
+	fsm := NewFSM(WithHistory(10), WithIDFunc(byAddress))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "reject",
+		From: []State{"review"},
+		To:   State("rejected"),
+		After: func(ctx context.Context, e *Event) error {
+			got = e
+			return nil
+		},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("review")}
+	meta := map[string]interface{}{"ticket": "OPS-12"}
+	if err := fsm.Fire(ctx, testStruct, "reject", WithReason("fraud"), WithMeta(meta)); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	if got == nil || got.Reason != "fraud" || got.Meta["ticket"] != "OPS-12" {
+		t.Errorf("callback saw %+v, want reason and meta", got)
+	}
+
+	history, err := fsm.History(ctx, testStruct)
+	if err != nil {
+		t.Errorf("History() error = %v", err)
+	}
+	if len(history) != 1 || history[0].Reason != "fraud" || history[0].Meta["ticket"] != "OPS-12" {
+		t.Errorf("History() = %+v, want reason and meta", history)
+	}
+}
@@ -0,0 +1,164 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ReconcileRule fires Event on instances in State that have been there for
+// at least OlderThan and, if set, satisfy When.
+type ReconcileRule struct {
+	Name      string
+	State     State
+	OlderThan time.Duration
+	When      func(ctx context.Context, s interface{}) (bool, error)
+	Event     string
+}
+
+// ReconcileReport summarises a reconciliation pass.
+type ReconcileReport struct {
+	Listed int
+	Fired  int
+	// Errors maps the index of an instance in the listed slice to the error
+	// its rule evaluation or Fire returned.
+	Errors map[int]error
+}
+
+// Reconciler periodically lists instances and fires the events their rules
+// call for, turning the FSM into a reconciliation loop.
+type Reconciler struct {
+	FSM   *FSM
+	List  func(ctx context.Context) ([]interface{}, error)
+	Rules []ReconcileRule
+	// Interval between passes of Run, which fails if it is not positive.
+	Interval time.Duration
+	// Concurrency bounds the number of instances handled at once, 1 if unset.
+	Concurrency int
+	// Since returns when s entered its current state. It defaults to the
+	// WithStateChangedAt field of s or else to the time of its last history
+	// entry, which needs WithHistory and WithIdentity or WithIDFunc; OlderThan
+	// rules fail with IdentityRequiredError without either.
+	Since func(ctx context.Context, s interface{}) (time.Time, error)
+}
+
+// Run reconciles every Interval until ctx is done.
+func (r *Reconciler) Run(ctx context.Context) error {
+	if r.Interval <= 0 {
+		return errors.New("fsm: Reconciler.Interval must be positive")
+	}
+
+	for {
+		if _, err := r.ReconcileOnce(ctx); err != nil {
+			return err
+		}
+
+		if err := sleep(ctx, r.FSM.timers(), r.Interval); err != nil {
+			return err
+		}
+	}
+}
+
+// ReconcileOnce runs a single pass. Per-instance failures are collected in
+// the report, only a failing List is returned as an error.
+func (r *Reconciler) ReconcileOnce(ctx context.Context) (ReconcileReport, error) {
+	objs, err := r.List(ctx)
+	if err != nil {
+		return ReconcileReport{}, err
+	}
+
+	report := ReconcileReport{Listed: len(objs), Errors: make(map[int]error)}
+
+	workers := r.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, obj := range objs {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return report, ctx.Err()
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int, obj interface{}) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			fired, err := r.reconcile(ctx, obj)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				report.Errors[i] = err
+			} else if fired {
+				report.Fired++
+			}
+		}(i, obj)
+	}
+	wg.Wait()
+
+	return report, nil
+}
+
+// reconcile fires the event of the first rule matching s.
+func (r *Reconciler) reconcile(ctx context.Context, s interface{}) (bool, error) {
+	machine, ok := r.FSM.machine(s)
+	if !ok {
+		return false, InternalError{}
+	}
+
+	_, state, err := machine.getSourceState(s)
+	if err != nil {
+		return false, err
+	}
+
+	for _, rule := range r.Rules {
+		ok, err := r.matches(ctx, rule, s, state)
+		if err != nil {
+			return false, err
+		}
+
+		if ok {
+			return true, r.FSM.Fire(ctx, s, rule.Event)
+		}
+	}
+
+	return false, nil
+}
+
+func (r *Reconciler) matches(ctx context.Context, rule ReconcileRule, s interface{}, state State) (bool, error) {
+	if rule.State != state {
+		return false, nil
+	}
+
+	if rule.OlderThan > 0 {
+		since, err := r.since(ctx, s)
+		if err != nil || since.IsZero() {
+			return false, err
+		}
+
+		if r.FSM.now().Sub(since) < rule.OlderThan {
+			return false, nil
+		}
+	}
+
+	if rule.When != nil {
+		return rule.When(ctx, s)
+	}
+	return true, nil
+}
+
+func (r *Reconciler) since(ctx context.Context, s interface{}) (time.Time, error) {
+	if r.Since != nil {
+		return r.Since(ctx, s)
+	}
+
+	return r.FSM.enteredAt(ctx, s, "ReconcileRule.OlderThan")
+}
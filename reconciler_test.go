@@ -0,0 +1,116 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReconcileOnce(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "activate",
+		From: []State{"provisioning"},
+		To:   State("active"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	stale := &TestStruct{State: State("provisioning")}
+	fresh := &TestStruct{State: State("provisioning")}
+	active := &TestStruct{State: State("active")}
+
+	r := &Reconciler{
+		FSM: fsm,
+		List: func(ctx context.Context) ([]interface{}, error) {
+			return []interface{}{stale, fresh, active}, nil
+		},
+		Rules: []ReconcileRule{{
+			Name:      "activate-ready",
+			State:     State("provisioning"),
+			OlderThan: 10 * time.Minute,
+			Event:     "activate",
+		}},
+		Concurrency: 2,
+		Since: func(ctx context.Context, s interface{}) (time.Time, error) {
+			if s == stale {
+				return time.Now().Add(-time.Hour), nil
+			}
+			return time.Now(), nil
+		},
+	}
+
+	report, err := r.ReconcileOnce(context.Background())
+	if err != nil {
+		t.Fatalf("ReconcileOnce() error = %v", err)
+	}
+
+	if report.Listed != 3 || report.Fired != 1 || len(report.Errors) != 0 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	if stale.State != State("active") || fresh.State != State("provisioning") {
+		t.Errorf("unexpected states stale=%s fresh=%s", stale.State, fresh.State)
+	}
+}
+
+func TestReconcileStateChangedAt(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*StampedStruct)(nil)), "State", Events{{
+		Name: "expire",
+		From: []State{"pending"},
+		To:   State("expired"),
+	}}, WithStateChangedAt("StateChangedAt")); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	stale := &StampedStruct{State: "pending", StateChangedAt: time.Now().Add(-time.Hour)}
+	fresh := &StampedStruct{State: "pending", StateChangedAt: time.Now()}
+	r := &Reconciler{
+		FSM: fsm,
+		List: func(ctx context.Context) ([]interface{}, error) {
+			return []interface{}{stale, fresh}, nil
+		},
+		Rules: []ReconcileRule{{State: "pending", OlderThan: 10 * time.Minute, Event: "expire"}},
+	}
+
+	if report, err := r.ReconcileOnce(context.Background()); err != nil || report.Fired != 1 || len(report.Errors) != 0 {
+		t.Errorf("ReconcileOnce() = %+v, %v", report, err)
+	}
+	if stale.State != "expired" || fresh.State != "pending" {
+		t.Errorf("unexpected states stale=%s fresh=%s", stale.State, fresh.State)
+	}
+}
+
+func TestReconcileRequiresIdentity(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "activate",
+		From: []State{"provisioning"},
+		To:   State("active"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	r := &Reconciler{
+		FSM: fsm,
+		List: func(ctx context.Context) ([]interface{}, error) {
+			return []interface{}{&TestStruct{State: "provisioning"}}, nil
+		},
+		Rules: []ReconcileRule{{State: "provisioning", OlderThan: time.Minute, Event: "activate"}},
+	}
+
+	report, err := r.ReconcileOnce(context.Background())
+	if err != nil {
+		t.Fatalf("ReconcileOnce() error = %v", err)
+	}
+	if !errors.As(report.Errors[0], new(IdentityRequiredError)) {
+		t.Errorf("ReconcileOnce() error = %v, want IdentityRequiredError", report.Errors[0])
+	}
+
+	if err := r.Run(context.Background()); err == nil {
+		t.Error("Run() without Interval error = nil")
+	}
+}
@@ -0,0 +1,16 @@
+module github.com/ceearrashee/fsm/redsync
+
+go 1.25
+
+require (
+	github.com/ceearrashee/fsm v0.0.0
+	github.com/go-redsync/redsync/v4 v4.13.0
+)
+
+require (
+	github.com/hashicorp/errwrap v1.1.0 // indirect
+	github.com/hashicorp/go-multierror v1.1.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/ceearrashee/fsm => ../
@@ -0,0 +1,69 @@
+// Package redsync implements fsm.LockProvider on top of redsync, locking
+// instances and resources across processes through Redis.
+package redsync
+
+import (
+	"context"
+	"time"
+
+	"github.com/ceearrashee/fsm"
+	rs "github.com/go-redsync/redsync/v4"
+)
+
+// LockProvider takes a redsync mutex per key.
+type LockProvider struct {
+	rs      *rs.Redsync
+	prefix  string
+	options []rs.Option
+}
+
+var _ fsm.LockProvider = (*LockProvider)(nil)
+
+// New creates a LockProvider taking mutexes from r, prefixing keys with
+// prefix, e.g. "fsm:". options configure every mutex, e.g. rs.WithExpiry
+// to bound how long a crashed process holds a lock.
+func New(r *rs.Redsync, prefix string, options ...rs.Option) *LockProvider {
+	return &LockProvider{rs: r, prefix: prefix, options: options}
+}
+
+// Lock implements fsm.LockProvider. The mutex is extended halfway to its
+// expiry for as long as it is held, so a transition may outlast the expiry.
+// The returned function releases the mutex even if ctx is done by then.
+func (p *LockProvider) Lock(ctx context.Context, key string) (func(), error) {
+	m := p.rs.NewMutex(p.prefix+key, p.options...)
+	if err := m.LockContext(ctx); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		extend(ctx, m, done)
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+		_, _ = m.UnlockContext(context.WithoutCancel(ctx))
+	}, nil
+}
+
+// extend extends m halfway to its expiry until done is closed or an
+// extension fails, in which case the lock is lost.
+func extend(ctx context.Context, m *rs.Mutex, done <-chan struct{}) {
+	ctx = context.WithoutCancel(ctx)
+	for {
+		t := time.NewTimer(time.Until(m.Until()) / 2)
+		select {
+		case <-done:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+
+		if ok, err := m.ExtendContext(ctx); !ok || err != nil {
+			return
+		}
+	}
+}
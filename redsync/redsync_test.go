@@ -0,0 +1,111 @@
+package redsync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	rs "github.com/go-redsync/redsync/v4"
+	"github.com/go-redsync/redsync/v4/redis"
+)
+
+// memoryPool is a redis.Pool keeping keys in memory, counting how often a
+// held key was extended. Expiry is not enforced.
+type memoryPool struct {
+	mu      sync.Mutex
+	keys    map[string]string
+	extends map[string]int
+}
+
+func newMemoryPool() *memoryPool {
+	return &memoryPool{keys: make(map[string]string), extends: make(map[string]int)}
+}
+
+func (p *memoryPool) Get(ctx context.Context) (redis.Conn, error) { return memoryConn{p}, nil }
+
+func (p *memoryPool) extended(key string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.extends[key]
+}
+
+func (p *memoryPool) held(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.keys[key]
+	return ok
+}
+
+type memoryConn struct{ p *memoryPool }
+
+func (c memoryConn) Get(name string) (string, error) {
+	c.p.mu.Lock()
+	defer c.p.mu.Unlock()
+	return c.p.keys[name], nil
+}
+
+func (c memoryConn) Set(name string, value string) (bool, error) {
+	c.p.mu.Lock()
+	defer c.p.mu.Unlock()
+	c.p.keys[name] = value
+	return true, nil
+}
+
+func (c memoryConn) SetNX(name string, value string, expiry time.Duration) (bool, error) {
+	c.p.mu.Lock()
+	defer c.p.mu.Unlock()
+	if _, ok := c.p.keys[name]; ok {
+		return false, nil
+	}
+	c.p.keys[name] = value
+	return true, nil
+}
+
+// Eval runs the unlock and extend scripts of redsync: both only act if the
+// key still holds the value of the caller, and only unlocking has two
+// arguments.
+func (c memoryConn) Eval(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error) {
+	c.p.mu.Lock()
+	defer c.p.mu.Unlock()
+
+	name, value := keysAndArgs[0].(string), keysAndArgs[1].(string)
+	if c.p.keys[name] != value {
+		return int64(0), nil
+	}
+	if len(keysAndArgs) == 2 {
+		delete(c.p.keys, name)
+	} else {
+		c.p.extends[name]++
+	}
+	return int64(1), nil
+}
+
+func (c memoryConn) PTTL(name string) (time.Duration, error) { return 0, nil }
+func (c memoryConn) Close() error                            { return nil }
+
+func TestLockProviderExtends(t *testing.T) {
+	pool := newMemoryPool()
+	locks := New(rs.New(pool), "fsm:", rs.WithExpiry(40*time.Millisecond), rs.WithTries(1))
+
+	ctx := context.Background()
+	unlock, err := locks.Lock(ctx, "instance:1")
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	if _, err := locks.Lock(ctx, "instance:1"); err == nil {
+		t.Error("Lock() of a held key error = nil")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if n := pool.extended("fsm:instance:1"); n < 2 {
+		t.Errorf("lock extended %d times while held for 2.5 expiries, want at least 2", n)
+	}
+
+	unlock()
+	n := pool.extended("fsm:instance:1")
+	time.Sleep(50 * time.Millisecond)
+	if pool.held("fsm:instance:1") || pool.extended("fsm:instance:1") != n {
+		t.Error("lock still held or extended after unlock")
+	}
+}
@@ -0,0 +1,66 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+)
+
+// InState returns a guard passing while the state column column of the
+// instance is in one of states. Machines registered for several columns of
+// a type advance independently, as orthogonal regions; InState on a
+// transition of one region joins it with the others, e.g. completing an
+// order only once both its payment and fulfillment columns are done.
+//
+// The other region is read without holding its instance lock, so a
+// concurrent transition there may or may not be seen.
+func (f *FSM) InState(column string, states ...State) Guard {
+	return func(ctx context.Context, e *Event) (bool, error) {
+		machine, ok := f.machineOn(e.Source, column)
+		if !ok {
+			return false, InternalError{}
+		}
+
+		_, state, err := machine.getSourceState(e.Source)
+		if err != nil {
+			return false, err
+		}
+
+		for _, s := range states {
+			if s == state {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// Regions func to return the current state of every state column of s, by
+// column name
+func (f *FSM) Regions(s interface{}) (map[string]State, error) {
+	machines := f.machinesOf(reflect.TypeOf(s))
+	if len(machines) == 0 {
+		return nil, InternalError{}
+	}
+
+	regions := make(map[string]State, len(machines))
+	for _, machine := range machines {
+		_, state, err := machine.getSourceState(s)
+		if err != nil {
+			return nil, err
+		}
+		regions[machine.column] = state
+	}
+	return regions, nil
+}
+
+// CurrentState func to return the state of s in the column of the machine
+// Fire uses for it
+func (f *FSM) CurrentState(s interface{}) (State, error) {
+	machine, ok := f.machine(s)
+	if !ok {
+		return "", InternalError{}
+	}
+
+	_, state, err := machine.getSourceState(s)
+	return state, err
+}
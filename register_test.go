@@ -0,0 +1,103 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type OtherStruct struct {
+	State State
+}
+
+func TestRegisterConcurrentWithFire(t *testing.T) {
+	fsm := NewFSM()
+	events := Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+	}}
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", events); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := fsm.Fire(context.Background(), &TestStruct{State: State("started")}, "make"); err != nil {
+				t.Errorf("Fire() error = %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := fsm.Register(reflect.TypeOf((*OtherStruct)(nil)), "State", events); err != nil {
+				t.Errorf("fsm.Register() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestUnregister(t *testing.T) {
+	fsm := NewFSM()
+	tag := reflect.TypeOf((*TestStruct)(nil))
+
+	if err := fsm.Unregister(tag); !errors.As(err, new(UnregisteredError)) {
+		t.Errorf("Unregister() of unknown type error = %v, want UnregisteredError", err)
+	}
+
+	entered := make(chan struct{})
+	proceed := make(chan struct{})
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+		Before: func(ctx context.Context, e *Event) error {
+			close(entered)
+			<-proceed
+			return nil
+		},
+	}, {
+		Name: "other",
+		From: []State{"started"},
+		To:   State("finished"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	instance := &TestStruct{State: State("started")}
+
+	// A transition waiting for the instance when its machine is removed
+	// fails cleanly.
+	done := make(chan error, 1)
+	go func() {
+		done <- fsm.Fire(context.Background(), instance, "make")
+	}()
+	<-entered
+
+	waiting := make(chan error, 1)
+	machine, _ := fsm.machine(instance)
+	go func() {
+		waiting <- machine.Fire(context.Background(), instance, "other")
+	}()
+
+	if err := fsm.Unregister(tag); err != nil {
+		t.Errorf("Unregister() error = %v", err)
+	}
+	close(proceed)
+
+	if err := <-done; err != nil {
+		t.Errorf("in-flight Fire() error = %v", err)
+	}
+	if err := <-waiting; !errors.As(err, new(UnregisteredError)) {
+		t.Errorf("waiting Fire() error = %v, want UnregisteredError", err)
+	}
+
+	if err := fsm.Fire(context.Background(), instance, "make"); !errors.As(err, new(InternalError)) {
+		t.Errorf("Fire() after Unregister error = %v, want InternalError", err)
+	}
+}
@@ -0,0 +1,109 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// Callback is a Before or After hook of a transition.
+type Callback func(context.Context, *Event) error
+
+// Registry maps names to guards and callbacks so definitions can reference
+// them symbolically, through EventTransition.GuardNames or a Schema, and so
+// rejections can be reported by name. Use it with WithRegistry.
+type Registry struct {
+	mu        sync.RWMutex
+	guards    map[string]Guard
+	names     map[uintptr][]string
+	callbacks map[string]Callback
+	// callbackNames are the names of callbacks by code pointer, as names
+	// are for guards.
+	callbackNames map[uintptr][]string
+}
+
+// NewRegistry func to create Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		guards:        make(map[string]Guard),
+		names:         make(map[uintptr][]string),
+		callbacks:     make(map[string]Callback),
+		callbackNames: make(map[uintptr][]string),
+	}
+}
+
+// RegisterGuard stores g under name, returning DuplicateNameError if the
+// name is taken.
+func (r *Registry) RegisterGuard(name string, g Guard) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.guards[name]; ok {
+		return DuplicateNameError{Name: name}
+	}
+
+	r.guards[name] = g
+	pc := reflect.ValueOf(g).Pointer()
+	r.names[pc] = append(r.names[pc], name)
+	return nil
+}
+
+// Guard returns the guard registered under name.
+func (r *Registry) Guard(name string) (Guard, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	g, ok := r.guards[name]
+	return g, ok
+}
+
+// guardName returns the registered name of g. Closures share their code
+// pointer, so a function registered under several names is not resolved.
+func (r *Registry) guardName(g Guard) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := r.names[reflect.ValueOf(g).Pointer()]
+	if len(names) != 1 {
+		return "", false
+	}
+	return names[0], true
+}
+
+// RegisterCallback stores fn under name, returning DuplicateNameError if the
+// name is taken.
+func (r *Registry) RegisterCallback(name string, fn Callback) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.callbacks[name]; ok {
+		return DuplicateNameError{Name: name}
+	}
+
+	r.callbacks[name] = fn
+	pc := reflect.ValueOf(fn).Pointer()
+	r.callbackNames[pc] = append(r.callbackNames[pc], name)
+	return nil
+}
+
+// Callback returns the callback registered under name.
+func (r *Registry) Callback(name string) (Callback, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fn, ok := r.callbacks[name]
+	return fn, ok
+}
+
+// callbackName returns the registered name of fn, with the caveat of
+// guardName.
+func (r *Registry) callbackName(fn Callback) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := r.callbackNames[reflect.ValueOf(fn).Pointer()]
+	if len(names) != 1 {
+		return "", false
+	}
+	return names[0], true
+}
@@ -0,0 +1,158 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// ReplayEvent is one entry of an event log given to Replay.
+type ReplayEvent struct {
+	Event   string
+	Options []Option
+	// Time is when the event was originally fired. It is written to the
+	// fields set by WithStateChangedAt and WithUpdatedAt, zero leaves them
+	// untouched.
+	Time time.Time
+	// To is the state the event originally led to, e.g. HistoryEntry.To.
+	// Without it the event must have a single transition from the state
+	// and it must not have a ToFunc.
+	To State
+}
+
+// ReplayOption configures Replay.
+type ReplayOption func(*replayOptions)
+
+type replayOptions struct {
+	guards bool
+}
+
+// ReplayGuards makes Replay decide the branch taken by evaluating guards
+// and ToFunc as Fire does, instead of following ReplayEvent.To. The result
+// then depends on what they read when replaying.
+func ReplayGuards() ReplayOption {
+	return func(o *replayOptions) {
+		o.guards = true
+	}
+}
+
+// Replay func to apply log to s in order, e.g. to rebuild an event-sourced
+// entity or reproduce an incident. Guards and ToFunc are not evaluated
+// unless ReplayGuards is given, the transitions recorded in log are taken
+// instead. Callbacks, hooks, quotas, history, publishing and propagation
+// are suppressed and variables are kept in memory only, so replaying has no
+// side effects and gives the same result every time. Replay stops at the
+// first event that can't be applied and returns a ReplayError.
+func (f *FSM) Replay(ctx context.Context, s interface{}, log []ReplayEvent, options ...ReplayOption) error {
+	machine, ok := f.machine(s)
+	if !ok {
+		return InternalError{}
+	}
+
+	var opts replayOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var vars *Vars
+	if len(machine.vars) > 0 {
+		vars = &Vars{decls: machine.vars, values: make(map[string]interface{}), changed: make(map[string]bool)}
+	}
+
+	return machine.exec(ctx, s, func() error {
+		for i, entry := range log {
+			if err := machine.replay(ctx, s, entry, vars, opts); err != nil {
+				return ReplayError{Index: i, Event: entry.Event, Err: err}
+			}
+		}
+		return nil
+	})
+}
+
+func (f *fsm) replay(ctx context.Context, s interface{}, entry ReplayEvent, vars *Vars, opts replayOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if f.removed.Load() {
+		return UnregisteredError{Type: f.tag}
+	}
+
+	args := &Options{}
+	for _, option := range entry.Options {
+		option(args)
+	}
+
+	field, state, err := f.getSourceState(s)
+	if err != nil {
+		return err
+	}
+	if f.isFinal(state) {
+		return MachineCompletedError{Event: entry.Event, State: string(state)}
+	}
+
+	destination, ok := f.destination(eventKey{entry.Event, state}, args)
+	if !ok {
+		return UnknownEventError{entry.Event}
+	}
+
+	var to State
+	if opts.guards {
+		e := &Event{Event: entry.Event, Source: s, From: state, Destination: destination, Vars: vars, Reason: args.Reason, Meta: args.Meta, Args: args.Args, DryRun: true}
+		br, guard, err := f.selectBranch(ctx, e, state, args, false)
+		if err != nil || br == nil {
+			return InvalidTransitionError{Event: entry.Event, State: string(state), Guard: guard, Err: err}
+		}
+
+		to = br.to
+		if br.toFunc != nil {
+			if to, err = f.choose(ctx, e, br); err != nil {
+				return err
+			}
+		}
+	} else if to, err = f.recorded(eventKey{entry.Event, state}, entry.To, args); err != nil {
+		return err
+	}
+
+	stamps, err := f.stampsFor(reflect.TypeOf(s))
+	if err != nil {
+		return err
+	}
+	if err := stamps.check(reflect.ValueOf(s).Elem()); err != nil {
+		return err
+	}
+
+	if err := f.setState(field, f.accessFor(reflect.TypeOf(s)), to); err != nil {
+		return err
+	}
+	if !entry.Time.IsZero() {
+		stamps.set(reflect.ValueOf(s).Elem(), entry.Time, to != state)
+	}
+	return nil
+}
+
+// recorded returns the destination of a replayed event of key that
+// originally led to to, without evaluating guards or ToFunc. If to is
+// empty, key must have a single transition with a fixed destination.
+func (f *fsm) recorded(key eventKey, to State, args *Options) (State, error) {
+	var candidates []*branch
+	for _, br := range f.table().branches[key] {
+		if !br.draft || args.includeDraft() {
+			candidates = append(candidates, br)
+		}
+	}
+
+	if to == "" {
+		if len(candidates) != 1 || candidates[0].toFunc != nil {
+			return "", AmbiguousTransitionError{Event: key.event, State: string(key.src)}
+		}
+		return candidates[0].to, nil
+	}
+
+	for _, br := range candidates {
+		if br.to == to || (br.toFunc != nil && (len(br.targets) == 0 || slices.Contains(br.targets, to))) {
+			return to, nil
+		}
+	}
+	return "", InvalidTransitionError{Event: key.event, State: string(key.src)}
+}
@@ -0,0 +1,122 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	called := 0
+	callback := func(ctx context.Context, e *Event) error {
+		called++
+		return nil
+	}
+
+	fsm := NewFSM(WithHistory(10), WithIDFunc(byAddress))
+	err := fsm.Register(reflect.TypeOf((*StampedStruct)(nil)), "State", Events{{
+		Name:   "pay",
+		From:   []State{"created"},
+		To:     State("paid"),
+		Before: callback,
+		After:  callback,
+	}, {
+		Name: "ship",
+		From: []State{"paid"},
+		To:   State("shipped"),
+		Guards: []Guard{func(ctx context.Context, e *Event) (bool, error) {
+			return e.Reason != "hold", nil
+		}},
+		After: callback,
+	}}, WithStateChangedAt("StateChangedAt"))
+	if err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	paid := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	shipped := paid.Add(time.Hour)
+
+	s := &StampedStruct{State: "created"}
+	err = fsm.Replay(ctx, s, []ReplayEvent{
+		{Event: "pay", Time: paid},
+		{Event: "ship", Time: shipped},
+	})
+	if err != nil {
+		t.Errorf("Replay() error = %v", err)
+	}
+	if s.State != "shipped" || !s.StateChangedAt.Equal(shipped) {
+		t.Errorf("after Replay() = %v at %v, want shipped at %v", s.State, s.StateChangedAt, shipped)
+	}
+	if called != 0 {
+		t.Errorf("Replay() ran %d callbacks, want none", called)
+	}
+	if history, _ := fsm.History(ctx, s); len(history) != 0 {
+		t.Errorf("Replay() recorded history %v", history)
+	}
+
+	held := []ReplayEvent{
+		{Event: "pay"},
+		{Event: "ship", Options: []Option{WithReason("hold")}},
+	}
+	s = &StampedStruct{State: "created"}
+	if err := fsm.Replay(ctx, s, held); err != nil || s.State != "shipped" {
+		t.Errorf("Replay() = %v, %v, want shipped without evaluating guards", s.State, err)
+	}
+
+	s = &StampedStruct{State: "created"}
+	err = fsm.Replay(ctx, s, held, ReplayGuards())
+	var rerr ReplayError
+	if !errors.As(err, &rerr) || rerr.Index != 1 || !errors.As(err, new(InvalidTransitionError)) {
+		t.Errorf("Replay() error = %v, want ReplayError at 1", err)
+	}
+	if s.State != "paid" {
+		t.Errorf("State = %v, want paid", s.State)
+	}
+}
+
+func TestReplayRecordedBranch(t *testing.T) {
+	fsm := NewFSM()
+	err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:     "route",
+		From:     []State{"created"},
+		To:       State("express"),
+		Priority: 1,
+		Guards: []Guard{func(ctx context.Context, e *Event) (bool, error) {
+			return false, nil
+		}},
+	}, {
+		Name:    "route",
+		From:    []State{"created"},
+		To:      State("standard"),
+		Default: true,
+	}, {
+		Name: "deliver",
+		From: []State{"express", "standard"},
+		ToFunc: func(ctx context.Context, e *Event) (State, error) {
+			return "", errors.New("carrier unavailable")
+		},
+		Targets: []State{"delivered", "lost"},
+	}})
+	if err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	s := &TestStruct{State: "created"}
+	if err := fsm.Replay(ctx, s, []ReplayEvent{{Event: "route", To: "express"}, {Event: "deliver", To: "lost"}}); err != nil || s.State != "lost" {
+		t.Errorf("Replay() = %v, %v, want lost", s.State, err)
+	}
+
+	s = &TestStruct{State: "created"}
+	if err := fsm.Replay(ctx, s, []ReplayEvent{{Event: "route"}}); !errors.As(err, new(AmbiguousTransitionError)) {
+		t.Errorf("Replay() without To error = %v, want AmbiguousTransitionError", err)
+	}
+
+	s = &TestStruct{State: "standard"}
+	if err := fsm.Replay(ctx, s, []ReplayEvent{{Event: "deliver", To: "returned"}}); !errors.As(err, new(InvalidTransitionError)) {
+		t.Errorf("Replay() to an undeclared target error = %v, want InvalidTransitionError", err)
+	}
+}
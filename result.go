@@ -0,0 +1,41 @@
+package fsm
+
+// FireResult describes the outcome of FireE.
+type FireResult int
+
+const (
+	// Failed means the transition did not happen, see the returned error.
+	Failed FireResult = iota
+	// Completed means the instance moved to the destination state.
+	Completed
+	// NoOp means the call was recognised as a repeat and did nothing.
+	NoOp
+	// PendingApproval means a RequireApprovals guard rejected the
+	// transition because it waits for further approvals. The
+	// InvalidTransitionError is still returned.
+	PendingApproval
+	// Compensated means the transition failed and its OnError event moved
+	// the instance to a failure state. The callback error is still returned.
+	Compensated
+	// CallbackFailed means the instance moved to the destination state but
+	// a step following the write failed, e.g. an OnEnter or After callback
+	// or recording history, see the returned error. A failing invariant
+	// resets the instance and is reported as Failed.
+	CallbackFailed
+)
+
+var fireResultNames = [...]string{
+	Failed:          "failed",
+	Completed:       "completed",
+	NoOp:            "noop",
+	PendingApproval: "pending_approval",
+	Compensated:     "compensated",
+	CallbackFailed:  "callback_failed",
+}
+
+func (r FireResult) String() string {
+	if r < 0 || int(r) >= len(fireResultNames) {
+		return "unknown"
+	}
+	return fireResultNames[r]
+}
@@ -0,0 +1,60 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFireResultAfterWrite(t *testing.T) {
+	errBoom := errors.New("boom")
+	tag := reflect.TypeOf((*TestStruct)(nil))
+
+	fsm := NewFSM()
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "prepare",
+		From: []State{"a"},
+		To:   State("b"),
+		Before: func(ctx context.Context, e *Event) error {
+			return errBoom
+		},
+	}, {
+		Name: "notify",
+		From: []State{"a"},
+		To:   State("b"),
+		After: func(ctx context.Context, e *Event) error {
+			return errBoom
+		},
+	}, {
+		Name: "corrupt",
+		From: []State{"a"},
+		To:   State("c"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := fsm.Invariant(tag, func(ctx context.Context, s interface{}) error {
+		if s.(*TestStruct).State == "c" {
+			return errBoom
+		}
+		return nil
+	}); err != nil {
+		t.Errorf("fsm.Invariant() error = %v", err)
+	}
+
+	for _, tt := range []struct {
+		event  string
+		result FireResult
+		state  State
+	}{
+		{"prepare", Failed, "a"},
+		{"notify", CallbackFailed, "b"},
+		{"corrupt", Failed, "a"},
+	} {
+		s := &TestStruct{State: "a"}
+		result, err := fsm.FireE(context.Background(), s, tt.event)
+		if !errors.Is(err, errBoom) || result != tt.result || s.State != tt.state {
+			t.Errorf("FireE(%s) = %v, %v, state %v, want %v, boom, state %v", tt.event, result, err, s.State, tt.result, tt.state)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package fsm
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy retries the guards and the Before and After callbacks of a
+// transition failing with a transient error, e.g. publishing to a message
+// bus, instead of failing Fire on the first error.
+type RetryPolicy struct {
+	// MaxAttempts is the number of calls including the first one. Values
+	// below 2 disable retries.
+	MaxAttempts int
+	// Backoff returns the delay before retry n, starting at 1. Nil retries
+	// immediately.
+	Backoff func(n int) time.Duration
+	// Retryable reports whether err is worth retrying. Nil retries every
+	// error.
+	Retryable func(err error) bool
+}
+
+// ExponentialBackoff doubles the delay from base for every retry, capped at
+// max.
+func ExponentialBackoff(base, max time.Duration) func(n int) time.Duration {
+	return func(n int) time.Duration {
+		d := base
+		for i := 1; i < n && d < max; i++ {
+			d *= 2
+		}
+		return min(d, max)
+	}
+}
+
+// Do calls fn until it succeeds, fails with an error p doesn't retry, or
+// runs out of attempts. Waiting stops once ctx is done, returning the last
+// error of fn.
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	return p.do(ctx, systemClock{}, func(int) error { return fn() })
+}
+
+// do is Do waiting on clock, passing fn the number of the attempt.
+func (p RetryPolicy) do(ctx context.Context, clock TimerClock, fn func(n int) error) error {
+	for n := 1; ; n++ {
+		err := fn(n)
+		if err == nil || n >= p.MaxAttempts || (p.Retryable != nil && !p.Retryable(err)) {
+			return err
+		}
+
+		if p.Backoff == nil {
+			continue
+		}
+		if sleep(ctx, clock, p.Backoff(n)) != nil {
+			return err
+		}
+	}
+}
+
+// retry calls fn under the retry policy of br, once if it has none,
+// numbering the calls in e.Attempt.
+func (f *fsm) retry(ctx context.Context, br *branch, e *Event, fn func() error) error {
+	defer func() { e.Attempt = 0 }()
+
+	if br.retry.MaxAttempts < 2 {
+		e.Attempt = 1
+		return fn()
+	}
+	return br.retry.do(ctx, f.parent.timers(), func(n int) error {
+		e.Attempt = n
+		return fn()
+	})
+}
@@ -0,0 +1,118 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRetryAfterCallback(t *testing.T) {
+	calls := 0
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "publish",
+		From: []State{"draft"},
+		To:   State("published"),
+		After: func(ctx context.Context, e *Event) error {
+			calls++
+			if calls < 3 {
+				return errors.New("bus unavailable")
+			}
+			return nil
+		},
+		Retry: RetryPolicy{MaxAttempts: 3, Backoff: ExponentialBackoff(time.Millisecond, 5*time.Millisecond)},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	s := &TestStruct{State: "draft"}
+	if err := fsm.Fire(context.Background(), s, "publish"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestRetryNotRetryable(t *testing.T) {
+	permanent := errors.New("permanent")
+	calls := 0
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "publish",
+		From: []State{"draft"},
+		To:   State("published"),
+		Guards: []Guard{func(ctx context.Context, e *Event) (bool, error) {
+			calls++
+			return false, permanent
+		}},
+		Retry: RetryPolicy{MaxAttempts: 5, Retryable: func(err error) bool { return !errors.Is(err, permanent) }},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	s := &TestStruct{State: "draft"}
+	if err := fsm.Fire(context.Background(), s, "publish"); !errors.Is(err, permanent) {
+		t.Errorf("Fire() error = %v, want %v", err, permanent)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	for n, want := range map[int]time.Duration{1: 10 * time.Millisecond, 2: 20 * time.Millisecond, 3: 40 * time.Millisecond, 4: 50 * time.Millisecond} {
+		if got := backoff(n); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestRetryPerTransition(t *testing.T) {
+	calls := 0
+	after := func(ctx context.Context, e *Event) error {
+		calls++
+		return errors.New("bus unavailable")
+	}
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:    "go",
+		From:    []State{"a"},
+		To:      State("b"),
+		After:   after,
+		Retry:   RetryPolicy{MaxAttempts: 3},
+		OnError: "fail",
+	}, {
+		Name:  "go",
+		From:  []State{"b"},
+		To:    State("c"),
+		After: after,
+		Retry: RetryPolicy{MaxAttempts: 5},
+	}, {
+		Name: "fail",
+		From: []State{"b"},
+		To:   State("failed"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	s := &TestStruct{State: "a"}
+	if err := fsm.Fire(context.Background(), s, "go"); err == nil {
+		t.Error("Fire() error = nil")
+	}
+	if calls != 3 || s.State != "failed" {
+		t.Errorf("calls = %d, state = %s, want 3 and failed", calls, s.State)
+	}
+
+	calls = 0
+	s = &TestStruct{State: "b"}
+	if err := fsm.Fire(context.Background(), s, "go"); err == nil {
+		t.Error("Fire() error = nil")
+	}
+	if calls != 5 || s.State != "c" {
+		t.Errorf("calls = %d, state = %s, want 5 and c", calls, s.State)
+	}
+}
@@ -0,0 +1,43 @@
+package fsm
+
+import "context"
+
+// SagaStep is one step of a saga run by FSM.RunSaga.
+type SagaStep struct {
+	Instance interface{}
+	Event    string
+	Options  []Option
+	// Compensate is the event fired on Instance to undo the step if a later
+	// step fails. Steps without one are left as they are.
+	Compensate string
+}
+
+// RunSaga func to fire the events of steps in order, each on its own
+// instance. If a step fails, the Compensate events of the steps already done
+// are fired in reverse order and SagaError is returned. Compensation runs
+// even if ctx is canceled
+func (f *FSM) RunSaga(ctx context.Context, steps ...SagaStep) error {
+	for i, step := range steps {
+		err := f.Fire(ctx, step.Instance, step.Event, step.Options...)
+		if err == nil {
+			continue
+		}
+
+		serr := SagaError{Step: i, Event: step.Event, Err: err}
+		cctx := context.WithoutCancel(ctx)
+		for j := i - 1; j >= 0; j-- {
+			done := steps[j]
+			if done.Compensate == "" {
+				continue
+			}
+			if cerr := f.Fire(cctx, done.Instance, done.Compensate); cerr != nil {
+				if serr.Compensations == nil {
+					serr.Compensations = make(map[int]error)
+				}
+				serr.Compensations[j] = cerr
+			}
+		}
+		return serr
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRunSaga(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "reserve",
+		From: []State{"available"},
+		To:   State("reserved"),
+	}, {
+		Name: "release",
+		From: []State{"reserved"},
+		To:   State("available"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	stock := &TestStruct{State: "available"}
+	seat := &TestStruct{State: "available"}
+	sold := &TestStruct{State: "sold"}
+
+	err := fsm.RunSaga(context.Background(),
+		SagaStep{Instance: stock, Event: "reserve", Compensate: "release"},
+		SagaStep{Instance: seat, Event: "reserve", Compensate: "release"},
+		SagaStep{Instance: sold, Event: "reserve", Compensate: "release"},
+	)
+
+	var serr SagaError
+	if !errors.As(err, &serr) || serr.Step != 2 || len(serr.Compensations) != 0 {
+		t.Errorf("RunSaga() error = %v, want step 2 failing", err)
+	}
+	if !errors.As(err, new(InvalidTransitionError)) && !errors.As(err, new(UnknownEventError)) {
+		t.Errorf("RunSaga() error = %v, want the step error wrapped", err)
+	}
+	if stock.State != "available" || seat.State != "available" {
+		t.Errorf("States = %v, %v, want compensated", stock.State, seat.State)
+	}
+
+	if err := fsm.RunSaga(context.Background(),
+		SagaStep{Instance: stock, Event: "reserve", Compensate: "release"},
+		SagaStep{Instance: seat, Event: "reserve", Compensate: "release"},
+	); err != nil {
+		t.Errorf("RunSaga() error = %v", err)
+	}
+	if stock.State != "reserved" || seat.State != "reserved" {
+		t.Errorf("States = %v, %v, want reserved", stock.State, seat.State)
+	}
+}
@@ -0,0 +1,286 @@
+package fsm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ScheduledFire is an event scheduled with FireAt or FireAfter.
+type ScheduledFire struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Event string `json:"event"`
+	// Instance is the ID of the instance, see WithIdentity and WithIDFunc.
+	Instance string    `json:"instance"`
+	At       time.Time `json:"at"`
+	// ClaimedUntil is when the claim of the FireDue pass running it expires,
+	// see ScheduleStore.Claim.
+	ClaimedUntil time.Time `json:"claimed_until"`
+}
+
+// ScheduleStore persists scheduled fires, e.g. in a database table, so they
+// survive restarts and can be shared by replicas.
+type ScheduleStore interface {
+	Add(ctx context.Context, fire ScheduledFire) error
+	// Remove deletes the fire with id, doing nothing if there is none.
+	Remove(ctx context.Context, id string) error
+	// Due returns the fires scheduled at or before now that are not claimed
+	// past now, earliest first.
+	Due(ctx context.Context, now time.Time) ([]ScheduledFire, error)
+	// Claim atomically sets the ClaimedUntil of the fire with id to until,
+	// unless it is claimed past now, and reports whether it did. It reports
+	// false if there is no such fire.
+	Claim(ctx context.Context, id string, now, until time.Time) (bool, error)
+	// Pending returns the fires scheduled for instance of typ, earliest
+	// first.
+	Pending(ctx context.Context, typ, instance string) ([]ScheduledFire, error)
+}
+
+// MemoryScheduleStore is an in-process ScheduleStore, the default.
+type MemoryScheduleStore struct {
+	mu    sync.Mutex
+	fires map[string]ScheduledFire
+}
+
+// NewMemoryScheduleStore func to create MemoryScheduleStore
+func NewMemoryScheduleStore() *MemoryScheduleStore {
+	return &MemoryScheduleStore{fires: make(map[string]ScheduledFire)}
+}
+
+func (m *MemoryScheduleStore) Add(ctx context.Context, fire ScheduledFire) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fires[fire.ID] = fire
+	return nil
+}
+
+func (m *MemoryScheduleStore) Remove(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.fires, id)
+	return nil
+}
+
+func (m *MemoryScheduleStore) Due(ctx context.Context, now time.Time) ([]ScheduledFire, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	due := []ScheduledFire{}
+	for _, fire := range m.fires {
+		if !fire.At.After(now) && !fire.ClaimedUntil.After(now) {
+			due = append(due, fire)
+		}
+	}
+	sortFires(due)
+	return due, nil
+}
+
+func (m *MemoryScheduleStore) Claim(ctx context.Context, id string, now, until time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fire, ok := m.fires[id]
+	if !ok || fire.ClaimedUntil.After(now) {
+		return false, nil
+	}
+	fire.ClaimedUntil = until
+	m.fires[id] = fire
+	return true, nil
+}
+
+func (m *MemoryScheduleStore) Pending(ctx context.Context, typ, instance string) ([]ScheduledFire, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pending := []ScheduledFire{}
+	for _, fire := range m.fires {
+		if fire.Type == typ && fire.Instance == instance {
+			pending = append(pending, fire)
+		}
+	}
+	sortFires(pending)
+	return pending, nil
+}
+
+// sortFires orders fires by time, then by ID.
+func sortFires(fires []ScheduledFire) {
+	sort.Slice(fires, func(i, j int) bool {
+		if !fires[i].At.Equal(fires[j].At) {
+			return fires[i].At.Before(fires[j].At)
+		}
+		return fires[i].ID < fires[j].ID
+	})
+}
+
+// WithScheduleStore keeps scheduled fires in store instead of process
+// memory.
+func WithScheduleStore(store ScheduleStore) FSMOption {
+	return func(f *FSM) {
+		f.schedules = store
+	}
+}
+
+// WithScheduleLoader sets how scheduled fires find their instance when it
+// is not held by this process, e.g. after a restart, typically by loading
+// it by fire.Instance from a database.
+func WithScheduleLoader(load func(ctx context.Context, fire ScheduledFire) (interface{}, error)) FSMOption {
+	return func(f *FSM) {
+		f.scheduleLoader = load
+	}
+}
+
+// DefaultScheduleLease is how long a FireDue pass claims a fire for unless
+// WithScheduleLease is set.
+const DefaultScheduleLease = time.Minute
+
+// WithScheduleLease sets how long a FireDue pass claims each fire for. A
+// fire whose pass crashed is run by another pass once its claim expired, so
+// d should exceed the time a fire takes.
+func WithScheduleLease(d time.Duration) FSMOption {
+	return func(f *FSM) {
+		f.scheduleLease = d
+	}
+}
+
+// FireAt func to schedule event to be fired on s at t by RunScheduler or
+// FireDue, returning the ID to cancel it with. The machine of s must have
+// WithIdentity or the FSM WithIDFunc, so the fire finds s again
+func (f *FSM) FireAt(ctx context.Context, s interface{}, event string, t time.Time) (string, error) {
+	machine, ok := f.machine(s)
+	if !ok {
+		return "", InternalError{}
+	}
+	instance, ok := machine.identityOf(s)
+	if !ok {
+		return "", machine.requireIdentity("FireAt")
+	}
+
+	id, err := newScheduleID()
+	if err != nil {
+		return "", err
+	}
+
+	fire := ScheduledFire{ID: id, Type: machine.name, Event: event, Instance: instance, At: t}
+	if err := f.schedules.Add(ctx, fire); err != nil {
+		return "", err
+	}
+
+	f.scheduled.Store(id, s)
+	return id, nil
+}
+
+// pending reports whether a fire is scheduled for s. Fires can only be
+// found again for instances with an identity.
+func (f *FSM) pending(ctx context.Context, machine *fsm, s interface{}) (bool, error) {
+	id, ok := machine.identityOf(s)
+	if !ok {
+		return false, nil
+	}
+	fires, err := f.schedules.Pending(ctx, machine.name, id)
+	return len(fires) > 0, err
+}
+
+// FireAfter func to schedule event to be fired on s once delay passed, see
+// FireAt
+func (f *FSM) FireAfter(ctx context.Context, s interface{}, event string, delay time.Duration) (string, error) {
+	return f.FireAt(ctx, s, event, f.now().Add(delay))
+}
+
+// CancelScheduled func to cancel the scheduled fire with id
+func (f *FSM) CancelScheduled(ctx context.Context, id string) error {
+	f.scheduled.Delete(id)
+	return f.schedules.Remove(ctx, id)
+}
+
+// ScheduleReport summarises a FireDue pass. Due counts the fires the pass
+// claimed, not those claimed by other passes.
+type ScheduleReport struct {
+	Due   int
+	Fired int
+	// Errors maps the ID of a fire to the error loading its instance or
+	// Fire returned. Failed fires are not retried.
+	Errors map[string]error
+}
+
+// FireDue func to fire every scheduled event that is due and remove it
+// from the schedule. Each fire is claimed first, so passes sharing the
+// ScheduleStore run it once, and removed once it ran, so it runs again if
+// the pass stops in between. Only a failing ScheduleStore is returned as an
+// error.
+func (f *FSM) FireDue(ctx context.Context) (ScheduleReport, error) {
+	now := f.now()
+	due, err := f.schedules.Due(ctx, now)
+	if err != nil {
+		return ScheduleReport{}, err
+	}
+
+	report := ScheduleReport{Errors: make(map[string]error)}
+	for _, fire := range due {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		claimed, err := f.schedules.Claim(ctx, fire.ID, now, now.Add(f.scheduleLease))
+		if err != nil {
+			return report, err
+		}
+		if !claimed {
+			continue
+		}
+		report.Due++
+
+		if err := f.fireScheduled(ctx, fire); err != nil {
+			report.Errors[fire.ID] = err
+		} else {
+			report.Fired++
+		}
+
+		if err := f.schedules.Remove(ctx, fire.ID); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+func (f *FSM) fireScheduled(ctx context.Context, fire ScheduledFire) error {
+	s, ok := f.scheduled.LoadAndDelete(fire.ID)
+	if !ok {
+		if f.scheduleLoader == nil {
+			return UnresolvedInstanceError{Type: fire.Type, Instance: fire.Instance}
+		}
+
+		var err error
+		if s, err = f.scheduleLoader(ctx, fire); err != nil {
+			return err
+		}
+	}
+
+	return f.Fire(ctx, s, fire.Event)
+}
+
+// RunScheduler func to call FireDue every interval on the clock of f until
+// ctx is done
+func (f *FSM) RunScheduler(ctx context.Context, interval time.Duration) error {
+	for {
+		if _, err := f.FireDue(ctx); err != nil {
+			return err
+		}
+
+		if err := sleep(ctx, f.timers(), interval); err != nil {
+			return err
+		}
+	}
+}
+
+func newScheduleID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,162 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type manualClock struct{ now time.Time }
+
+type Reservation struct {
+	ID    string
+	State State
+}
+
+func (c *manualClock) Now() time.Time { return c.now }
+
+func TestFireAfter(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryScheduleStore()
+	fsm := NewFSM(WithClock(clock), WithScheduleStore(store), WithIDFunc(byAddress))
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "expire",
+		From: []State{"pending"},
+		To:   State("expired"),
+	}, {
+		Name: "remind",
+		From: []State{"pending"},
+		To:   State("reminded"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("pending")}
+	if _, err := fsm.FireAfter(ctx, testStruct, "expire", time.Hour); err != nil {
+		t.Errorf("FireAfter() error = %v", err)
+	}
+	remind, err := fsm.FireAt(ctx, testStruct, "remind", clock.now.Add(time.Minute))
+	if err != nil {
+		t.Errorf("FireAt() error = %v", err)
+	}
+	if err := fsm.CancelScheduled(ctx, remind); err != nil {
+		t.Errorf("CancelScheduled() error = %v", err)
+	}
+
+	clock.now = clock.now.Add(59 * time.Minute)
+	if report, err := fsm.FireDue(ctx); err != nil || report.Due != 0 || testStruct.State != "pending" {
+		t.Errorf("FireDue() before delay = %+v, %v, State = %v", report, err, testStruct.State)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	if report, err := fsm.FireDue(ctx); err != nil || report.Fired != 1 || testStruct.State != "expired" {
+		t.Errorf("FireDue() after delay = %+v, %v, State = %v", report, err, testStruct.State)
+	}
+	if due, _ := store.Due(ctx, clock.now.Add(time.Hour)); len(due) != 0 {
+		t.Errorf("store still holds %+v", due)
+	}
+}
+
+func TestScheduleSurvivesRestart(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryScheduleStore()
+	events := Events{{
+		Name: "expire",
+		From: []State{"pending"},
+		To:   State("expired"),
+	}}
+	tag := reflect.TypeOf((*Reservation)(nil))
+	ctx := context.Background()
+
+	before := NewFSM(WithClock(clock), WithScheduleStore(store), WithIDFunc(func(s interface{}) string {
+		return s.(*Reservation).ID
+	}))
+	if err := before.Register(tag, "State", events); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if _, err := before.FireAfter(ctx, &Reservation{ID: "o-1", State: "pending"}, "expire", time.Hour); err != nil {
+		t.Errorf("FireAfter() error = %v", err)
+	}
+
+	clock.now = clock.now.Add(time.Hour)
+	loaded := &Reservation{ID: "o-1", State: "pending"}
+	after := NewFSM(WithClock(clock), WithScheduleStore(store))
+	if err := after.Register(tag, "State", events); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	report, err := after.FireDue(ctx)
+	if err != nil || report.Due != 1 || len(report.Errors) != 1 {
+		t.Errorf("FireDue() without loader = %+v, %v", report, err)
+	}
+	for _, err := range report.Errors {
+		if !errors.As(err, new(UnresolvedInstanceError)) {
+			t.Errorf("FireDue() without loader error = %v, want UnresolvedInstanceError", err)
+		}
+	}
+
+	if _, err := before.FireAfter(ctx, loaded, "expire", 0); err != nil {
+		t.Errorf("FireAfter() error = %v", err)
+	}
+	restarted := NewFSM(WithClock(clock), WithScheduleStore(store), WithScheduleLoader(func(ctx context.Context, fire ScheduledFire) (interface{}, error) {
+		if fire.Instance != "o-1" {
+			t.Errorf("fire.Instance = %q, want o-1", fire.Instance)
+		}
+		return loaded, nil
+	}))
+	if err := restarted.Register(tag, "State", events); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if report, err := restarted.FireDue(ctx); err != nil || report.Fired != 1 || loaded.State != "expired" {
+		t.Errorf("FireDue() with loader = %+v, %v, State = %v", report, err, loaded.State)
+	}
+}
+
+func TestFireDueClaims(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryScheduleStore()
+	fsm := NewFSM(WithClock(clock), WithScheduleStore(store), WithIDFunc(func(s interface{}) string {
+		return s.(*Reservation).ID
+	}))
+	if err := fsm.Register(reflect.TypeOf((*Reservation)(nil)), "State", Events{{
+		Name: "expire",
+		From: []State{"pending"},
+		To:   State("expired"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	reservation := &Reservation{ID: "o-1", State: "pending"}
+	id, err := fsm.FireAt(ctx, reservation, "expire", clock.now)
+	if err != nil {
+		t.Errorf("FireAt() error = %v", err)
+	}
+
+	// Another replica claimed the fire and stopped before running it.
+	if claimed, err := store.Claim(ctx, id, clock.now, clock.now.Add(DefaultScheduleLease)); !claimed || err != nil {
+		t.Errorf("Claim() = %v, %v, want true", claimed, err)
+	}
+	if report, err := fsm.FireDue(ctx); err != nil || report.Due != 0 || reservation.State != "pending" {
+		t.Errorf("FireDue() while claimed = %+v, %v, State = %v", report, err, reservation.State)
+	}
+
+	clock.now = clock.now.Add(DefaultScheduleLease)
+	if report, err := fsm.FireDue(ctx); err != nil || report.Fired != 1 || reservation.State != "expired" {
+		t.Errorf("FireDue() once the claim expired = %+v, %v, State = %v", report, err, reservation.State)
+	}
+	if pending, _ := store.Pending(ctx, "*fsm.Reservation", "o-1"); len(pending) != 0 {
+		t.Errorf("store still holds %+v", pending)
+	}
+
+	anonymous := NewFSM()
+	if err := anonymous.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{Name: "expire", From: []State{"pending"}, To: State("expired")}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if _, err := anonymous.FireAt(ctx, &TestStruct{State: "pending"}, "expire", clock.now); !errors.As(err, new(IdentityRequiredError)) {
+		t.Errorf("FireAt() without identity error = %v, want IdentityRequiredError", err)
+	}
+}
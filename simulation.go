@@ -0,0 +1,101 @@
+package fsm
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"sort"
+)
+
+// SimulationStep is one transition taken by a Simulator.
+type SimulationStep struct {
+	Event string
+	From  State
+	To    State
+}
+
+// Simulator walks the transition graph of a machine choosing events at
+// random according to EventTransition.Weight. It never touches instances,
+// guards or callbacks, so weights have no effect on Fire.
+type Simulator struct {
+	machine *fsm
+	rng     *rand.Rand
+}
+
+// NewSimulator func to create a Simulator for the default machine of tag
+// drawing from src, e.g. rand.NewPCG(seed, 0) for reproducible runs
+func (f *FSM) NewSimulator(tag reflect.Type, src rand.Source) (*Simulator, error) {
+	machines := f.machinesOf(tag)
+	if len(machines) == 0 {
+		return nil, InternalError{}
+	}
+
+	return &Simulator{machine: machines[0], rng: rand.New(src)}, nil
+}
+
+// Walk takes up to steps random transitions starting at from, stopping
+// early in states without outgoing events.
+func (s *Simulator) Walk(from State, steps int) []SimulationStep {
+	path := []SimulationStep{}
+	state := from
+
+	for i := 0; i < steps; i++ {
+		event, ok := s.choose(state)
+		if !ok {
+			break
+		}
+
+		to := s.machine.table().transitions[eventKey{event, state}]
+		path = append(path, SimulationStep{Event: event, From: state, To: to})
+		state = to
+	}
+
+	return path
+}
+
+// Distribution runs the given number of walks and counts the states they
+// end in.
+func (s *Simulator) Distribution(from State, steps, runs int) map[State]int {
+	counts := make(map[State]int)
+
+	for i := 0; i < runs; i++ {
+		state := from
+		if path := s.Walk(from, steps); len(path) > 0 {
+			state = path[len(path)-1].To
+		}
+		counts[state]++
+	}
+
+	return counts
+}
+
+// choose picks an event declared from state proportionally to its weight.
+func (s *Simulator) choose(state State) (string, bool) {
+	events := append([]string(nil), s.machine.table().initialStates[state]...)
+	if len(events) == 0 {
+		return "", false
+	}
+	// Map iteration order is random, sort to keep seeded runs reproducible.
+	sort.Strings(events)
+
+	total := 0.0
+	for _, event := range events {
+		total += s.machine.weight(eventKey{event, state})
+	}
+
+	n := s.rng.Float64() * total
+	for _, event := range events {
+		n -= s.machine.weight(eventKey{event, state})
+		if n < 0 {
+			return event, true
+		}
+	}
+	return events[len(events)-1], true
+}
+
+func (f *fsm) weight(key eventKey) float64 {
+	t := f.table()
+	if w, ok := t.weights[key]; ok {
+		return w
+	}
+	return 1
+}
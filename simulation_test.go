@@ -0,0 +1,44 @@
+package fsm
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestSimulatorWeights(t *testing.T) {
+	tag := reflect.TypeOf((*TestStruct)(nil))
+
+	fsm := NewFSM()
+	if err := fsm.Register(tag, "State", Events{{
+		Name:   "approve",
+		From:   []State{"review"},
+		To:     State("approved"),
+		Weight: 9,
+	}, {
+		Name:   "reject",
+		From:   []State{"review"},
+		To:     State("rejected"),
+		Weight: 1,
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	sim, err := fsm.NewSimulator(tag, rand.NewPCG(1, 2))
+	if err != nil {
+		t.Fatalf("fsm.NewSimulator() error = %v", err)
+	}
+
+	counts := sim.Distribution(State("review"), 5, 1000)
+	if counts["approved"]+counts["rejected"] != 1000 {
+		t.Errorf("expected every run to end in a terminal state, got %v", counts)
+	}
+	if counts["approved"] < 850 || counts["approved"] > 950 {
+		t.Errorf("expected about 90%% approvals, got %v", counts)
+	}
+
+	again, _ := fsm.NewSimulator(tag, rand.NewPCG(1, 2))
+	if other := again.Distribution(State("review"), 5, 1000); other["approved"] != counts["approved"] {
+		t.Errorf("expected seeded runs to be reproducible, got %v and %v", counts, other)
+	}
+}
@@ -0,0 +1,133 @@
+package fsm
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+)
+
+// snapshot is the runtime data of one instance as written by Snapshot.
+type snapshot struct {
+	Vars    map[string]json.RawMessage `json:"vars,omitempty"`
+	History []HistoryEntry             `json:"history,omitempty"`
+}
+
+// Snapshot func to return the runtime data the FSM keeps for s, its
+// extended-state variables and history, to be persisted alongside it and
+// given to Restore after a restart
+func (f *FSM) Snapshot(ctx context.Context, s interface{}) ([]byte, error) {
+	machine, ok := f.machine(s)
+	if !ok {
+		return nil, InternalError{}
+	}
+
+	var snap snapshot
+	err := machine.exec(ctx, s, func() error {
+		v, err := machine.loadVars(ctx, s)
+		if err != nil {
+			return err
+		}
+		if v != nil && len(v.values) > 0 {
+			snap.Vars = make(map[string]json.RawMessage, len(v.values))
+			for name, value := range v.values {
+				data, err := json.Marshal(value)
+				if err != nil {
+					return err
+				}
+				snap.Vars[name] = data
+			}
+		}
+
+		if f.historyLimit > 0 {
+			snap.History, err = machine.History(ctx, s)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(snap)
+}
+
+// Restore func to replace the runtime data of s with a Snapshot, e.g. one
+// taken of the same entity before the process restarted. Variables the
+// machine no longer declares are dropped.
+func (f *FSM) Restore(ctx context.Context, s interface{}, data []byte) error {
+	machine, ok := f.machine(s)
+	if !ok {
+		return InternalError{}
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+
+	return machine.exec(ctx, s, func() error {
+		if err := machine.restoreVars(ctx, s, snap.Vars); err != nil {
+			return err
+		}
+		if f.historyLimit > 0 {
+			return machine.restoreHistory(ctx, s, snap.History)
+		}
+		return nil
+	})
+}
+
+func (f *fsm) restoreVars(ctx context.Context, s interface{}, raw map[string]json.RawMessage) error {
+	v, err := f.loadVars(ctx, s)
+	if err != nil || v == nil {
+		return err
+	}
+
+	clear(v.values)
+	for name, decl := range f.vars {
+		v.changed[name] = true
+
+		msg, ok := raw[name]
+		if !ok {
+			v.values[name] = decl.initial
+			continue
+		}
+
+		ptr := reflect.New(decl.typ)
+		if err := json.Unmarshal(msg, ptr.Interface()); err != nil {
+			return err
+		}
+		v.values[name] = ptr.Elem().Interface()
+	}
+	return f.saveVars(ctx, s, v)
+}
+
+func (f *fsm) restoreHistory(ctx context.Context, s interface{}, entries []HistoryEntry) error {
+	f.historyMu.Lock()
+	defer f.historyMu.Unlock()
+
+	key, max := f.historyKey(s), f.parent.historyLimit
+	ds, ok := f.parent.store.(DeltaStore)
+	if !ok {
+		if len(entries) > max {
+			entries = entries[len(entries)-max:]
+		}
+		data, err := json.Marshal(entries)
+		if err != nil {
+			return err
+		}
+		return f.parent.store.Save(ctx, key, data, 0)
+	}
+
+	if err := ds.Delete(ctx, key); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if err := ds.Append(ctx, key, data, max); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	events := Events{{
+		Name: "retry",
+		From: []State{"failed"},
+		To:   State("failed"),
+		After: func(ctx context.Context, e *Event) error {
+			return e.Vars.Set("retries", Var[int](e.Vars, "retries")+1)
+		},
+	}}
+
+	ctx := context.Background()
+	id := WithIDFunc(func(s interface{}) string { return "entity-1" })
+	before := NewFSM(WithHistory(10), id)
+	if err := before.Register(reflect.TypeOf((*TestStruct)(nil)), "State", events, DeclareVar("retries", 0)); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("failed")}
+	for i := 0; i < 2; i++ {
+		if err := before.Fire(ctx, testStruct, "retry"); err != nil {
+			t.Errorf("Fire() #%d error = %v", i, err)
+		}
+	}
+
+	data, err := before.Snapshot(ctx, testStruct)
+	if err != nil {
+		t.Errorf("Snapshot() error = %v", err)
+	}
+
+	// A new process loads the entity into a new struct.
+	after := NewFSM(WithHistory(10), id)
+	if err := after.Register(reflect.TypeOf((*TestStruct)(nil)), "State", events, DeclareVar("retries", 0)); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	loaded := &TestStruct{State: testStruct.State}
+	if err := after.Restore(ctx, loaded, data); err != nil {
+		t.Errorf("Restore() error = %v", err)
+	}
+
+	vars, err := after.Vars(ctx, loaded)
+	if err != nil || Var[int](vars, "retries") != 2 {
+		t.Errorf("Vars() after Restore = %v, %v, want retries 2", vars, err)
+	}
+
+	history, err := after.History(ctx, loaded)
+	if err != nil || len(history) != 2 || history[1].Event != "retry" {
+		t.Errorf("History() after Restore = %v, %v, want 2 entries", history, err)
+	}
+}
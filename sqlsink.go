@@ -0,0 +1,82 @@
+package fsm
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// DefaultSQLSinkQuery is the statement SQLSink inserts a batch with when
+// Query is unset.
+const DefaultSQLSinkQuery = "INSERT INTO fsm_history (recorded_at, entries) VALUES (?, ?)"
+
+// SQLSink is an AuditSink inserting history entries into DB in batches. A
+// batch is one row, holding its entries as JSON lines compressed with
+// Compression if set, and is inserted by Query with the time of the flush
+// and the batch as arguments, e.g. "... VALUES ($1, $2)" for PostgreSQL.
+// Clock tells the time of the flush and times Run, the system clock if nil.
+// Run it to also flush every FlushInterval, and Flush before the process
+// exits.
+type SQLSink struct {
+	DB            *sql.DB
+	Query         string
+	Compression   Compression
+	BatchSize     int
+	FlushInterval time.Duration
+	Clock         Clock
+
+	mu      sync.Mutex
+	pending []HistoryEntry
+}
+
+func (s *SQLSink) Record(ctx context.Context, entry HistoryEntry) error {
+	s.mu.Lock()
+	s.pending = append(s.pending, entry)
+	full := len(s.pending) >= s.batchSize()
+	s.mu.Unlock()
+
+	if full {
+		return s.Flush(ctx)
+	}
+	return nil
+}
+
+func (s *SQLSink) batchSize() int {
+	if s.BatchSize > 0 {
+		return s.BatchSize
+	}
+	return DefaultBatchSize
+}
+
+// Flush inserts the buffered entries as one batch. They are kept for the
+// next flush if the insert fails.
+func (s *SQLSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	batch, err := encodeBatch(s.pending, s.Compression)
+	if err != nil {
+		return err
+	}
+
+	query := s.Query
+	if query == "" {
+		query = DefaultSQLSinkQuery
+	}
+	if _, err := s.DB.ExecContext(ctx, query, nowOf(s.Clock), batch); err != nil {
+		return err
+	}
+	s.pending = nil
+	return nil
+}
+
+// Run flushes every FlushInterval, DefaultFlushInterval if unset, until ctx
+// is done, then flushes once more.
+func (s *SQLSink) Run(ctx context.Context) error {
+	return runFlushes(ctx, timersOf(s.Clock), s.FlushInterval, s.Flush)
+}
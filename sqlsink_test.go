@@ -0,0 +1,123 @@
+package fsm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingDriver is a database/sql driver keeping the arguments of every
+// statement it executes, failing while err is set.
+type recordingDriver struct {
+	mu    sync.Mutex
+	err   error
+	query string
+	rows  [][]driver.Value
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) { return recordingConn{d}, nil }
+
+type recordingConn struct{ d *recordingDriver }
+
+func (c recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return recordingStmt{c.d, query}, nil
+}
+func (c recordingConn) Close() error              { return nil }
+func (c recordingConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type recordingStmt struct {
+	d     *recordingDriver
+	query string
+}
+
+func (s recordingStmt) Close() error  { return nil }
+func (s recordingStmt) NumInput() int { return -1 }
+func (s recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if s.d.err != nil {
+		return nil, s.d.err
+	}
+	s.d.query = s.query
+	s.d.rows = append(s.d.rows, args)
+	return driver.RowsAffected(1), nil
+}
+func (s recordingStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+var sqlDriver = &recordingDriver{}
+
+func init() {
+	sql.Register("fsm-recording", sqlDriver)
+}
+
+func TestSQLSink(t *testing.T) {
+	db, err := sql.Open("fsm-recording", "")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close()
+
+	clock := &manualClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	sink := &SQLSink{DB: db, Compression: Gzip(gzip.BestSpeed), BatchSize: 2, Clock: clock}
+	ctx := context.Background()
+
+	sqlDriver.err = errors.New("database down")
+	if err := sink.Record(ctx, HistoryEntry{Event: "pay", To: "paid"}); err != nil {
+		t.Errorf("Record() error = %v", err)
+	}
+	if err := sink.Record(ctx, HistoryEntry{Event: "ship", To: "shipped"}); !errors.Is(err, sqlDriver.err) {
+		t.Errorf("Record() error = %v, want %v", err, sqlDriver.err)
+	}
+
+	sqlDriver.err = nil
+	if err := sink.Flush(ctx); err != nil {
+		t.Errorf("Flush() error = %v", err)
+	}
+	if err := sink.Flush(ctx); err != nil {
+		t.Errorf("Flush() error = %v", err)
+	}
+
+	if len(sqlDriver.rows) != 1 {
+		t.Fatalf("inserted %d batches, want 1", len(sqlDriver.rows))
+	}
+	if sqlDriver.query != DefaultSQLSinkQuery {
+		t.Errorf("inserted with %q, want DefaultSQLSinkQuery", sqlDriver.query)
+	}
+
+	if at, _ := sqlDriver.rows[0][0].(time.Time); !at.Equal(clock.now) {
+		t.Errorf("inserted at %v, want %v", sqlDriver.rows[0][0], clock.now)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(sqlDriver.rows[0][1].([]byte)))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("reading batch error = %v", err)
+	}
+
+	var tos []State
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var entry HistoryEntry
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		tos = append(tos, entry.To)
+	}
+	if want := []State{"paid", "shipped"}; !reflect.DeepEqual(tos, want) {
+		t.Errorf("inserted %v, want %v", tos, want)
+	}
+}
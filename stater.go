@@ -0,0 +1,187 @@
+package fsm
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Stater is implemented by state field types that are not strings, e.g.
+// integer status codes. SetStateValue is called on a pointer to the field.
+type Stater interface {
+	StateValue() State
+	SetStateValue(State)
+}
+
+// StateConverter converts between State and the value stored in a state
+// field of a type that neither is a string nor implements Stater.
+type StateConverter struct {
+	ToState   func(value interface{}) (State, error)
+	FromState func(state State) (interface{}, error)
+}
+
+// RegisterOption configures a machine registered with FSM.Register.
+type RegisterOption func(*fsm)
+
+// WithStateConverter reads and writes the state field through c.
+func WithStateConverter(c StateConverter) RegisterOption {
+	return func(f *fsm) {
+		f.converter = &c
+	}
+}
+
+var staterType = reflect.TypeOf((*Stater)(nil)).Elem()
+
+type accessMode int
+
+const (
+	accessString accessMode = iota
+	accessStater
+	accessConverter
+	accessInvalid
+)
+
+// fieldAccess is the resolved location of the state field in a struct type.
+type fieldAccess struct {
+	index []int
+	mode  accessMode
+}
+
+// resolveField locates column in the struct tag points to. It is done once
+// at Register so Fire doesn't look the field up by name every call.
+func (f *fsm) resolveField(tag reflect.Type) fieldAccess {
+	if tag.Kind() != reflect.Ptr || tag.Elem().Kind() != reflect.Struct {
+		return fieldAccess{mode: accessInvalid}
+	}
+
+	sf, ok := fieldByPath(tag.Elem(), f.column)
+	if !ok {
+		return fieldAccess{mode: accessInvalid}
+	}
+
+	access := fieldAccess{index: sf.Index}
+	switch {
+	case f.converter != nil:
+		access.mode = accessConverter
+	case reflect.PointerTo(sf.Type).Implements(staterType):
+		access.mode = accessStater
+	case sf.Type.Kind() == reflect.String:
+		access.mode = accessString
+	default:
+		access.mode = accessInvalid
+	}
+	return access
+}
+
+// fieldByPath looks up the exported field at the dotted path in t, e.g.
+// "Meta.Status", following nested structs and pointers to them. Fields
+// promoted from embedded structs are found by their own name. The index of
+// the returned field is relative to t.
+func fieldByPath(t reflect.Type, path string) (reflect.StructField, bool) {
+	var index []int
+	var sf reflect.StructField
+	for _, name := range strings.Split(path, ".") {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return sf, false
+		}
+
+		var ok bool
+		if sf, ok = t.FieldByName(name); !ok || !sf.IsExported() {
+			return sf, false
+		}
+		index = append(index, sf.Index...)
+		t = sf.Type
+	}
+
+	sf.Index = index
+	return sf, true
+}
+
+// throughPointer reports whether the field at index in the struct type t is
+// reached through a pointer.
+func throughPointer(t reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		t = t.Field(i).Type
+		if t.Kind() == reflect.Ptr {
+			return true
+		}
+	}
+	return false
+}
+
+// accessFor returns the state field location for instances of type t. A
+// machine registered for an interface resolves it once per concrete type.
+func (f *fsm) accessFor(t reflect.Type) fieldAccess {
+	if f.tag.Kind() != reflect.Interface {
+		if t != f.tag {
+			return fieldAccess{mode: accessInvalid}
+		}
+		return f.access
+	}
+
+	if access, ok := f.accesses.Load(t); ok {
+		return access.(fieldAccess)
+	}
+
+	access := fieldAccess{mode: accessInvalid}
+	if t.Implements(f.tag) {
+		access = f.resolveField(t)
+	}
+	f.accesses.Store(t, access)
+	return access
+}
+
+// getSourceState returns the state field of s and its current value.
+func (f *fsm) getSourceState(s interface{}) (field reflect.Value, state State, err error) {
+	val := reflect.ValueOf(s)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return field, state, InternalError{}
+	}
+
+	access := f.accessFor(val.Type())
+	if access.mode == accessInvalid {
+		return field, state, InternalError{}
+	}
+
+	// Index chains through embedded pointers can't be followed if they are nil.
+	field, err = val.Elem().FieldByIndexErr(access.index)
+	if err != nil {
+		return field, state, InternalError{}
+	}
+
+	switch access.mode {
+	case accessConverter:
+		state, err = f.converter.ToState(field.Interface())
+	case accessStater:
+		state = field.Addr().Interface().(Stater).StateValue()
+	default:
+		state = State(field.String())
+	}
+
+	return field, state, err
+}
+
+// setState stores state in the state field returned by getSourceState.
+func (f *fsm) setState(field reflect.Value, access fieldAccess, state State) error {
+	switch access.mode {
+	case accessConverter:
+		value, err := f.converter.FromState(state)
+		if err != nil {
+			return err
+		}
+
+		v := reflect.ValueOf(value)
+		if !v.IsValid() || !v.Type().ConvertibleTo(field.Type()) {
+			return InternalError{}
+		}
+		field.Set(v.Convert(field.Type()))
+	case accessStater:
+		field.Addr().Interface().(Stater).SetStateValue(state)
+	default:
+		field.SetString(string(state))
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type statusCode int
+
+var statusNames = map[statusCode]State{0: "started", 1: "finished"}
+
+func (c statusCode) StateValue() State {
+	return statusNames[c]
+}
+
+func (c *statusCode) SetStateValue(s State) {
+	for code, name := range statusNames {
+		if name == s {
+			*c = code
+		}
+	}
+}
+
+type StaterStruct struct {
+	Status statusCode
+}
+
+type IntStruct struct {
+	Status int
+}
+
+func TestStaterField(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*StaterStruct)(nil)), "Status", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	s := &StaterStruct{}
+	if err := fsm.Fire(context.Background(), s, "make"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	if s.Status != 1 {
+		t.Errorf("expected status 1, got %d", s.Status)
+	}
+}
+
+func TestStateConverter(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*IntStruct)(nil)), "Status", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+	}}, WithStateConverter(StateConverter{
+		ToState: func(value interface{}) (State, error) {
+			return statusNames[statusCode(value.(int))], nil
+		},
+		FromState: func(s State) (interface{}, error) {
+			for code, name := range statusNames {
+				if name == s {
+					return int(code), nil
+				}
+			}
+			return nil, fmt.Errorf("unknown state %s", s)
+		},
+	})); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	s := &IntStruct{}
+	if err := fsm.Fire(context.Background(), s, "make"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	if s.Status != 1 {
+		t.Errorf("expected status 1, got %d", s.Status)
+	}
+}
@@ -0,0 +1,131 @@
+package fsm
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// stateConfig holds what is configured per state after Register, e.g. final
+// states. It is replaced as a whole so it can be changed while events are
+// fired.
+type stateConfig struct {
+	initial    State
+	finals     map[State]bool
+	onEnter    map[State]Callback
+	onComplete Callback
+	onAny      []hook
+
+	propagations map[State][]Propagation
+	invariants   []Invariant
+}
+
+func (f *fsm) stateConfig() *stateConfig {
+	if c := f.states.Load(); c != nil {
+		return c
+	}
+	return &stateConfig{}
+}
+
+// updateStates applies fn to a copy of the state configuration of f.
+func (f *fsm) updateStates(fn func(c *stateConfig)) {
+	for {
+		old := f.states.Load()
+		c := &stateConfig{
+			finals:       make(map[State]bool),
+			onEnter:      make(map[State]Callback),
+			propagations: make(map[State][]Propagation),
+		}
+		if old != nil {
+			for state := range old.finals {
+				c.finals[state] = true
+			}
+			for state, cb := range old.onEnter {
+				c.onEnter[state] = cb
+			}
+			c.initial, c.onComplete = old.initial, old.onComplete
+			c.onAny = append([]hook(nil), old.onAny...)
+			c.invariants = append([]Invariant(nil), old.invariants...)
+			for state, ps := range old.propagations {
+				c.propagations[state] = append([]Propagation(nil), ps...)
+			}
+		}
+		fn(c)
+
+		if f.states.CompareAndSwap(old, c) {
+			return
+		}
+	}
+}
+
+// OnAnyTransition func to add a callback run after every successful
+// transition of the default machine of tag, following the After callback of
+// the transition, e.g. to invalidate caches or publish events. See
+// HookPriority and Concurrent for their order
+func (f *FSM) OnAnyTransition(tag reflect.Type, fn Callback, options ...HookOption) error {
+	h := hook{fn: fn}
+	for _, option := range options {
+		option(&h)
+	}
+
+	return f.updateStates(tag, func(c *stateConfig) {
+		c.onAny = addHook(c.onAny, h)
+	})
+}
+
+// transitioned runs the OnAnyTransition callbacks, stopping at the first
+// error.
+func (f *fsm) transitioned(ctx context.Context, e *Event) error {
+	return runHooks(ctx, e, f.stateConfig().onAny)
+}
+
+// updateStates applies fn to the state configuration of the default
+// machine of tag and of its other versions.
+func (f *FSM) updateStates(tag reflect.Type, fn func(c *stateConfig)) error {
+	machines, err := f.defaultMachines(tag)
+	if err != nil {
+		return err
+	}
+
+	for _, machine := range machines {
+		machine.updateStates(fn)
+	}
+	return nil
+}
+
+// defaultMachines returns the default machine of tag followed by its other
+// versions, see FSM.RegisterVersion.
+func (f *FSM) defaultMachines(tag reflect.Type) ([]*fsm, error) {
+	machine, err := f.defaultMachine(tag)
+	if err != nil {
+		return nil, err
+	}
+	return append([]*fsm{machine}, f.olderVersions(tag, machine)...), nil
+}
+
+// olderVersions returns the versions of tag other than machine, in version
+// order.
+func (f *FSM) olderVersions(tag reflect.Type, machine *fsm) []*fsm {
+	set := f.versionSet(tag)
+	if set == nil {
+		return nil
+	}
+
+	var machines []*fsm
+	for _, version := range slices.Sorted(maps.Keys(set.machines)) {
+		if m := set.machines[version]; m != machine {
+			machines = append(machines, m)
+		}
+	}
+	return machines
+}
+
+// defaultMachine returns the default machine of tag.
+func (f *FSM) defaultMachine(tag reflect.Type) (*fsm, error) {
+	machines := f.machinesOf(tag)
+	if len(machines) == 0 {
+		return nil, InternalError{}
+	}
+	return machines[0], nil
+}
@@ -0,0 +1,179 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StateStore persists runtime data the FSM keeps per instance, e.g. quota
+// counters. Load returns nil without an error if the key does not exist.
+type StateStore interface {
+	Load(ctx context.Context, key string) ([]byte, error)
+	Save(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// DeltaStore is implemented by StateStores able to update parts of a value.
+// The FSM then writes only the variables a transition changed and appends
+// history entries instead of rewriting whole snapshots.
+type DeltaStore interface {
+	StateStore
+	// LoadFields returns the fields stored under key and their version, nil
+	// and zero if the key does not exist.
+	LoadFields(ctx context.Context, key string) (map[string][]byte, uint64, error)
+	// SaveFields sets fields under key, leaving other fields untouched, if
+	// key is still at version. It returns the new version, or
+	// VersionConflictError if key was changed since it was loaded.
+	SaveFields(ctx context.Context, key string, fields map[string][]byte, version uint64) (uint64, error)
+	// Append adds value to the list under key, keeping at most the last max
+	// values.
+	Append(ctx context.Context, key string, value []byte, max int) error
+	// LoadList returns the list under key, oldest first.
+	LoadList(ctx context.Context, key string) ([][]byte, error)
+}
+
+// MemoryStore is an in-process StateStore. A zero ttl never expires.
+type MemoryStore struct {
+	clock   Clock
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	fields  map[string]*memoryFields
+	lists   map[string][][]byte
+}
+
+type memoryFields struct {
+	values  map[string][]byte
+	version uint64
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryStore func to create MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]memoryEntry),
+		fields:  make(map[string]*memoryFields),
+		lists:   make(map[string][][]byte),
+	}
+}
+
+func (m *MemoryStore) now() time.Time {
+	if m.clock == nil {
+		return time.Now()
+	}
+	return m.clock.Now()
+}
+
+func (m *MemoryStore) Load(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, nil
+	}
+
+	if !e.expires.IsZero() && m.now().After(e.expires) {
+		delete(m.entries, key)
+		return nil, nil
+	}
+
+	return append([]byte(nil), e.value...), nil
+}
+
+func (m *MemoryStore) Save(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := memoryEntry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		e.expires = m.now().Add(ttl)
+	}
+	m.entries[key] = e
+	return nil
+}
+
+func (m *MemoryStore) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	delete(m.fields, key)
+	delete(m.lists, key)
+	return nil
+}
+
+func (m *MemoryStore) LoadFields(ctx context.Context, key string) (map[string][]byte, uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, ok := m.fields[key]
+	if !ok {
+		return nil, 0, nil
+	}
+
+	values := make(map[string][]byte, len(f.values))
+	for name, value := range f.values {
+		values[name] = append([]byte(nil), value...)
+	}
+	return values, f.version, nil
+}
+
+func (m *MemoryStore) SaveFields(ctx context.Context, key string, fields map[string][]byte, version uint64) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, ok := m.fields[key]
+	if !ok {
+		f = &memoryFields{values: make(map[string][]byte)}
+	}
+	if f.version != version {
+		return 0, VersionConflictError{Key: key}
+	}
+
+	for name, value := range fields {
+		f.values[name] = append([]byte(nil), value...)
+	}
+	f.version++
+	m.fields[key] = f
+	return f.version, nil
+}
+
+func (m *MemoryStore) Append(ctx context.Context, key string, value []byte, max int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := append(m.lists[key], append([]byte(nil), value...))
+	if max > 0 && len(list) > max {
+		list = append([][]byte(nil), list[len(list)-max:]...)
+	}
+	m.lists[key] = list
+	return nil
+}
+
+func (m *MemoryStore) LoadList(ctx context.Context, key string) ([][]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([][]byte, len(m.lists[key]))
+	for i, value := range m.lists[key] {
+		list[i] = append([]byte(nil), value...)
+	}
+	return list, nil
+}
+
+// instanceKey returns the key runtime data of s is stored under, derived
+// from its identity if WithIdentity or WithIDFunc is set and from its
+// address otherwise. Data outliving a Fire call is only kept for identified
+// instances, see requireIdentity.
+func (f *fsm) instanceKey(s interface{}) string {
+	if id, ok := f.identityOf(s); ok {
+		return f.name + ":" + f.column + ":" + id
+	}
+	return fmt.Sprintf("%s:%s:%p", f.name, f.column, s)
+}
@@ -0,0 +1,50 @@
+package fsm
+
+// WithStates declares every state of the machine. Register fails with
+// SchemaError if a transition leaves from or leads to another state, so
+// typos are caught when the machine is defined. See StrictStates.
+func WithStates(states ...State) RegisterOption {
+	return func(f *fsm) {
+		if f.validStates == nil {
+			f.validStates = make(map[State]bool)
+		}
+		for _, state := range states {
+			f.validStates[state] = true
+		}
+	}
+}
+
+// StrictStates makes Fire refuse instances whose state is not declared by
+// WithStates with UnknownStateError, catching corrupted rows early. Empty
+// states are left to RequireInitialized. Register fails with SchemaError
+// without WithStates.
+func StrictStates() RegisterOption {
+	return func(f *fsm) {
+		f.strictStates = true
+	}
+}
+
+// checkStates returns SchemaError if e names a state not declared by
+// WithStates.
+func (f *fsm) checkStates(e EventTransition) error {
+	if f.validStates == nil {
+		return nil
+	}
+
+	states := append(append([]State{e.To}, e.From...), e.Targets...)
+	for _, state := range states {
+		if state != "" && !f.validStates[state] {
+			return SchemaError{Event: e.Name, Reason: "undeclared state " + string(state)}
+		}
+	}
+	return nil
+}
+
+// checkState returns UnknownStateError in strict mode if state is not
+// declared.
+func (f *fsm) checkState(event string, state State) error {
+	if !f.strictStates || state == "" || f.validStates[state] {
+		return nil
+	}
+	return UnknownStateError{Event: event, State: string(state)}
+}
@@ -0,0 +1,61 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStrictStates(t *testing.T) {
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	states := WithStates("created", "paid", "canceled")
+
+	fsm := NewFSM()
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "pay",
+		From: []State{"created"},
+		To:   State("payed"),
+	}}, states); !errors.As(err, new(SchemaError)) {
+		t.Errorf("fsm.Register() with a typo error = %v, want SchemaError", err)
+	}
+
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "pay",
+		From: []State{"created"},
+		To:   State("paid"),
+	}, {
+		Name: "cancel",
+		From: []State{"created", "paid"},
+		To:   State("canceled"),
+	}}, states, StrictStates()); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := fsm.Fire(ctx, &TestStruct{State: "created"}, "pay"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	corrupted := &TestStruct{State: "Created"}
+	if err := fsm.Fire(ctx, corrupted, "cancel"); !errors.As(err, new(UnknownStateError)) {
+		t.Errorf("Fire() on a corrupted state error = %v, want UnknownStateError", err)
+	}
+	if err := fsm.ForceState(ctx, corrupted, "cancelled", WithReason("repair")); !errors.As(err, new(UnknownStateError)) {
+		t.Errorf("ForceState() to an undeclared state error = %v, want UnknownStateError", err)
+	}
+	if err := fsm.ForceState(ctx, corrupted, "created", WithReason("repair")); err != nil {
+		t.Errorf("ForceState() error = %v", err)
+	}
+}
+
+func TestStrictStatesWithoutStates(t *testing.T) {
+	err := NewFSM().Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "pay",
+		From: []State{"created"},
+		To:   State("paid"),
+	}}, StrictStates())
+	if !errors.As(err, new(SchemaError)) {
+		t.Errorf("fsm.Register() error = %v, want SchemaError", err)
+	}
+}
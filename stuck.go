@@ -0,0 +1,212 @@
+package fsm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// StuckReason tells why an instance is considered stuck.
+type StuckReason string
+
+const (
+	// StuckDeadEnd instances have no permitted events and are not in a
+	// final state.
+	StuckDeadEnd StuckReason = "dead_end"
+	// StuckSLABreached instances stayed in their state longer than its SLA
+	// and have no scheduled fire pending, see FireAt.
+	StuckSLABreached StuckReason = "sla_breached"
+)
+
+// StuckInstance is an instance reported by a StuckDetector.
+type StuckInstance struct {
+	// Index of the instance in the listed slice.
+	Index    int
+	Instance interface{}
+	State    State
+	Reason   StuckReason
+	// Tenant is the tenant of the instance, see WithTenantFunc.
+	Tenant string
+	// Since is when the instance entered State, zero if unknown or if State
+	// has no SLA.
+	Since time.Time
+}
+
+// StuckMetrics is implemented by Metrics that also track stuck instances.
+// StuckDetector reports the count per type, tenant, state and reason of each
+// pass, zero for those counted by an earlier pass only.
+type StuckMetrics interface {
+	SetStuck(typ, tenant string, state State, reason StuckReason, n int)
+}
+
+// OccupancyMetrics is implemented by Metrics that also track how many
+// instances are in each state. StuckDetector reports the count per type,
+// tenant and state of the instances listed by each pass, zero for those
+// counted by an earlier pass only.
+type OccupancyMetrics interface {
+	SetOccupancy(typ, tenant string, state State, n int)
+}
+
+// StuckDetector lists instances and classifies those that are stuck: dead
+// ends, or instances exceeding the SLA of their state.
+type StuckDetector struct {
+	FSM  *FSM
+	List func(ctx context.Context) ([]interface{}, error)
+	// SLA is the longest an instance may stay in each state.
+	SLA map[State]time.Duration
+	// Since returns when s entered its current state. It defaults to the
+	// WithStateChangedAt field of s or else to the time of its last history
+	// entry, which needs WithHistory and WithIdentity or WithIDFunc; SLA
+	// checks fail with IdentityRequiredError without either.
+	Since func(ctx context.Context, s interface{}) (time.Time, error)
+	// Report, if set, is called for every stuck instance.
+	Report func(ctx context.Context, stuck StuckInstance)
+
+	mu        sync.Mutex
+	stuck     gauges[stuckKey]     // counts reported to StuckMetrics
+	occupancy gauges[occupancyKey] // counts reported to OccupancyMetrics
+}
+
+type occupancyKey struct {
+	typ    string
+	tenant string
+	state  State
+}
+
+type stuckKey struct {
+	occupancyKey
+	reason StuckReason
+}
+
+// gauges remembers the keys reported to a gauge.
+type gauges[K comparable] map[K]bool
+
+// set reports counts with fn, and zero for the keys reported before that
+// are missing from counts.
+func (g *gauges[K]) set(counts map[K]int, fn func(key K, n int)) {
+	if *g == nil {
+		*g = make(gauges[K])
+	}
+	for key := range *g {
+		if _, ok := counts[key]; !ok {
+			fn(key, 0)
+		}
+	}
+	for key, n := range counts {
+		fn(key, n)
+		(*g)[key] = true
+	}
+}
+
+// Detect runs a single pass and returns the stuck instances. It stops at the
+// first error.
+func (d *StuckDetector) Detect(ctx context.Context) ([]StuckInstance, error) {
+	objs, err := d.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[stuckKey]int)
+	occupancy := make(map[occupancyKey]int)
+
+	stuck := []StuckInstance{}
+	for i, obj := range objs {
+		if err := ctx.Err(); err != nil {
+			return stuck, err
+		}
+
+		st, typ, ok, err := d.classify(ctx, obj)
+		if err != nil {
+			return stuck, err
+		}
+		key := occupancyKey{typ: typ, tenant: st.Tenant, state: st.State}
+		occupancy[key]++
+		if !ok {
+			continue
+		}
+
+		st.Index = i
+		stuck = append(stuck, st)
+		counts[stuckKey{occupancyKey: key, reason: st.Reason}]++
+
+		if d.Report != nil {
+			d.Report(ctx, st)
+		}
+	}
+
+	d.setMetrics(counts, occupancy)
+	return stuck, nil
+}
+
+// setMetrics reports the counts of a pass to the Metrics of the FSM.
+func (d *StuckDetector) setMetrics(counts map[stuckKey]int, occupancy map[occupancyKey]int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if m, ok := d.FSM.metrics.(StuckMetrics); ok {
+		d.stuck.set(counts, func(key stuckKey, n int) {
+			m.SetStuck(key.typ, key.tenant, key.state, key.reason, n)
+		})
+	}
+	if m, ok := d.FSM.metrics.(OccupancyMetrics); ok {
+		d.occupancy.set(occupancy, func(key occupancyKey, n int) {
+			m.SetOccupancy(key.typ, key.tenant, key.state, n)
+		})
+	}
+}
+
+// classify returns s with its state and tenant, the type of its machine and
+// whether it is stuck.
+func (d *StuckDetector) classify(ctx context.Context, s interface{}) (StuckInstance, string, bool, error) {
+	machine, ok := d.FSM.machine(s)
+	if !ok {
+		return StuckInstance{}, "", false, InternalError{}
+	}
+
+	_, state, err := machine.getSourceState(s)
+	if err != nil {
+		return StuckInstance{}, "", false, err
+	}
+
+	st := StuckInstance{Instance: s, State: state, Tenant: d.FSM.tenant(ctx, s)}
+
+	sla, hasSLA := d.SLA[state]
+	if hasSLA {
+		since, err := d.since(ctx, s)
+		if err != nil {
+			return StuckInstance{}, "", false, err
+		}
+		st.Since = since
+	}
+
+	if !machine.isFinal(state) {
+		events, err := machine.GetPermittedEvents(ctx, s)
+		if err != nil {
+			return StuckInstance{}, "", false, err
+		}
+		if len(events) == 0 {
+			st.Reason = StuckDeadEnd
+			return st, machine.name, true, nil
+		}
+	}
+
+	if hasSLA && !st.Since.IsZero() && d.FSM.now().Sub(st.Since) > sla {
+		pending, err := d.FSM.pending(ctx, machine, s)
+		if err != nil {
+			return StuckInstance{}, "", false, err
+		}
+		if !pending {
+			st.Reason = StuckSLABreached
+			return st, machine.name, true, nil
+		}
+	}
+
+	return st, machine.name, false, nil
+}
+
+func (d *StuckDetector) since(ctx context.Context, s interface{}) (time.Time, error) {
+	if d.Since != nil {
+		return d.Since(ctx, s)
+	}
+	return d.FSM.enteredAt(ctx, s, "StuckDetector.SLA")
+}
@@ -0,0 +1,197 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type stuckMetrics struct {
+	recordingMetrics
+	stuck map[StuckReason]int
+}
+
+func (m *stuckMetrics) SetStuck(typ, tenant string, state State, reason StuckReason, n int) {
+	m.stuck[reason] += n
+}
+
+func TestStuckDetector(t *testing.T) {
+	metrics := &stuckMetrics{stuck: make(map[StuckReason]int)}
+	fsm := NewFSM(WithMetrics(metrics))
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "approve",
+		From: []State{"review"},
+		To:   State("approved"),
+		Guards: []Guard{func(ctx context.Context, e *Event) (bool, error) {
+			return false, nil
+		}},
+	}, {
+		Name: "ship",
+		From: []State{"paid"},
+		To:   State("shipped"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := fsm.MarkFinal(tag, State("shipped")); err != nil {
+		t.Errorf("MarkFinal() error = %v", err)
+	}
+
+	instances := []interface{}{
+		&TestStruct{State: State("review")},
+		&TestStruct{State: State("paid")},
+		&TestStruct{State: State("shipped")},
+		&TestStruct{State: State("paid")},
+	}
+	entered := map[interface{}]time.Time{instances[3]: time.Now().Add(-2 * time.Hour)}
+
+	var reported int
+	d := &StuckDetector{
+		FSM: fsm,
+		List: func(ctx context.Context) ([]interface{}, error) {
+			return instances, nil
+		},
+		SLA: map[State]time.Duration{"paid": time.Hour},
+		Since: func(ctx context.Context, s interface{}) (time.Time, error) {
+			if at, ok := entered[s]; ok {
+				return at, nil
+			}
+			return time.Now(), nil
+		},
+		Report: func(ctx context.Context, stuck StuckInstance) {
+			reported++
+		},
+	}
+
+	stuck, err := d.Detect(context.Background())
+	if err != nil {
+		t.Errorf("Detect() error = %v", err)
+	}
+
+	if len(stuck) != 2 ||
+		stuck[0].Index != 0 || stuck[0].Reason != StuckDeadEnd ||
+		stuck[1].Index != 3 || stuck[1].Reason != StuckSLABreached {
+		t.Errorf("Detect() = %+v, want dead end at 0 and SLA breach at 3", stuck)
+	}
+	if reported != 2 {
+		t.Errorf("reported %d instances, want 2", reported)
+	}
+	if metrics.stuck[StuckDeadEnd] != 1 || metrics.stuck[StuckSLABreached] != 1 {
+		t.Errorf("stuck metrics = %v", metrics.stuck)
+	}
+}
+
+func TestStuckDetectorDefaultSince(t *testing.T) {
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*StampedStruct)(nil)), "State", Events{{
+		Name: "ship",
+		From: []State{"paid"},
+		To:   State("shipped"),
+	}}, WithStateChangedAt("StateChangedAt")); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "approve",
+		From: []State{"review"},
+		To:   State("approved"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	instances := []interface{}{
+		&StampedStruct{State: "paid", StateChangedAt: time.Now().Add(-2 * time.Hour)},
+		&StampedStruct{State: "paid", StateChangedAt: time.Now()},
+		&TestStruct{State: "review"},
+	}
+	d := &StuckDetector{
+		FSM: fsm,
+		List: func(ctx context.Context) ([]interface{}, error) {
+			return instances, nil
+		},
+		SLA: map[State]time.Duration{"paid": time.Hour},
+	}
+
+	stuck, err := d.Detect(context.Background())
+	if err != nil {
+		t.Errorf("Detect() error = %v", err)
+	}
+	if len(stuck) != 1 || stuck[0].Index != 0 || stuck[0].Reason != StuckSLABreached {
+		t.Errorf("Detect() = %+v, want SLA breach at 0", stuck)
+	}
+
+	d.SLA["review"] = time.Hour
+	if _, err := d.Detect(context.Background()); !errors.As(err, new(IdentityRequiredError)) {
+		t.Errorf("Detect() error = %v, want IdentityRequiredError", err)
+	}
+}
+
+type gaugeMetrics struct {
+	recordingMetrics
+	gauges    map[State]int
+	occupancy map[string]int // by tenant and state
+}
+
+func (m *gaugeMetrics) SetStuck(typ, tenant string, state State, reason StuckReason, n int) {
+	m.gauges[state] = n
+}
+
+func (m *gaugeMetrics) SetOccupancy(typ, tenant string, state State, n int) {
+	m.occupancy[tenant+"/"+string(state)] = n
+}
+
+func TestStuckDetectorRecovery(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	metrics := &gaugeMetrics{gauges: make(map[State]int), occupancy: make(map[string]int)}
+	fsm := NewFSM(WithMetrics(metrics), WithClock(clock), WithTenantFunc(func(ctx context.Context, s interface{}) string {
+		return "acme"
+	}))
+	if err := fsm.Register(reflect.TypeOf((*StampedStruct)(nil)), "State", Events{{
+		Name: "ship",
+		From: []State{"paid"},
+		To:   State("shipped"),
+	}, {
+		Name: "deliver",
+		From: []State{"shipped"},
+		To:   State("delivered"),
+	}}, WithStateChangedAt("StateChangedAt"), WithIdentity(byAddress)); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	s := &StampedStruct{State: "paid", StateChangedAt: clock.now.Add(-2 * time.Hour)}
+	d := &StuckDetector{
+		FSM: fsm,
+		List: func(ctx context.Context) ([]interface{}, error) {
+			return []interface{}{s}, nil
+		},
+		SLA: map[State]time.Duration{"paid": time.Hour},
+	}
+
+	ctx := context.Background()
+	if stuck, err := d.Detect(ctx); err != nil || len(stuck) != 1 || stuck[0].Tenant != "acme" || metrics.gauges["paid"] != 1 {
+		t.Errorf("Detect() = %+v, %v, gauges %v, want the SLA breach", stuck, err, metrics.gauges)
+	}
+
+	// A pending timer takes care of the instance.
+	id, err := fsm.FireAfter(ctx, s, "ship", time.Minute)
+	if err != nil {
+		t.Errorf("FireAfter() error = %v", err)
+	}
+	if stuck, err := d.Detect(ctx); err != nil || len(stuck) != 0 || metrics.gauges["paid"] != 0 {
+		t.Errorf("Detect() with a pending fire = %+v, %v, gauges %v, want none", stuck, err, metrics.gauges)
+	}
+
+	if err := fsm.CancelScheduled(ctx, id); err != nil {
+		t.Errorf("CancelScheduled() error = %v", err)
+	}
+	if err := fsm.Fire(ctx, s, "ship"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if stuck, err := d.Detect(ctx); err != nil || len(stuck) != 0 || metrics.gauges["paid"] != 0 {
+		t.Errorf("Detect() after recovery = %+v, %v, gauges %v, want none", stuck, err, metrics.gauges)
+	}
+	if metrics.occupancy["acme/paid"] != 0 || metrics.occupancy["acme/shipped"] != 1 {
+		t.Errorf("occupancy = %v, want one acme instance shipped", metrics.occupancy)
+	}
+}
@@ -0,0 +1,173 @@
+package fsm
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// table is the compiled form of the transitions of a machine. It is never
+// modified once built; AddTransition and RemoveTransition replace it.
+type table struct {
+	events        []EventTransition
+	transitions   map[eventKey]State
+	drafts        map[eventKey]State // transitions only declared as drafts
+	draftStates   map[State][]string
+	branches      map[eventKey][]*branch
+	schema        Schema
+	initialStates map[State][]string
+	guards        map[string][]namedGuard
+	weights       map[eventKey]float64
+	resources     map[eventKey][]func(context.Context, interface{}) []string
+	permitted     sync.Map // map[State]*PermittedSet
+}
+
+// table returns the current transition table of f.
+func (f *fsm) table() *table {
+	return f.tables.Load()
+}
+
+// compile builds the transition table of events for f.
+func (f *fsm) compile(events []EventTransition) (*table, error) {
+	t := &table{events: events}
+	t.transitions = make(map[eventKey]State)
+	t.drafts = make(map[eventKey]State)
+	t.draftStates = make(map[State][]string)
+	t.branches = make(map[eventKey][]*branch)
+	t.guards = make(map[string][]namedGuard)
+	t.weights = make(map[eventKey]float64)
+	t.resources = make(map[eventKey][]func(context.Context, interface{}) []string)
+	t.initialStates = make(map[State][]string)
+
+	t.schema = Schema{Column: f.column}
+	for state := range f.validStates {
+		t.schema.States = append(t.schema.States, state)
+	}
+	sort.Slice(t.schema.States, func(i, j int) bool { return t.schema.States[i] < t.schema.States[j] })
+
+	for _, e := range events {
+		if e.Name == "" {
+			return nil, SchemaError{Reason: "event without name"}
+		}
+		if len(e.From) == 0 || (e.To == "" && e.ToFunc == nil) {
+			return nil, SchemaError{Event: e.Name, Reason: "missing from or to state"}
+		}
+		if err := f.checkStates(e); err != nil {
+			return nil, err
+		}
+
+		// The schema lists what the transition declares, not the defaults.
+		declared := e
+		e := f.defaults.apply(e)
+		br := &branch{id: transitionID(e), to: e.To, priority: e.Priority, fallback: e.Default, before: e.Before, after: e.After, draft: e.Draft, labels: e.Labels, roles: e.AllowedRoles, onError: e.OnError}
+		if e.ToFunc != nil {
+			if len(e.Targets) == 0 {
+				return nil, SchemaError{Event: e.Name, Reason: "ToFunc without Targets"}
+			}
+			br.toFunc, br.targets = e.ToFunc, e.Targets
+			if br.to == "" {
+				br.to = e.Targets[0]
+			}
+		}
+
+		se := SchemaEvent{Name: e.Name, From: append([]State(nil), e.From...), To: e.To, OnError: e.OnError, Quota: e.Quota, Draft: e.Draft, Labels: e.Labels}
+		se.Before, se.After = f.callbackName(declared.Before), f.callbackName(declared.After)
+
+		if e.Guards != nil || e.GuardNames != nil {
+			guards, err := namedGuards(f.parent.registry, e)
+			if err != nil {
+				return nil, err
+			}
+			br.guards = guards
+			t.guards[e.Name] = append(t.guards[e.Name], guards...)
+		}
+		if declared.Guards != nil || declared.GuardNames != nil {
+			guards, err := namedGuards(f.parent.registry, declared)
+			if err != nil {
+				return nil, err
+			}
+			for _, g := range guards {
+				se.Guards = append(se.Guards, g.name)
+			}
+		}
+		if e.ToFunc == nil {
+			t.schema.Events = append(t.schema.Events, se)
+		}
+		for _, target := range br.targets {
+			se.To = target
+			t.schema.Events = append(t.schema.Events, se)
+		}
+
+		if e.Retry.MaxAttempts > 1 {
+			br.retry = e.Retry
+		}
+		br.timeout = e.CallbackTimeout
+		br.unavailable = e.Fallback
+
+		if e.Quota > 0 {
+			if err := f.requireIdentity("Quota"); err != nil {
+				return nil, err
+			}
+			br.quota = e.Quota
+		}
+
+		if e.Throttle.enabled() {
+			if err := f.requireIdentity("Throttle"); err != nil {
+				return nil, err
+			}
+			br.throttle = e.Throttle
+		}
+
+		for _, src := range e.From {
+			key := eventKey{event: e.Name, src: src}
+			t.branches[key] = append(t.branches[key], br)
+			if e.Locks != nil {
+				t.resources[key] = append(t.resources[key], e.Locks)
+			}
+			if e.Weight > 0 {
+				t.weights[key] = e.Weight
+			}
+		}
+	}
+
+	for key, branches := range t.branches {
+		sort.SliceStable(branches, func(i, j int) bool {
+			if branches[i].fallback != branches[j].fallback {
+				return branches[j].fallback
+			}
+			return branches[i].priority > branches[j].priority
+		})
+		if err := f.checkBranches(key, branches); err != nil {
+			return nil, err
+		}
+
+		t.drafts[key] = branches[0].to
+		for _, br := range branches {
+			if !br.draft {
+				t.transitions[key] = br.to
+				delete(t.drafts, key)
+				break
+			}
+		}
+	}
+
+	// List the events leaving each state in declaration order, so that
+	// GetPermittedEvents is deterministic.
+	listed := make(map[eventKey]bool)
+	for _, e := range events {
+		for _, src := range e.From {
+			key := eventKey{event: e.Name, src: src}
+			if listed[key] {
+				continue
+			}
+			listed[key] = true
+			if _, ok := t.transitions[key]; ok {
+				t.initialStates[src] = append(t.initialStates[src], e.Name)
+			} else if _, ok := t.drafts[key]; ok {
+				t.draftStates[src] = append(t.draftStates[src], e.Name)
+			}
+		}
+	}
+
+	return t, nil
+}
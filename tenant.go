@@ -0,0 +1,35 @@
+package fsm
+
+import "context"
+
+type tenantKey struct{}
+
+// WithTenant returns a context carrying the tenant firing events. The tenant
+// is reported in metrics, logs and history unless WithTenantFunc overrides
+// how it is determined.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant stored by WithTenant.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok
+}
+
+// WithTenantFunc determines the tenant of a Fire call with fn, e.g. from a
+// field of the instance, instead of from the context.
+func WithTenantFunc(fn func(ctx context.Context, s interface{}) string) FSMOption {
+	return func(f *FSM) {
+		f.tenantFunc = fn
+	}
+}
+
+// tenant returns the tenant s is fired for, empty if unknown.
+func (f *FSM) tenant(ctx context.Context, s interface{}) string {
+	if f.tenantFunc != nil {
+		return f.tenantFunc(ctx, s)
+	}
+	tenant, _ := TenantFromContext(ctx)
+	return tenant
+}
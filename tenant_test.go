@@ -0,0 +1,63 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type TenantStruct struct {
+	State  State
+	Tenant string
+}
+
+func TestTenantFromContext(t *testing.T) {
+	metrics := &recordingMetrics{}
+	fsm := NewFSM(WithMetrics(metrics), WithHistory(10), WithIDFunc(byAddress))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("started")}
+	if err := fsm.Fire(WithTenant(context.Background(), "acme"), testStruct, "make"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	if len(metrics.transitions) != 1 || metrics.transitions[0].Tenant != "acme" {
+		t.Errorf("transitions = %v, want tenant acme", metrics.transitions)
+	}
+
+	history, err := fsm.History(context.Background(), testStruct)
+	if err != nil {
+		t.Errorf("History() error = %v", err)
+	}
+	if len(history) != 1 || history[0].Tenant != "acme" {
+		t.Errorf("History() = %v, want tenant acme", history)
+	}
+}
+
+func TestTenantFunc(t *testing.T) {
+	metrics := &recordingMetrics{}
+	fsm := NewFSM(WithMetrics(metrics), WithTenantFunc(func(ctx context.Context, s interface{}) string {
+		return s.(*TenantStruct).Tenant
+	}))
+	if err := fsm.Register(reflect.TypeOf((*TenantStruct)(nil)), "State", Events{{
+		Name: "make",
+		From: []State{"started"},
+		To:   State("finished"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	if err := fsm.Fire(context.Background(), &TenantStruct{State: State("started"), Tenant: "globex"}, "make"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	if len(metrics.transitions) != 1 || metrics.transitions[0].Tenant != "globex" {
+		t.Errorf("transitions = %v, want tenant globex", metrics.transitions)
+	}
+}
@@ -0,0 +1,113 @@
+package fsm
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Throttle limits how often an event may be fired on one instance, e.g. to
+// absorb flapping sensors. Only successful transitions count.
+type Throttle struct {
+	// MinInterval is the least time between two transitions.
+	MinInterval time.Duration
+	// MaxPerMinute limits the transitions in any sliding minute. Zero means
+	// unlimited.
+	MaxPerMinute int
+}
+
+func (t Throttle) enabled() bool {
+	return t.MinInterval > 0 || t.MaxPerMinute > 0
+}
+
+// window is how long past transitions are remembered.
+func (t Throttle) window() time.Duration {
+	return max(t.MinInterval, time.Minute)
+}
+
+func (f *fsm) throttleKey(s interface{}, br *branch) string {
+	return "throttle:" + f.instanceKey(s) + ":" + br.id
+}
+
+// throttled returns when event was recently fired on s, oldest first, as
+// far as it matters to throttle at now.
+func (f *fsm) throttled(ctx context.Context, key string, throttle Throttle, now time.Time) ([]time.Time, error) {
+	value, err := f.parent.store.Load(ctx, key)
+	if err != nil || len(value) == 0 {
+		return nil, err
+	}
+
+	var fired []time.Time
+	for _, field := range strings.Split(string(value), ",") {
+		nanos, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if at := time.Unix(0, nanos); now.Sub(at) < throttle.window() {
+			fired = append(fired, at)
+		}
+	}
+	return fired, nil
+}
+
+// checkThrottle returns ThrottledError if the transition br of event was
+// taken on s too recently or too often.
+func (f *fsm) checkThrottle(ctx context.Context, s interface{}, event string, br *branch) error {
+	throttle := br.throttle
+	if !throttle.enabled() {
+		return nil
+	}
+
+	now := f.parent.now()
+	fired, err := f.throttled(ctx, f.throttleKey(s, br), throttle, now)
+	if err != nil || len(fired) == 0 {
+		return err
+	}
+
+	var retryAfter time.Duration
+	if last := fired[len(fired)-1]; now.Sub(last) < throttle.MinInterval {
+		retryAfter = last.Add(throttle.MinInterval).Sub(now)
+	}
+	if n := len(fired); throttle.MaxPerMinute > 0 && n >= throttle.MaxPerMinute {
+		var inMinute []time.Time
+		for _, at := range fired {
+			if now.Sub(at) < time.Minute {
+				inMinute = append(inMinute, at)
+			}
+		}
+		if len(inMinute) >= throttle.MaxPerMinute {
+			oldest := inMinute[len(inMinute)-throttle.MaxPerMinute]
+			retryAfter = max(retryAfter, oldest.Add(time.Minute).Sub(now))
+		}
+	}
+
+	if retryAfter > 0 {
+		return ThrottledError{Event: event, RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// recordThrottle remembers that the transition br was taken on s.
+func (f *fsm) recordThrottle(ctx context.Context, s interface{}, br *branch) error {
+	throttle := br.throttle
+	if !throttle.enabled() {
+		return nil
+	}
+
+	now := f.parent.now()
+	skey := f.throttleKey(s, br)
+	fired, err := f.throttled(ctx, skey, throttle, now)
+	if err != nil {
+		return err
+	}
+	if keep := max(throttle.MaxPerMinute-1, 0); len(fired) > keep {
+		fired = fired[len(fired)-keep:]
+	}
+
+	fields := make([]string, 0, len(fired)+1)
+	for _, at := range append(fired, now) {
+		fields = append(fields, strconv.FormatInt(at.UnixNano(), 10))
+	}
+	return f.parent.store.Save(ctx, skey, []byte(strings.Join(fields, ",")), throttle.window())
+}
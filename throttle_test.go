@@ -0,0 +1,129 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	fsm := NewFSM(WithClock(clock), WithIDFunc(byAddress))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:     "trip",
+		From:     []State{"ok", "alarm"},
+		To:       State("alarm"),
+		Throttle: Throttle{MinInterval: 10 * time.Second, MaxPerMinute: 3},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("ok")}
+	fire := func() error { return fsm.Fire(ctx, testStruct, "trip") }
+
+	if err := fire(); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+
+	clock.now = clock.now.Add(4 * time.Second)
+	var throttled ThrottledError
+	if err := fire(); !errors.As(err, &throttled) || throttled.RetryAfter != 6*time.Second {
+		t.Errorf("Fire() within MinInterval error = %v, want ThrottledError retrying after 6s", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		clock.now = clock.now.Add(10 * time.Second)
+		if err := fire(); err != nil {
+			t.Errorf("Fire() error = %v", err)
+		}
+	}
+
+	// Three transitions at 0s, 14s and 24s; the fourth has to wait until the
+	// first leaves the minute.
+	clock.now = clock.now.Add(10 * time.Second)
+	if err := fire(); !errors.As(err, &throttled) || throttled.RetryAfter != 26*time.Second {
+		t.Errorf("Fire() beyond MaxPerMinute error = %v, want ThrottledError retrying after 26s", err)
+	}
+	if kind := errorKind(fire()); kind != KindThrottled {
+		t.Errorf("errorKind() = %v, want %v", kind, KindThrottled)
+	}
+
+	clock.now = clock.now.Add(26 * time.Second)
+	if err := fire(); err != nil {
+		t.Errorf("Fire() after RetryAfter error = %v", err)
+	}
+}
+
+func TestThrottlePerTransition(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	fsm := NewFSM(WithClock(clock), WithIDFunc(byAddress))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:     "toggle",
+		From:     []State{"off"},
+		To:       State("on"),
+		Throttle: Throttle{MinInterval: time.Minute},
+	}, {
+		Name:     "toggle",
+		From:     []State{"on"},
+		To:       State("off"),
+		Throttle: Throttle{MinInterval: time.Second},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("off")}
+	for i := 0; i < 2; i++ {
+		if err := fsm.Fire(ctx, testStruct, "toggle"); err != nil {
+			t.Errorf("Fire() error = %v", err)
+		}
+		clock.now = clock.now.Add(2 * time.Second)
+	}
+
+	var throttled ThrottledError
+	if err := fsm.Fire(ctx, testStruct, "toggle"); !errors.As(err, &throttled) || throttled.RetryAfter != 56*time.Second {
+		t.Errorf("Fire() from off error = %v, want ThrottledError retrying after 56s", err)
+	}
+}
+
+func TestThrottlePerBranch(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	fsm := NewFSM(WithClock(clock), WithIDFunc(byAddress))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:     "ping",
+		From:     []State{"up"},
+		To:       State("up"),
+		Priority: 1,
+		Guards: []Guard{func(ctx context.Context, e *Event) (bool, error) {
+			return e.Meta["slow"] == "yes", nil
+		}},
+		Throttle: Throttle{MinInterval: time.Minute},
+	}, {
+		Name:     "ping",
+		From:     []State{"up"},
+		To:       State("up"),
+		Throttle: Throttle{MinInterval: time.Second},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("up")}
+	slow := WithMeta(map[string]interface{}{"slow": "yes"})
+
+	if err := fsm.Fire(ctx, testStruct, "ping", slow); err != nil {
+		t.Errorf("Fire() of the slow branch error = %v", err)
+	}
+	clock.now = clock.now.Add(2 * time.Second)
+	if err := fsm.Fire(ctx, testStruct, "ping"); err != nil {
+		t.Errorf("Fire() of the fast branch error = %v", err)
+	}
+
+	var throttled ThrottledError
+	if err := fsm.Fire(ctx, testStruct, "ping", slow); !errors.As(err, &throttled) || throttled.RetryAfter != 58*time.Second {
+		t.Errorf("Fire() of the slow branch error = %v, want ThrottledError retrying after 58s", err)
+	}
+}
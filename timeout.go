@@ -0,0 +1,39 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// WithCallbackTimeout bounds every guard and callback of the machine to d,
+// unless the transition sets EventTransition.CallbackTimeout. Hooks get a
+// context with the deadline and must honor it: a hook still running when the
+// deadline passes is waited for, and its result replaced by
+// CallbackTimeoutError.
+func WithCallbackTimeout(d time.Duration) RegisterOption {
+	return func(f *fsm) {
+		f.callbackTimeout = d
+	}
+}
+
+// bounded runs the hook of the transition br of event with the timeout
+// configured for it, that of the machine if br is nil or sets none.
+func (f *fsm) bounded(ctx context.Context, br *branch, event, hook string, fn func(ctx context.Context) error) error {
+	d := f.callbackTimeout
+	if br != nil && br.timeout > 0 {
+		d = br.timeout
+	}
+	if d <= 0 {
+		return fn(ctx)
+	}
+
+	hctx, cancel := f.parent.withTimeout(ctx, d)
+	defer cancel()
+
+	err := fn(hctx)
+	if errors.Is(context.Cause(hctx), context.DeadlineExceeded) && ctx.Err() == nil {
+		return CallbackTimeoutError{Event: event, Hook: hook, Timeout: d}
+	}
+	return err
+}
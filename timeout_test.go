@@ -0,0 +1,80 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCallbackTimeout(t *testing.T) {
+	slow := func(ctx context.Context, e *Event) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:   "finish",
+		From:   []State{"started"},
+		To:     State("finished"),
+		Before: slow,
+	}, {
+		Name:            "approve",
+		From:            []State{"started"},
+		To:              State("approved"),
+		CallbackTimeout: 10 * time.Millisecond,
+		Guards: []Guard{func(ctx context.Context, e *Event) (bool, error) {
+			return true, slow(ctx, e)
+		}},
+	}}, WithCallbackTimeout(20*time.Millisecond)); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("started")}
+
+	var terr CallbackTimeoutError
+	if err := fsm.Fire(ctx, testStruct, "finish"); !errors.As(err, &terr) || terr.Hook != "before" || terr.Timeout != 20*time.Millisecond {
+		t.Errorf("Fire(finish) error = %v, want CallbackTimeoutError of before", err)
+	}
+
+	err := fsm.Fire(ctx, testStruct, "approve")
+	if !errors.As(err, &terr) || terr.Timeout != 10*time.Millisecond || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Fire(approve) error = %v, want CallbackTimeoutError of the guard", err)
+	}
+	if testStruct.State != "started" {
+		t.Errorf("State = %v, want started", testStruct.State)
+	}
+}
+
+func TestCallbackTimeoutPerTransition(t *testing.T) {
+	slow := func(ctx context.Context, e *Event) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:            "finish",
+		From:            []State{"started"},
+		To:              State("finished"),
+		CallbackTimeout: 10 * time.Millisecond,
+		Before:          slow,
+	}, {
+		Name:   "finish",
+		From:   []State{"paused"},
+		To:     State("finished"),
+		Before: slow,
+	}}, WithCallbackTimeout(20*time.Millisecond)); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	for state, want := range map[State]time.Duration{"started": 10 * time.Millisecond, "paused": 20 * time.Millisecond} {
+		var terr CallbackTimeoutError
+		if err := fsm.Fire(context.Background(), &TestStruct{State: state}, "finish"); !errors.As(err, &terr) || terr.Timeout != want {
+			t.Errorf("Fire() from %s error = %v, want CallbackTimeoutError after %v", state, err, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package fsm
+
+import (
+	"reflect"
+	"time"
+)
+
+// WithStateChangedAt sets the time.Time or *time.Time field named field to
+// the time of every successful transition that changes the state. Register
+// fails with SchemaError if the type has no such field. Fire fails with
+// InternalError before running callbacks if the field is promoted through a
+// nil embedded pointer.
+func WithStateChangedAt(field string) RegisterOption {
+	return func(f *fsm) {
+		f.stateChangedAt = field
+	}
+}
+
+// WithUpdatedAt sets the time.Time or *time.Time field named field to the
+// time of every successful transition, including self-transitions. It is
+// checked like WithStateChangedAt.
+func WithUpdatedAt(field string) RegisterOption {
+	return func(f *fsm) {
+		f.updatedAt = field
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// stampField is the resolved location of a timestamp field.
+type stampField struct {
+	index []int
+	ptr   bool
+}
+
+// stampFields are the timestamp fields of one concrete type, nil where the
+// machine sets none.
+type stampFields struct {
+	stateChangedAt *stampField
+	updatedAt      *stampField
+}
+
+// stampsFor resolves the timestamp fields of instances of type t once per
+// type. It returns SchemaError if a configured field is missing or is not
+// a time.Time or *time.Time. Register resolves them for concrete types.
+func (f *fsm) stampsFor(t reflect.Type) (stampFields, error) {
+	if f.stateChangedAt == "" && f.updatedAt == "" {
+		return stampFields{}, nil
+	}
+
+	if stamps, ok := f.stamps.Load(t); ok {
+		return stamps.(stampFields), nil
+	}
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return stampFields{}, SchemaError{Reason: "timestamps need a pointer to a struct, not " + t.String()}
+	}
+
+	var stamps stampFields
+	for _, c := range []struct {
+		name string
+		dst  **stampField
+	}{{f.stateChangedAt, &stamps.stateChangedAt}, {f.updatedAt, &stamps.updatedAt}} {
+		if c.name == "" {
+			continue
+		}
+
+		sf, ok := t.Elem().FieldByName(c.name)
+		if !ok || !sf.IsExported() {
+			return stampFields{}, SchemaError{Reason: "timestamp field " + c.name + " not found in " + t.String()}
+		}
+
+		switch sf.Type {
+		case timeType:
+			*c.dst = &stampField{index: sf.Index}
+		case reflect.PointerTo(timeType):
+			*c.dst = &stampField{index: sf.Index, ptr: true}
+		default:
+			return stampFields{}, SchemaError{Reason: "timestamp field " + c.name + " of " + t.String() + " is not a time.Time or *time.Time"}
+		}
+	}
+
+	f.stamps.Store(t, stamps)
+	return stamps, nil
+}
+
+// check returns InternalError if a timestamp field of the struct v can't be
+// reached because it is promoted through a nil embedded pointer.
+func (s stampFields) check(v reflect.Value) error {
+	for _, sf := range []*stampField{s.stateChangedAt, s.updatedAt} {
+		if sf == nil {
+			continue
+		}
+		if _, err := v.FieldByIndexErr(sf.index); err != nil {
+			return InternalError{}
+		}
+	}
+	return nil
+}
+
+// set stores now in the timestamp fields of the struct v, which must pass
+// check.
+func (s stampFields) set(v reflect.Value, now time.Time, changed bool) {
+	if changed && s.stateChangedAt != nil {
+		s.stateChangedAt.set(v, now)
+	}
+	if s.updatedAt != nil {
+		s.updatedAt.set(v, now)
+	}
+}
+
+func (s *stampField) set(v reflect.Value, now time.Time) {
+	field := v.FieldByIndex(s.index)
+	if s.ptr {
+		field.Set(reflect.ValueOf(&now))
+		return
+	}
+	field.Set(reflect.ValueOf(now))
+}
+
+// stateChangedAtOf returns the WithStateChangedAt field of s, zero if it is
+// a nil *time.Time.
+func (f *fsm) stateChangedAtOf(s interface{}) (time.Time, error) {
+	stamps, err := f.stampsFor(reflect.TypeOf(s))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	v := reflect.ValueOf(s)
+	if v.IsNil() {
+		return time.Time{}, InternalError{}
+	}
+	field, err := v.Elem().FieldByIndexErr(stamps.stateChangedAt.index)
+	if err != nil {
+		return time.Time{}, InternalError{}
+	}
+
+	if stamps.stateChangedAt.ptr {
+		if field.IsNil() {
+			return time.Time{}, nil
+		}
+		field = field.Elem()
+	}
+	return field.Interface().(time.Time), nil
+}
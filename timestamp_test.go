@@ -0,0 +1,98 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+type StampedStruct struct {
+	State          string
+	StateChangedAt time.Time
+	UpdatedAt      *time.Time
+}
+
+func TestTimestampFields(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	fsm := NewFSM(WithClock(fixedClock(now)))
+	err := fsm.Register(reflect.TypeOf((*StampedStruct)(nil)), "State", Events{{
+		Name: "pay",
+		From: []State{"created"},
+		To:   State("paid"),
+	}, {
+		Name: "touch",
+		From: []State{"paid"},
+		To:   State("paid"),
+	}}, WithStateChangedAt("StateChangedAt"), WithUpdatedAt("UpdatedAt"))
+	if err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	s := &StampedStruct{State: "created"}
+	if err := fsm.Fire(ctx, s, "pay"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if !s.StateChangedAt.Equal(now) || s.UpdatedAt == nil || !s.UpdatedAt.Equal(now) {
+		t.Errorf("timestamps = %v, %v, want %v", s.StateChangedAt, s.UpdatedAt, now)
+	}
+
+	s.StateChangedAt = time.Time{}
+	if err := fsm.Fire(ctx, s, "touch"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if !s.StateChangedAt.IsZero() {
+		t.Errorf("StateChangedAt = %v, want unchanged by a self-transition", s.StateChangedAt)
+	}
+}
+
+type StampBase struct {
+	UpdatedAt time.Time
+}
+
+type EmbeddedStampStruct struct {
+	*StampBase
+	State string
+}
+
+func TestTimestampFieldInvalid(t *testing.T) {
+	events := Events{{
+		Name: "pay",
+		From: []State{"created"},
+		To:   State("paid"),
+	}}
+	tag := reflect.TypeOf((*StampedStruct)(nil))
+	for _, field := range []string{"State", "UpdatedAtt"} {
+		if err := NewFSM().Register(tag, "State", events, WithUpdatedAt(field)); !errors.As(err, new(SchemaError)) {
+			t.Errorf("fsm.Register(WithUpdatedAt(%q)) error = %v, want SchemaError", field, err)
+		}
+	}
+
+	before := 0
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*EmbeddedStampStruct)(nil)), "State", Events{{
+		Name: "pay",
+		From: []State{"created"},
+		To:   State("paid"),
+		Before: func(ctx context.Context, e *Event) error {
+			before++
+			return nil
+		},
+	}}, WithUpdatedAt("UpdatedAt")); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	s := &EmbeddedStampStruct{State: "created"}
+	if err := fsm.Fire(context.Background(), s, "pay"); !errors.As(err, new(InternalError)) {
+		t.Errorf("Fire() through a nil embedded pointer error = %v, want InternalError", err)
+	}
+	if s.State != "created" || before != 0 {
+		t.Errorf("State = %v after %d Before calls, want created untouched", s.State, before)
+	}
+}
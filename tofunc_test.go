@@ -0,0 +1,90 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestToFunc(t *testing.T) {
+	fsm := NewFSM()
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "submit",
+		From: []State{"draft"},
+		ToFunc: func(ctx context.Context, e *Event) (State, error) {
+			switch e.Reason {
+			case "low risk":
+				return "auto_approved", nil
+			case "high risk":
+				return "manual_review", nil
+			}
+			return "approved", nil
+		},
+		Targets: []State{"auto_approved", "manual_review"},
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	for reason, want := range map[string]State{"low risk": "auto_approved", "high risk": "manual_review"} {
+		testStruct := &TestStruct{State: State("draft")}
+		if err := fsm.Fire(ctx, testStruct, "submit", WithReason(reason)); err != nil || testStruct.State != want {
+			t.Errorf("Fire() with %s = %v, %v, want %v", reason, testStruct.State, err, want)
+		}
+	}
+
+	testStruct := &TestStruct{State: State("draft")}
+	if err := fsm.Fire(ctx, testStruct, "submit"); !errors.As(err, new(UndeclaredTargetError)) || testStruct.State != "draft" {
+		t.Errorf("Fire() = %v, %v, want UndeclaredTargetError", testStruct.State, err)
+	}
+
+	schema, err := fsm.Schema(tag)
+	if err != nil || len(schema.Events) != 2 || schema.Events[1].To != "manual_review" {
+		t.Errorf("Schema() = %+v, %v, want an event per target", schema, err)
+	}
+
+	err = fsm.Register(tag, "State", Events{{
+		Name:   "submit",
+		From:   []State{"draft"},
+		ToFunc: func(ctx context.Context, e *Event) (State, error) { return "approved", nil },
+	}})
+	if !errors.As(err, new(SchemaError)) {
+		t.Errorf("fsm.Register() error = %v, want SchemaError without Targets", err)
+	}
+}
+
+func TestAddTransitionToFunc(t *testing.T) {
+	fsm := NewFSM()
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name: "create",
+		From: []State{"new"},
+		To:   State("draft"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	submit := EventTransition{
+		Name: "submit",
+		From: []State{"draft"},
+		ToFunc: func(ctx context.Context, e *Event) (State, error) {
+			return "manual_review", nil
+		},
+		Targets: []State{"auto_approved", "manual_review"},
+	}
+	if err := fsm.AddTransition(tag, submit); err != nil {
+		t.Errorf("AddTransition() error = %v", err)
+	}
+
+	testStruct := &TestStruct{State: State("draft")}
+	if err := fsm.Fire(context.Background(), testStruct, "submit"); err != nil || testStruct.State != "manual_review" {
+		t.Errorf("Fire() = %v, %v, want manual_review", testStruct.State, err)
+	}
+
+	diff := DiffEvents(nil, Events{submit})
+	if len(diff.Added) != 2 || diff.Added[0].To != "auto_approved" || diff.Added[1].To != "manual_review" {
+		t.Errorf("DiffEvents() added %+v, want an edge per target", diff.Added)
+	}
+}
@@ -0,0 +1,85 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+)
+
+// TransitionInfo describes a transition declared from the current state of
+// an instance, as returned by GetPermittedTransitions.
+type TransitionInfo struct {
+	Event string
+	From  State
+	// To is the destination Fire would take, that of the first declared
+	// branch if no guard passes.
+	To State
+	// Permitted reports whether Fire would currently take the transition.
+	Permitted bool
+	// Guard is the name of a guard rejecting the transition, empty if it is
+	// permitted or rejected by authorization.
+	Guard string
+	// Draft is set for transitions only declared as drafts, see
+	// IncludeDraft.
+	Draft bool
+	// Labels are the EventTransition.Labels of the transition to To.
+	Labels map[string]string
+}
+
+func (f *fsm) GetPermittedTransitions(ctx context.Context, s interface{}, options ...Option) ([]TransitionInfo, error) {
+	t := f.table()
+	args := &Options{}
+	for _, option := range options {
+		option(args)
+	}
+
+	_, state, err := f.getSourceState(s)
+	if err != nil {
+		return nil, err
+	}
+
+	events := f.eventsFrom(state, args)
+	if len(events) == 0 || f.isFinal(state) {
+		return []TransitionInfo{}, nil
+	}
+
+	vars, err := f.loadVars(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]TransitionInfo, 0, len(events))
+	for _, event := range events {
+		key := eventKey{event, state}
+		to, _ := f.destination(key, args)
+		_, declared := t.transitions[key]
+		info := TransitionInfo{Event: event, From: state, To: to, Draft: !declared, Labels: f.labels(key, to)}
+
+		e := &Event{Event: event, Source: s, From: state, Destination: to, Vars: vars, Args: args.Args, DryRun: true}
+		br, guard, err := f.selectBranch(ctx, e, state, args, true)
+		if err != nil && errors.As(err, new(PermissionDeniedError)) {
+			infos = append(infos, info)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if br != nil {
+			info.Permitted, info.To, info.Labels = true, br.to, br.labels
+		} else {
+			info.Guard = guard
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// labels returns the labels of the first branch of key leading to to.
+func (f *fsm) labels(key eventKey, to State) map[string]string {
+	for _, br := range f.table().branches[key] {
+		if br.to == to {
+			return br.labels
+		}
+	}
+	return nil
+}
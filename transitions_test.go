@@ -0,0 +1,49 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestGetPermittedTransitions(t *testing.T) {
+	isManager := func(ctx context.Context, e *Event) (bool, error) {
+		return false, nil
+	}
+
+	fsm := NewFSM()
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name:   "finish",
+		From:   []State{"started"},
+		To:     State("finished"),
+		Labels: map[string]string{"title": "Finish"},
+	}, {
+		Name:   "escalate",
+		From:   []State{"started"},
+		To:     State("escalated"),
+		Guards: []Guard{isManager},
+	}, {
+		Name:  "archive",
+		From:  []State{"started"},
+		To:    State("archived"),
+		Draft: true,
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	infos, err := fsm.GetPermittedTransitions(context.Background(), &TestStruct{State: State("started")}, IncludeDraft())
+	if err != nil {
+		t.Errorf("GetPermittedTransitions() error = %v", err)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Event < infos[j].Event })
+
+	want := []TransitionInfo{
+		{Event: "archive", From: "started", To: "archived", Permitted: true, Draft: true},
+		{Event: "escalate", From: "started", To: "escalated", Guard: GuardName(isManager)},
+		{Event: "finish", From: "started", To: "finished", Permitted: true, Labels: map[string]string{"title": "Finish"}},
+	}
+	if !reflect.DeepEqual(infos, want) {
+		t.Errorf("GetPermittedTransitions() = %+v, want %+v", infos, want)
+	}
+}
@@ -0,0 +1,107 @@
+package fsm
+
+import "context"
+
+// uniqueConstraint allows a single instance per group in state.
+type uniqueConstraint struct {
+	state State
+	group func(s interface{}) string
+}
+
+// Unique allows at most one instance per group to be in state, e.g. one
+// active subscription per customer, with group returning the customer ID.
+// Fire fails with StateConflictError if another instance of the group holds
+// the state. The holder is kept in the StateStore under a lock of the
+// LockProvider, so the constraint holds across processes sharing them;
+// instances are told apart by WithIdentity or WithIDFunc, Register fails
+// with IdentityRequiredError without either.
+func Unique(state State, group func(s interface{}) string) RegisterOption {
+	return func(f *fsm) {
+		f.uniques = append(f.uniques, uniqueConstraint{state: state, group: group})
+	}
+}
+
+func (f *fsm) uniqueKey(state State, group string) string {
+	return "unique:" + f.name + ":" + f.column + ":" + string(state) + ":" + group
+}
+
+// instanceID identifies s across processes if WithIdentity or WithIDFunc
+// is set.
+func (f *fsm) instanceID(s interface{}) string {
+	if id, ok := f.identityOf(s); ok {
+		return id
+	}
+	return f.instanceKey(s)
+}
+
+// noClaim is the claim of transitions without constrained groups.
+var noClaim = &uniqueClaim{}
+
+// uniqueClaim is the outcome of claimUnique. commit records the transition
+// once the state is written, release unlocks the groups.
+type uniqueClaim struct {
+	f       *fsm
+	s       interface{}
+	claim   []string
+	leave   []string
+	unlocks []func()
+}
+
+// claimUnique locks the groups of s constrained in destination and fails
+// with StateConflictError if another instance holds one of them.
+func (f *fsm) claimUnique(ctx context.Context, s interface{}, source, destination State) (*uniqueClaim, error) {
+	if source == destination || len(f.uniques) == 0 {
+		return noClaim, nil
+	}
+	c := &uniqueClaim{f: f, s: s}
+
+	id := f.instanceID(s)
+	for _, u := range f.uniques {
+		switch u.state {
+		case source:
+			c.leave = append(c.leave, f.uniqueKey(source, u.group(s)))
+		case destination:
+			group := u.group(s)
+			key := f.uniqueKey(destination, group)
+
+			unlock, err := f.parent.locks.Lock(ctx, key)
+			if err != nil {
+				c.release()
+				return nil, err
+			}
+			c.unlocks = append(c.unlocks, unlock)
+
+			holder, err := f.parent.store.Load(ctx, key)
+			if err != nil {
+				c.release()
+				return nil, err
+			}
+			if holder != nil && string(holder) != id {
+				c.release()
+				return nil, StateConflictError{State: string(destination), Group: group}
+			}
+			c.claim = append(c.claim, key)
+		}
+	}
+	return c, nil
+}
+
+func (c *uniqueClaim) commit(ctx context.Context) error {
+	for _, key := range c.claim {
+		if err := c.f.parent.store.Save(ctx, key, []byte(c.f.instanceID(c.s)), 0); err != nil {
+			return err
+		}
+	}
+	for _, key := range c.leave {
+		if err := c.f.parent.store.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *uniqueClaim) release() {
+	for i := len(c.unlocks) - 1; i >= 0; i-- {
+		c.unlocks[i]()
+	}
+}
@@ -0,0 +1,111 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type Membership struct {
+	Customer string
+	State    State
+}
+
+func TestUniqueState(t *testing.T) {
+	fsm := NewFSM(WithIDFunc(byAddress))
+	if err := fsm.Register(reflect.TypeOf((*Membership)(nil)), "State", Events{{
+		Name: "activate",
+		From: []State{"pending"},
+		To:   State("active"),
+	}, {
+		Name: "cancel",
+		From: []State{"active"},
+		To:   State("canceled"),
+	}}, Unique("active", func(s interface{}) string {
+		return s.(*Membership).Customer
+	})); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	first := &Membership{Customer: "c1", State: "pending"}
+	second := &Membership{Customer: "c1", State: "pending"}
+	other := &Membership{Customer: "c2", State: "pending"}
+
+	if err := fsm.Fire(ctx, first, "activate"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if err := fsm.Fire(ctx, other, "activate"); err != nil {
+		t.Errorf("Fire() error = %v for another customer", err)
+	}
+
+	err := fsm.Fire(ctx, second, "activate")
+	if !errors.As(err, new(StateConflictError)) {
+		t.Errorf("Fire() error = %v, want StateConflictError", err)
+	}
+	if second.State != "pending" {
+		t.Errorf("State = %v, want pending", second.State)
+	}
+
+	if err := fsm.Fire(ctx, first, "cancel"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if err := fsm.Fire(ctx, second, "activate"); err != nil {
+		t.Errorf("Fire() error = %v after the holder left", err)
+	}
+}
+
+func TestUniqueStateInvariant(t *testing.T) {
+	type Subscription struct {
+		ID       string
+		Customer string
+		State    State
+	}
+
+	fsm := NewFSM()
+	tag := reflect.TypeOf((*Subscription)(nil))
+	if err := fsm.Register(tag, "State", Events{{
+		Name:  "activate",
+		From:  []State{"new"},
+		To:    State("active"),
+		Quota: 1,
+	}}, Unique("active", func(s interface{}) string {
+		return s.(*Subscription).Customer
+	}), Unique("new", func(s interface{}) string {
+		return s.(*Subscription).Customer
+	}), WithIdentity(func(s interface{}) string {
+		return s.(*Subscription).ID
+	})); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	errBroken := errors.New("broken")
+	if err := fsm.Invariant(tag, func(ctx context.Context, s interface{}) error {
+		if s.(*Subscription).ID == "a" {
+			return errBroken
+		}
+		return nil
+	}); err != nil {
+		t.Errorf("fsm.Invariant() error = %v", err)
+	}
+
+	ctx := context.Background()
+	a := &Subscription{ID: "a", Customer: "c1", State: "new"}
+	if err := fsm.Fire(ctx, a, "activate"); !errors.Is(err, errBroken) || a.State != "new" {
+		t.Errorf("Fire() = %v in %s, want the invariant violation in new", err, a.State)
+	}
+
+	machine, _ := fsm.machine(a)
+	if holder, _ := fsm.store.Load(ctx, machine.uniqueKey("active", "c1")); holder != nil {
+		t.Errorf("active group held by %s after the rollback", holder)
+	}
+
+	b := &Subscription{ID: "b", Customer: "c1", State: "new"}
+	if err := fsm.Fire(ctx, b, "activate"); err != nil {
+		t.Errorf("Fire() error = %v", err)
+	}
+	if used, _ := machine.quotaUsed(ctx, machine.quotaKey(ctx, a, machine.table().branches[eventKey{"activate", "new"}][0])); used != 0 {
+		t.Errorf("quota used = %d after the rollback, want 0", used)
+	}
+}
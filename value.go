@@ -0,0 +1,45 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+)
+
+// FireValue func to fire event on a copy of s, leaving s untouched, and
+// return the copy. s may be a struct or a pointer to one; the copy is of the
+// same kind. The copy is shallow, so a state field reached through a pointer
+// (see Register) is rejected with SharedStateError. The machine must have
+// WithIdentity or the FSM WithIDFunc, so per-instance data such as vars and
+// history is kept for s rather than for each copy
+func (f *FSM) FireValue(ctx context.Context, s interface{}, event string, options ...Option) (interface{}, error) {
+	v := reflect.ValueOf(s)
+	isPtr := v.Kind() == reflect.Ptr
+	if isPtr {
+		if v.IsNil() {
+			return nil, InternalError{}
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, InternalError{}
+	}
+
+	next := reflect.New(v.Type())
+	next.Elem().Set(v)
+	if machine, ok := f.machine(next.Interface(), options...); ok {
+		if err := machine.requireIdentity("FireValue"); err != nil {
+			return nil, err
+		}
+		if access := machine.accessFor(next.Type()); access.mode != accessInvalid && throughPointer(v.Type(), access.index) {
+			return nil, SharedStateError{Type: next.Type(), Column: machine.column}
+		}
+	}
+	if err := f.Fire(ctx, next.Interface(), event, options...); err != nil {
+		return nil, err
+	}
+
+	if isPtr {
+		return next.Interface(), nil
+	}
+	return next.Elem().Interface(), nil
+}
@@ -0,0 +1,60 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFireValue(t *testing.T) {
+	fsm := NewFSM(WithIDFunc(byAddress))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "pay",
+		From: []State{"created"},
+		To:   State("paid"),
+	}}); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	order := TestStruct{State: "created"}
+	next, err := fsm.FireValue(ctx, order, "pay")
+	if err != nil || next.(TestStruct).State != "paid" || order.State != "created" {
+		t.Errorf("FireValue() = %+v, %v, original %+v", next, err, order)
+	}
+
+	ptr := &TestStruct{State: "created"}
+	next, err = fsm.FireValue(ctx, ptr, "pay")
+	if err != nil || next.(*TestStruct).State != "paid" || next == interface{}(ptr) || ptr.State != "created" {
+		t.Errorf("FireValue() on a pointer = %+v, %v, original %+v", next, err, ptr)
+	}
+
+	if _, err := fsm.FireValue(ctx, next, "pay"); !errors.As(err, new(UnknownEventError)) {
+		t.Errorf("FireValue() error = %v, want UnknownEventError", err)
+	}
+}
+
+func TestFireValueRejects(t *testing.T) {
+	tag := reflect.TypeOf((*Ticket)(nil))
+	events := Events{{Name: "approve", From: []State{"pending"}, To: State("approved")}}
+
+	fsm := NewFSM()
+	if err := fsm.Register(tag, "Status", events); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	ctx := context.Background()
+	if _, err := fsm.FireValue(ctx, Ticket{Audited: Audited{Status: "pending"}}, "approve"); !errors.As(err, new(IdentityRequiredError)) {
+		t.Errorf("FireValue() without identity error = %v, want IdentityRequiredError", err)
+	}
+
+	fsm = NewFSM(WithIDFunc(byAddress))
+	if err := fsm.Register(tag, "Meta.Review.Status", events); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+	ticket := &Ticket{}
+	ticket.Meta.Review = &struct{ Status State }{Status: "pending"}
+	if _, err := fsm.FireValue(ctx, ticket, "approve"); !errors.As(err, new(SharedStateError)) || ticket.Meta.Review.Status != "pending" {
+		t.Errorf("FireValue() through a pointer error = %v, original %v, want SharedStateError", err, ticket.Meta.Review.Status)
+	}
+}
@@ -0,0 +1,201 @@
+package fsm
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// varDecl is an extended-state variable declared with DeclareVar.
+type varDecl struct {
+	name    string
+	initial interface{}
+	typ     reflect.Type
+}
+
+// DeclareVar declares an extended-state variable of the type of initial,
+// e.g. a retry counter or an assignee. Variables are kept per instance in
+// the StateStore and exposed to guards and callbacks as Event.Vars. Register
+// fails with IdentityRequiredError without WithIdentity or WithIDFunc.
+func DeclareVar(name string, initial interface{}) RegisterOption {
+	return func(f *fsm) {
+		f.vars[name] = varDecl{name: name, initial: initial, typ: reflect.TypeOf(initial)}
+	}
+}
+
+// Vars holds the extended-state variables of one instance.
+type Vars struct {
+	decls   map[string]varDecl
+	values  map[string]interface{}
+	changed map[string]bool
+	// version is the DeltaStore version the values were loaded at.
+	version uint64
+}
+
+// Get returns the value of the variable name, its initial value if it was
+// never set, or nil if it is not declared.
+func (v *Vars) Get(name string) interface{} {
+	if value, ok := v.values[name]; ok {
+		return value
+	}
+	return v.decls[name].initial
+}
+
+// Set changes the variable name. Changes made by guards and callbacks are
+// persisted once the transition succeeds.
+func (v *Vars) Set(name string, value interface{}) error {
+	decl, ok := v.decls[name]
+	if !ok {
+		return UnknownVarError{Name: name}
+	}
+
+	if reflect.TypeOf(value) != decl.typ {
+		return VarTypeError{Name: name, Type: decl.typ.String()}
+	}
+
+	v.values[name] = value
+	v.changed[name] = true
+	return nil
+}
+
+// Names returns the declared variable names in sorted order.
+func (v *Vars) Names() []string {
+	names := make([]string, 0, len(v.decls))
+	for name := range v.decls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Var returns the variable name as T, the zero value if the variable is not
+// declared with type T.
+func Var[T any](v *Vars, name string) T {
+	value, _ := v.Get(name).(T)
+	return value
+}
+
+func (f *fsm) varsKey(s interface{}) string {
+	return "vars:" + f.instanceKey(s)
+}
+
+// loadVars reads the variables of s, nil if the machine declares none.
+func (f *fsm) loadVars(ctx context.Context, s interface{}) (*Vars, error) {
+	if len(f.vars) == 0 {
+		return nil, nil
+	}
+
+	v := &Vars{decls: f.vars, values: make(map[string]interface{}), changed: make(map[string]bool)}
+
+	var raw map[string][]byte
+	if ds, ok := f.parent.store.(DeltaStore); ok {
+		fields, version, err := ds.LoadFields(ctx, f.varsKey(s))
+		if err != nil {
+			return nil, err
+		}
+		raw, v.version = fields, version
+	} else {
+		data, err := f.parent.store.Load(ctx, f.varsKey(s))
+		if err != nil || data == nil {
+			return v, err
+		}
+
+		var msgs map[string]json.RawMessage
+		if err := json.Unmarshal(data, &msgs); err != nil {
+			return nil, err
+		}
+		raw = make(map[string][]byte, len(msgs))
+		for name, msg := range msgs {
+			raw[name] = msg
+		}
+	}
+
+	for name, msg := range raw {
+		decl, ok := f.vars[name]
+		if !ok {
+			continue
+		}
+
+		ptr := reflect.New(decl.typ)
+		if err := json.Unmarshal(msg, ptr.Interface()); err != nil {
+			return nil, err
+		}
+		v.values[name] = ptr.Elem().Interface()
+	}
+
+	return v, nil
+}
+
+// saveVars persists v if it changed since it was loaded. With a DeltaStore
+// only the changed variables are written, failing with VersionConflictError
+// if they were changed concurrently.
+func (f *fsm) saveVars(ctx context.Context, s interface{}, v *Vars) error {
+	if v == nil || len(v.changed) == 0 {
+		return nil
+	}
+
+	ds, ok := f.parent.store.(DeltaStore)
+	if !ok {
+		data, err := json.Marshal(v.values)
+		if err != nil {
+			return err
+		}
+
+		if err := f.parent.store.Save(ctx, f.varsKey(s), data, 0); err != nil {
+			return err
+		}
+		clear(v.changed)
+		return nil
+	}
+
+	fields := make(map[string][]byte, len(v.changed))
+	for name := range v.changed {
+		data, err := json.Marshal(v.values[name])
+		if err != nil {
+			return err
+		}
+		fields[name] = data
+	}
+
+	version, err := ds.SaveFields(ctx, f.varsKey(s), fields, v.version)
+	if err != nil {
+		return err
+	}
+	v.version = version
+	clear(v.changed)
+	return nil
+}
+
+// Vars func to return the extended-state variables of s
+func (f *FSM) Vars(ctx context.Context, s interface{}) (*Vars, error) {
+	machine, ok := f.machine(s)
+	if !ok {
+		return nil, InternalError{}
+	}
+
+	return machine.loadVars(ctx, s)
+}
+
+// SetVar func to change an extended-state variable of s outside a transition
+func (f *FSM) SetVar(ctx context.Context, s interface{}, name string, value interface{}) error {
+	machine, ok := f.machine(s)
+	if !ok {
+		return InternalError{}
+	}
+
+	return machine.exec(ctx, s, func() error {
+		v, err := machine.loadVars(ctx, s)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return UnknownVarError{Name: name}
+		}
+
+		if err := v.Set(name, value); err != nil {
+			return err
+		}
+		return machine.saveVars(ctx, s, v)
+	})
+}
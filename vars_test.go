@@ -0,0 +1,78 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExtendedStateVars(t *testing.T) {
+	fsm := NewFSM(WithIDFunc(byAddress))
+	if err := fsm.Register(reflect.TypeOf((*TestStruct)(nil)), "State", Events{{
+		Name: "retry",
+		From: []State{"failed"},
+		To:   State("failed"),
+		Guards: []Guard{func(ctx context.Context, e *Event) (bool, error) {
+			return Var[int](e.Vars, "retries") < 2, nil
+		}},
+		After: func(ctx context.Context, e *Event) error {
+			return e.Vars.Set("retries", Var[int](e.Vars, "retries")+1)
+		},
+	}}, DeclareVar("retries", 0), DeclareVar("assignee", "")); err != nil {
+		t.Errorf("fsm.Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	testStruct := &TestStruct{State: State("failed")}
+
+	for i := 0; i < 2; i++ {
+		if err := fsm.Fire(ctx, testStruct, "retry"); err != nil {
+			t.Errorf("Fire() #%d error = %v", i, err)
+		}
+	}
+
+	if err := fsm.Fire(ctx, testStruct, "retry"); !errors.As(err, new(InvalidTransitionError)) {
+		t.Errorf("expected 'InvalidTransitionError' after 2 retries, got %v", err)
+	}
+
+	if err := fsm.SetVar(ctx, testStruct, "assignee", "alice"); err != nil {
+		t.Errorf("fsm.SetVar() error = %v", err)
+	}
+	if err := fsm.SetVar(ctx, testStruct, "assignee", 42); !errors.As(err, new(VarTypeError)) {
+		t.Errorf("expected 'VarTypeError', got %v", err)
+	}
+
+	vars, err := fsm.Vars(ctx, testStruct)
+	if err != nil {
+		t.Errorf("fsm.Vars() error = %v", err)
+	}
+
+	if Var[int](vars, "retries") != 2 || Var[string](vars, "assignee") != "alice" {
+		t.Errorf("unexpected vars retries=%v assignee=%v", vars.Get("retries"), vars.Get("assignee"))
+	}
+}
+
+func TestRuntimeDataRequiresIdentity(t *testing.T) {
+	tag := reflect.TypeOf((*TestStruct)(nil))
+	cases := map[string]struct {
+		events  Events
+		options []RegisterOption
+	}{
+		"DeclareVar": {Events{{Name: "go", From: []State{"a"}, To: "b"}}, []RegisterOption{DeclareVar("n", 0)}},
+		"Unique":     {Events{{Name: "go", From: []State{"a"}, To: "b"}}, []RegisterOption{Unique("b", func(s interface{}) string { return "" })}},
+		"Quota":      {Events{{Name: "go", From: []State{"a"}, To: "b", Quota: 1}}, nil},
+		"Throttle":   {Events{{Name: "go", From: []State{"a"}, To: "b", Throttle: Throttle{MinInterval: time.Second}}}, nil},
+	}
+
+	for option, c := range cases {
+		var ierr IdentityRequiredError
+		if err := NewFSM().Register(tag, "State", c.events, c.options...); !errors.As(err, &ierr) || ierr.Option != option {
+			t.Errorf("Register() with %s error = %v, want IdentityRequiredError", option, err)
+		}
+		if err := NewFSM(WithIDFunc(byAddress)).Register(tag, "State", c.events, c.options...); err != nil {
+			t.Errorf("Register() with %s and WithIDFunc error = %v", option, err)
+		}
+	}
+}
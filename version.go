@@ -0,0 +1,198 @@
+package fsm
+
+import (
+	"context"
+	"maps"
+	"reflect"
+)
+
+// versionSet holds the registered versions of the machine of a type. It is
+// replaced as a whole when changed, so it can be read without f.mu.
+type versionSet struct {
+	field      string
+	machines   map[int]*fsm
+	latest     int
+	migrations map[int]map[State]State
+}
+
+func (v *versionSet) clone() *versionSet {
+	c := *v
+	c.machines = maps.Clone(v.machines)
+	c.migrations = maps.Clone(v.migrations)
+	return &c
+}
+
+// WithVersion fires on, or queries, the given version of the machine
+// instead of the one named by the version field of the instance, see
+// FSM.RegisterVersion.
+func WithVersion(version int) Option {
+	return func(args *Options) {
+		args.Version = version
+	}
+}
+
+// RegisterVersion func to register version of the machine of tag. Versions
+// start at 1; the highest one is the default machine of tag, used for
+// instances whose version is unknown, e.g. new ones. An instance uses the
+// version named by its version field, see SetVersionField, unless
+// WithVersion selects another. Instances of a version that is not
+// registered fail with InternalError. MarkFinal, OnComplete, SetInitial,
+// OnEnter, OnAnyTransition, Invariant, Propagate and Freeze apply to every
+// version registered before they are called. Versions share the instance
+// locks of the first one, so Migrate excludes Fire on any version
+func (f *FSM) RegisterVersion(tag reflect.Type, version int, column string, events []EventTransition, options ...RegisterOption) error {
+	if version < 1 {
+		return UnknownVersionError{Type: tag, Version: version}
+	}
+
+	machine, err := newFSM(f, tag, column, events, options...)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	set := &versionSet{machines: map[int]*fsm{}, migrations: map[int]map[State]State{}}
+	if old, ok := f.versions[tag]; ok {
+		set = old.clone()
+		// Share the instance locks and actors of the versions, so that Fire
+		// on one version excludes Migrate of the instance from another.
+		existing := old.machines[old.latest]
+		machine.instanceLocks, machine.actors = existing.instanceLocks, existing.actors
+	}
+	set.machines[version] = machine
+	if version >= set.latest {
+		set.latest = version
+		f.install(tag, machine)
+	}
+	f.versions[tag] = set
+	f.generation.Add(1)
+	return nil
+}
+
+// SetVersionField func to read the version of instances of tag from the
+// integer field named field
+func (f *FSM) SetVersionField(tag reflect.Type, field string) error {
+	return f.updateVersions(tag, func(set *versionSet) error {
+		set.field = field
+		return nil
+	})
+}
+
+// RegisterMigration func to map the states of instances of tag from version
+// from to version from+1 when migrated with Migrate. States missing from
+// states are kept
+func (f *FSM) RegisterMigration(tag reflect.Type, from int, states map[State]State) error {
+	return f.updateVersions(tag, func(set *versionSet) error {
+		set.migrations[from] = maps.Clone(states)
+		return nil
+	})
+}
+
+func (f *FSM) updateVersions(tag reflect.Type, fn func(set *versionSet) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	old, ok := f.versions[tag]
+	if !ok {
+		return InternalError{}
+	}
+
+	set := old.clone()
+	if err := fn(set); err != nil {
+		return err
+	}
+	f.versions[tag] = set
+	f.generation.Add(1)
+	return nil
+}
+
+// versionSet returns the versions of tag, nil if it has none.
+func (f *FSM) versionSet(tag reflect.Type) *versionSet {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.versions[tag]
+}
+
+// version returns the version field of s, zero if there is none.
+func (v *versionSet) version(s interface{}) int {
+	if v.field == "" {
+		return 0
+	}
+
+	field := reflect.ValueOf(s).Elem().FieldByName(v.field)
+	if !field.IsValid() || !field.CanInt() {
+		return 0
+	}
+	return int(field.Int())
+}
+
+func (v *versionSet) setVersion(s interface{}, version int) {
+	field := reflect.ValueOf(s).Elem().FieldByName(v.field)
+	if field.IsValid() && field.CanInt() {
+		field.SetInt(int64(version))
+	}
+}
+
+// machine returns the version of the machine s uses.
+func (v *versionSet) machine(s interface{}, options []Option) (*fsm, bool) {
+	args := &Options{}
+	for _, option := range options {
+		option(args)
+	}
+
+	version := args.Version
+	if version == 0 {
+		version = v.version(s)
+	}
+	if version == 0 {
+		version = v.latest
+	}
+
+	m, ok := v.machines[version]
+	return m, ok
+}
+
+// Migrate func to move s to the latest version of its machine, one version
+// at a time, mapping its state with the migrations registered for each
+// step and updating its version field. Instances without a version are
+// considered to be at the latest one
+func (f *FSM) Migrate(ctx context.Context, s interface{}) error {
+	tag := reflect.TypeOf(s)
+	set := f.versionSet(tag)
+	if set == nil || set.field == "" {
+		return InternalError{}
+	}
+
+	version := set.version(s)
+	if version == 0 {
+		return nil
+	}
+
+	machine, ok := set.machines[version]
+	if !ok {
+		return UnknownVersionError{Type: tag, Version: version}
+	}
+
+	return machine.exec(ctx, s, func() error {
+		for ; version < set.latest; version++ {
+			from, to := set.machines[version], set.machines[version+1]
+			if to == nil {
+				return UnknownVersionError{Type: tag, Version: version + 1}
+			}
+
+			field, state, err := from.getSourceState(s)
+			if err != nil {
+				return err
+			}
+			if mapped, ok := set.migrations[version][state]; ok {
+				if err := to.setState(field, to.accessFor(tag), mapped); err != nil {
+					return err
+				}
+			}
+			set.setVersion(s, version+1)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,112 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type VersionedStruct struct {
+	State   State
+	Version int
+}
+
+func registerVersions(t *testing.T) *FSM {
+	tag := reflect.TypeOf((*VersionedStruct)(nil))
+	fsm := NewFSM()
+	if err := fsm.RegisterVersion(tag, 1, "State", Events{{
+		Name: "approve",
+		From: []State{"pending"},
+		To:   State("approved"),
+	}}); err != nil {
+		t.Errorf("fsm.RegisterVersion() error = %v", err)
+	}
+	if err := fsm.RegisterVersion(tag, 2, "State", Events{{
+		Name: "review",
+		From: []State{"submitted"},
+		To:   State("in_review"),
+	}, {
+		Name: "approve",
+		From: []State{"in_review"},
+		To:   State("approved"),
+	}}); err != nil {
+		t.Errorf("fsm.RegisterVersion() error = %v", err)
+	}
+	if err := fsm.SetVersionField(tag, "Version"); err != nil {
+		t.Errorf("SetVersionField() error = %v", err)
+	}
+	if err := fsm.RegisterMigration(tag, 1, map[State]State{"pending": "submitted"}); err != nil {
+		t.Errorf("RegisterMigration() error = %v", err)
+	}
+	return fsm
+}
+
+func TestRegisterVersion(t *testing.T) {
+	fsm := registerVersions(t)
+	ctx := context.Background()
+
+	old := &VersionedStruct{State: "pending", Version: 1}
+	if err := fsm.Fire(ctx, old, "approve"); err != nil {
+		t.Errorf("Fire() on version 1 error = %v", err)
+	}
+
+	current := &VersionedStruct{State: "submitted"}
+	if err := fsm.Fire(ctx, current, "review"); err != nil {
+		t.Errorf("Fire() on the latest version error = %v", err)
+	}
+
+	if ok, _ := fsm.MayFire(ctx, &VersionedStruct{State: "pending", Version: 2}, "approve", WithVersion(1)); !ok {
+		t.Errorf("MayFire(WithVersion(1)) = false")
+	}
+	if err := fsm.Fire(ctx, &VersionedStruct{State: "pending", Version: 3}, "approve"); err == nil {
+		t.Errorf("Fire() on an unknown version error = nil")
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	fsm := registerVersions(t)
+	ctx := context.Background()
+
+	s := &VersionedStruct{State: "pending", Version: 1}
+	if err := fsm.Migrate(ctx, s); err != nil {
+		t.Errorf("Migrate() error = %v", err)
+	}
+	if s.State != "submitted" || s.Version != 2 {
+		t.Errorf("Migrate() = %v at %d, want submitted at 2", s.State, s.Version)
+	}
+	if err := fsm.Fire(ctx, s, "review"); err != nil {
+		t.Errorf("Fire() after Migrate() error = %v", err)
+	}
+}
+
+func TestVersionSettings(t *testing.T) {
+	fsm := registerVersions(t)
+	tag := reflect.TypeOf((*VersionedStruct)(nil))
+	if err := fsm.MarkFinal(tag, "approved"); err != nil {
+		t.Errorf("MarkFinal() error = %v", err)
+	}
+	if err := fsm.Freeze(tag); err != nil {
+		t.Errorf("Freeze() error = %v", err)
+	}
+
+	ctx := context.Background()
+	old := &VersionedStruct{State: "pending", Version: 1}
+	if err := fsm.Fire(ctx, old, "approve"); !errors.As(err, new(MachineFrozenError)) {
+		t.Errorf("Fire() on version 1 error = %v, want MachineFrozenError", err)
+	}
+	if err := fsm.Unfreeze(tag); err != nil {
+		t.Errorf("Unfreeze() error = %v", err)
+	}
+	if err := fsm.Fire(ctx, old, "approve"); err != nil {
+		t.Errorf("Fire() on version 1 error = %v", err)
+	}
+	if err := fsm.Fire(ctx, old, "approve"); !errors.As(err, new(MachineCompletedError)) {
+		t.Errorf("Fire() in a final state of version 1 error = %v, want MachineCompletedError", err)
+	}
+
+	set := fsm.versionSet(tag)
+	if set.machines[1].instanceLocks != set.machines[2].instanceLocks {
+		t.Error("versions don't share their instance locks")
+	}
+}
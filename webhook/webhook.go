@@ -0,0 +1,129 @@
+// Package webhook implements an fsm.Publisher POSTing transitions as JSON to
+// HTTP endpoints.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/ceearrashee/fsm"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the request body, hex encoded
+// and prefixed with "sha256=", for endpoints with a Secret.
+const SignatureHeader = "X-Fsm-Signature"
+
+// Endpoint is a URL notified of transitions.
+type Endpoint struct {
+	URL string
+	// Secret signs requests, see SignatureHeader. Empty sends them unsigned.
+	Secret string
+	// Types and Events restrict the notifications to these machine types
+	// (fsm.TransitionEvent.Type) and events. Empty means all.
+	Types  []string
+	Events []string
+}
+
+func (e Endpoint) matches(event fsm.TransitionEvent) bool {
+	return (len(e.Types) == 0 || slices.Contains(e.Types, event.Type)) &&
+		(len(e.Events) == 0 || slices.Contains(e.Events, event.Event))
+}
+
+// StatusError is returned for a response outside the 2xx range.
+type StatusError struct {
+	URL  string
+	Code int
+}
+
+func (e StatusError) Error() string {
+	return "webhook " + e.URL + " responded " + strconv.Itoa(e.Code)
+}
+
+// Retryable reports whether err is worth retrying: any error but a 4xx
+// response other than 429 Too Many Requests. It is the default of
+// Notifier.Retry.Retryable.
+func Retryable(err error) bool {
+	var serr StatusError
+	if errors.As(err, &serr) {
+		return serr.Code >= 500 || serr.Code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// Notifier is an fsm.Publisher notifying every matching endpoint of a
+// transition, e.g. fsm.NewFSM(fsm.WithPublisher(&webhook.Notifier{...})).
+type Notifier struct {
+	Endpoints []Endpoint
+	// Client sends the requests, http.DefaultClient if nil.
+	Client *http.Client
+	// Retry retries failed requests per endpoint.
+	Retry fsm.RetryPolicy
+}
+
+// Publish implements fsm.Publisher. It returns the errors of all endpoints
+// that could not be notified, joined.
+func (n *Notifier) Publish(ctx context.Context, event fsm.TransitionEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	retry := n.Retry
+	if retry.Retryable == nil {
+		retry.Retryable = Retryable
+	}
+
+	var errs []error
+	for _, e := range n.Endpoints {
+		if !e.matches(event) {
+			continue
+		}
+		if err := retry.Do(ctx, func() error { return n.post(ctx, e, body) }); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) post(ctx context.Context, e Endpoint, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(e.Secret, body))
+	}
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return StatusError{URL: e.URL, Code: resp.StatusCode}
+	}
+	return nil
+}
+
+// Sign returns the SignatureHeader value of body for secret, e.g. for
+// receivers to compare with hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
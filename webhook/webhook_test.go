@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ceearrashee/fsm"
+)
+
+func TestNotifier(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got := r.Header.Get(SignatureHeader); got != Sign("secret", body) {
+			t.Errorf("signature = %q, want %q", got, Sign("secret", body))
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	n := &Notifier{
+		Endpoints: []Endpoint{
+			{URL: srv.URL, Secret: "secret", Events: []string{"pay"}},
+		},
+		Retry: fsm.RetryPolicy{MaxAttempts: 2},
+	}
+
+	ctx := context.Background()
+	if err := n.Publish(ctx, fsm.TransitionEvent{Type: "*Order", Event: "pay"}); err != nil {
+		t.Errorf("Publish() error = %v", err)
+	}
+	if err := n.Publish(ctx, fsm.TransitionEvent{Type: "*Order", Event: "ship"}); err != nil {
+		t.Errorf("Publish() error = %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d, want 2", got)
+	}
+}
+
+func TestNotifierClientError(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	n := &Notifier{Endpoints: []Endpoint{{URL: srv.URL}}, Retry: fsm.RetryPolicy{MaxAttempts: 3}}
+
+	err := n.Publish(context.Background(), fsm.TransitionEvent{Type: "*Order", Event: "pay"})
+	var serr StatusError
+	if !errors.As(err, &serr) || serr.Code != http.StatusBadRequest {
+		t.Errorf("Publish() error = %v, want a 400 StatusError", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d, want no retries", got)
+	}
+}